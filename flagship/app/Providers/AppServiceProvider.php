@@ -12,6 +12,11 @@ class AppServiceProvider extends ServiceProvider
     public function register(): void
     {
         // migration is handled in separate migrate service
+
+        // VALKEY_MODE=sentinel (see config/database.php)
+        $this->app->resolving('redis', function (\Illuminate\Redis\RedisManager $redis) {
+            $redis->extend('phpredis-sentinel', fn () => new \App\Services\PhpRedisSentinelConnector());
+        });
     }
 
     /**
@@ -10,6 +10,23 @@ class DeploymentQueue
     private const STREAM_KEY = 'nodepulse:deployments:stream';
     private const MAX_STREAM_BACKLOG = 2000; // Reject new deployments if stream has more than this many pending
 
+    /**
+     * Stream key as stored in Valkey
+     *
+     * In cluster mode Submarines keeps all stream keys in one hash slot
+     * ("nodepulse:deployments:stream" -> "{nodepulse}:deployments:stream"), so the key must match
+     *
+     * @return string
+     */
+    private static function streamKey(): string
+    {
+        if (config('services.submarines.valkey_mode') === 'cluster') {
+            return '{nodepulse}:' . substr(self::STREAM_KEY, strlen('nodepulse:'));
+        }
+
+        return self::STREAM_KEY;
+    }
+
     /**
      * Publish deployment job to Valkey stream
      *
@@ -22,7 +39,7 @@ class DeploymentQueue
     public static function publish(Deployment $deployment, array $serverIds, array $extraVars): string
     {
         // Check stream backlog before adding (backpressure protection)
-        $streamLength = Redis::xLen(self::STREAM_KEY);
+        $streamLength = Redis::xLen(self::streamKey());
         if ($streamLength >= self::MAX_STREAM_BACKLOG) {
             throw new \Exception("Deployment queue is overloaded ({$streamLength} pending jobs). Please try again later.");
         }
@@ -39,14 +56,14 @@ public static function publish(Deployment $deployment, array $serverIds, array $
         // XADD to Valkey stream WITHOUT MAXLEN (no auto-trimming to prevent data loss)
         // Messages are removed only after deployer workers ACK them
         $messageId = Redis::xAdd(
-            self::STREAM_KEY,
+            self::streamKey(),
             '*', // Auto-generate ID
             $message
         );
 
         \Log::info('Published deployment to Valkey stream', [
             'deployment_id' => $deployment->id,
-            'stream_key' => self::STREAM_KEY,
+            'stream_key' => self::streamKey(),
             'message_id' => $messageId,
             'stream_length' => $streamLength + 1,
         ]);
@@ -62,17 +79,17 @@ public static function publish(Deployment $deployment, array $serverIds, array $
     public static function getStreamInfo(): array
     {
         try {
-            $info = Redis::xInfo('STREAM', self::STREAM_KEY);
+            $info = Redis::xInfo('STREAM', self::streamKey());
 
             return [
-                'stream_key' => self::STREAM_KEY,
+                'stream_key' => self::streamKey(),
                 'length' => $info['length'] ?? 0,
                 'first_entry' => $info['first-entry'] ?? null,
                 'last_entry' => $info['last-entry'] ?? null,
             ];
         } catch (\Exception $e) {
             return [
-                'stream_key' => self::STREAM_KEY,
+                'stream_key' => self::streamKey(),
                 'error' => $e->getMessage(),
             ];
         }
@@ -86,7 +103,7 @@ public static function getStreamInfo(): array
     public static function getPendingCount(): int
     {
         try {
-            $info = Redis::xInfo('STREAM', self::STREAM_KEY);
+            $info = Redis::xInfo('STREAM', self::streamKey());
             return $info['length'] ?? 0;
         } catch (\Exception $e) {
             return 0;
@@ -0,0 +1,76 @@
+<?php
+
+namespace App\Services;
+
+use Illuminate\Redis\Connectors\PhpRedisConnector;
+use RedisException;
+use RedisSentinel;
+
+/**
+ * phpredis connector for Valkey Sentinel (VALKEY_MODE=sentinel)
+ *
+ * Laravel's phpredis connector only knows standalone and cluster servers, so the
+ * master is looked up from the sentinels on every (re)connect, which also follows
+ * a failover once the old master drops the connection
+ */
+class PhpRedisSentinelConnector extends PhpRedisConnector
+{
+    /**
+     * Create the Redis client for the current master
+     *
+     * @param array $config
+     * @return \Redis
+     * @throws RedisException if no sentinel knows the master
+     */
+    protected function createClient(array $config)
+    {
+        return parent::createClient(array_merge($config, $this->resolveMaster($config)));
+    }
+
+    /**
+     * Ask the sentinels, in order, for the master's address
+     *
+     * Sentinels share the data nodes' TLS settings but have their own credentials
+     * (TLS to sentinels needs phpredis 6.1+)
+     *
+     * @param array $config
+     * @return array{host: string, port: int}
+     * @throws RedisException
+     */
+    protected function resolveMaster(array $config): array
+    {
+        $master = $config['sentinel_master'] ?? 'mymaster';
+        $auth = null;
+        if (! empty($config['sentinel_password'])) {
+            $auth = ! empty($config['sentinel_username'])
+                ? [$config['sentinel_username'], $config['sentinel_password']]
+                : $config['sentinel_password'];
+        }
+
+        $lastError = null;
+        foreach ($config['sentinels'] ?? [] as $sentinel) {
+            try {
+                $client = new RedisSentinel(array_filter([
+                    'host' => $sentinel['host'],
+                    'port' => (int) $sentinel['port'],
+                    'connectTimeout' => (float) ($config['timeout'] ?? 1.0),
+                    'auth' => $auth,
+                    'ssl' => $config['context']['stream'] ?? null,
+                ], fn ($value) => $value !== null));
+
+                $address = $client->getMasterAddrByName($master);
+            } catch (RedisException $e) {
+                $lastError = $e;
+                continue;
+            }
+
+            if (is_array($address) && count($address) === 2) {
+                return ['host' => $address[0], 'port' => (int) $address[1]];
+            }
+        }
+
+        throw new RedisException(
+            "No sentinel returned an address for master {$master}".($lastError ? ": {$lastError->getMessage()}" : ''),
+        );
+    }
+}
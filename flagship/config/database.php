@@ -1,6 +1,60 @@
 <?php
 
+/*
+|--------------------------------------------------------------------------
+| Valkey Topology
+|--------------------------------------------------------------------------
+|
+| Flagship shares Valkey with Submarines, so the Redis connections below
+| follow the same VALKEY_* settings: VALKEY_MODE (standalone, sentinel or
+| cluster), VALKEY_ADDRESSES (sentinel/cluster seeds), VALKEY_TLS* and the
+| ACL user. DeploymentQueue must reach the streams the deployer reads.
+|
+*/
 
+$valkeyMode = env('VALKEY_MODE', 'standalone');
+
+// Sentinel or cluster seed nodes as host:port, comma-separated (defaults to REDIS_HOST:REDIS_PORT)
+$valkeySeeds = array_map(function (string $address) {
+    [$host, $port] = array_pad(explode(':', trim($address), 2), 2, env('REDIS_PORT', '6379'));
+
+    return ['host' => $host, 'port' => (int) $port];
+}, array_filter(explode(',', env('VALKEY_ADDRESSES') ?: env('REDIS_HOST', '127.0.0.1').':'.env('REDIS_PORT', '6379'))));
+
+// TLS (CA empty = system roots; cert/key enable mutual TLS)
+$valkeyTls = env('VALKEY_TLS', false) ? array_filter([
+    'cafile' => env('VALKEY_TLS_CA_FILE'),
+    'local_cert' => env('VALKEY_TLS_CERT_FILE'),
+    'local_pk' => env('VALKEY_TLS_KEY_FILE'),
+    'peer_name' => env('VALKEY_TLS_SERVER_NAME'),
+    'verify_peer' => true,
+    'verify_peer_name' => true,
+], fn ($value) => $value !== null && $value !== '') : null;
+
+// Standalone and sentinel connections (sentinel resolves host/port from the sentinels)
+$redisConnection = fn (string $database) => [
+    'url' => env('REDIS_URL'),
+    'host' => env('REDIS_HOST', '127.0.0.1'),
+    'username' => env('REDIS_USERNAME'),
+    'password' => env('REDIS_PASSWORD'),
+    'port' => env('REDIS_PORT', '6379'),
+    'database' => $database,
+    'scheme' => $valkeyTls !== null ? 'tls' : null,
+    'context' => $valkeyTls !== null ? ['stream' => $valkeyTls] : null,
+    'sentinels' => $valkeySeeds,
+    'sentinel_master' => env('VALKEY_SENTINEL_MASTER', 'mymaster'),
+    'sentinel_username' => env('VALKEY_SENTINEL_USERNAME'),
+    'sentinel_password' => env('VALKEY_SENTINEL_PASSWORD'),
+    'max_retries' => env('REDIS_MAX_RETRIES', 3),
+    'backoff_algorithm' => env('REDIS_BACKOFF_ALGORITHM', 'decorrelated_jitter'),
+    'backoff_base' => env('REDIS_BACKOFF_BASE', 100),
+    'backoff_cap' => env('REDIS_BACKOFF_CAP', 1000),
+];
+
+// Cluster seeds (a cluster only has database 0, the cache shares it)
+$redisClusterSeeds = array_map(fn (array $seed) => $seed + [
+    'scheme' => $valkeyTls !== null ? 'tls' : null,
+], $valkeySeeds);
 
 return [
 
@@ -157,7 +211,7 @@
     |
     */
 
-    'redis' => [
+    'redis' => $valkeyMode === 'cluster' ? [
 
         'client' => env('REDIS_CLIENT', 'phpredis'),
 
@@ -167,32 +221,37 @@
             'persistent' => env('REDIS_PERSISTENT', false),
         ],
 
-        'default' => [
-            'url' => env('REDIS_URL'),
-            'host' => env('REDIS_HOST', '127.0.0.1'),
-            'username' => env('REDIS_USERNAME'),
-            'password' => env('REDIS_PASSWORD'),
-            'port' => env('REDIS_PORT', '6379'),
-            'database' => env('REDIS_DB', '0'),
-            'max_retries' => env('REDIS_MAX_RETRIES', 3),
-            'backoff_algorithm' => env('REDIS_BACKOFF_ALGORITHM', 'decorrelated_jitter'),
-            'backoff_base' => env('REDIS_BACKOFF_BASE', 100),
-            'backoff_cap' => env('REDIS_BACKOFF_CAP', 1000),
+        'clusters' => [
+            'options' => [
+                // ACL user: phpredis authenticates cluster nodes with [user, password]
+                'password' => env('REDIS_USERNAME')
+                    ? [env('REDIS_USERNAME'), env('REDIS_PASSWORD')]
+                    : env('REDIS_PASSWORD'),
+                'context' => $valkeyTls,
+                'max_retries' => env('REDIS_MAX_RETRIES', 3),
+                'backoff_algorithm' => env('REDIS_BACKOFF_ALGORITHM', 'decorrelated_jitter'),
+                'backoff_base' => env('REDIS_BACKOFF_BASE', 100),
+                'backoff_cap' => env('REDIS_BACKOFF_CAP', 1000),
+            ],
+            'default' => $redisClusterSeeds,
+            'cache' => $redisClusterSeeds,
         ],
 
-        'cache' => [
-            'url' => env('REDIS_URL'),
-            'host' => env('REDIS_HOST', '127.0.0.1'),
-            'username' => env('REDIS_USERNAME'),
-            'password' => env('REDIS_PASSWORD'),
-            'port' => env('REDIS_PORT', '6379'),
-            'database' => env('REDIS_CACHE_DB', '1'),
-            'max_retries' => env('REDIS_MAX_RETRIES', 3),
-            'backoff_algorithm' => env('REDIS_BACKOFF_ALGORITHM', 'decorrelated_jitter'),
-            'backoff_base' => env('REDIS_BACKOFF_BASE', 100),
-            'backoff_cap' => env('REDIS_BACKOFF_CAP', 1000),
+    ] : [
+
+        // Sentinel: App\Services\PhpRedisSentinelConnector asks the sentinels for the master
+        'client' => $valkeyMode === 'sentinel' ? 'phpredis-sentinel' : env('REDIS_CLIENT', 'phpredis'),
+
+        'options' => [
+            'cluster' => env('REDIS_CLUSTER', 'redis'),
+            'prefix' => env('REDIS_PREFIX', ''), // No prefix - share keys with Submarines (Go services)
+            'persistent' => env('REDIS_PERSISTENT', false),
         ],
 
+        'default' => $redisConnection(env('REDIS_DB', '0')),
+
+        'cache' => $redisConnection(env('REDIS_CACHE_DB', '1')),
+
     ],
 
 ];
@@ -37,6 +37,8 @@
 
     'submarines' => [
         'url' => 'http://submarines-ingest:8080',
+        // Must match Submarines' VALKEY_MODE: cluster mode hash-tags stream keys
+        'valkey_mode' => env('VALKEY_MODE', 'standalone'),
     ],
 
 ];
@@ -0,0 +1,43 @@
+-- Up Migration
+-- Add uniqueness guarantees for metric and process snapshots
+-- Stream redelivery (worker dies between COMMIT and XACK) used to produce duplicate rows.
+-- Digest now inserts with ON CONFLICT, so replaying a message is harmless.
+
+-- ============================================================
+-- SECTION 1: Remove existing duplicates (keep the earliest row)
+-- ============================================================
+
+DELETE FROM admiral.metrics a
+USING admiral.metrics b
+WHERE a.server_id = b.server_id
+  AND a.timestamp = b.timestamp
+  AND a.id > b.id;
+
+DELETE FROM admiral.process_snapshots a
+USING admiral.process_snapshots b
+WHERE a.server_id = b.server_id
+  AND a.timestamp = b.timestamp
+  AND a.process_name = b.process_name
+  AND a.id > b.id;
+
+-- ============================================================
+-- SECTION 2: Unique constraints
+-- ============================================================
+
+-- One node_exporter snapshot per server per timestamp
+ALTER TABLE admiral.metrics
+    ADD CONSTRAINT uq_metrics_server_timestamp UNIQUE (server_id, timestamp);
+
+-- One process group row per server per timestamp
+ALTER TABLE admiral.process_snapshots
+    ADD CONSTRAINT uq_process_snapshots_server_timestamp_name UNIQUE (server_id, timestamp, process_name);
+
+COMMENT ON CONSTRAINT uq_metrics_server_timestamp ON admiral.metrics IS 'Makes redelivered stream messages idempotent (ON CONFLICT target)';
+COMMENT ON CONSTRAINT uq_process_snapshots_server_timestamp_name ON admiral.process_snapshots IS 'Makes redelivered stream messages idempotent (ON CONFLICT target)';
+
+
+-- Down Migration
+-- Remove snapshot uniqueness constraints
+
+ALTER TABLE admiral.process_snapshots DROP CONSTRAINT IF EXISTS uq_process_snapshots_server_timestamp_name;
+ALTER TABLE admiral.metrics DROP CONSTRAINT IF EXISTS uq_metrics_server_timestamp;
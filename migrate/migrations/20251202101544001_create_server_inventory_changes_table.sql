@@ -0,0 +1,31 @@
+-- Up Migration
+-- Create server inventory change history
+-- Digest populates admiral.servers system info (kernel, distro, IPs, ...) from the agent's
+-- optional system_info payload section and records every change here (kernel upgrades, IP moves)
+
+CREATE TABLE IF NOT EXISTS admiral.server_inventory_changes (
+    id BIGSERIAL PRIMARY KEY,
+    server_id TEXT NOT NULL, -- Agent's server_id (matches servers.server_id), no FK for flexibility
+    field TEXT NOT NULL, -- Column that changed: hostname, kernel, kernel_version, distro, distro_version, architecture, cpu_cores, ipv4, ipv6
+    old_value TEXT, -- NULL when the field was populated for the first time
+    new_value TEXT,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Indexes for per-server history and per-field queries (e.g., "all kernel upgrades this week")
+CREATE INDEX IF NOT EXISTS idx_server_inventory_changes_server ON admiral.server_inventory_changes(server_id, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_server_inventory_changes_field ON admiral.server_inventory_changes(field, changed_at DESC);
+
+-- Add comments
+COMMENT ON TABLE admiral.server_inventory_changes IS 'History of server inventory changes reported by agents (kernel upgrades, IP moves, hardware changes)';
+COMMENT ON COLUMN admiral.server_inventory_changes.field IS 'Name of the admiral.servers column that changed';
+COMMENT ON COLUMN admiral.server_inventory_changes.old_value IS 'Previous value (text representation), NULL on first population';
+COMMENT ON COLUMN admiral.server_inventory_changes.new_value IS 'New value (text representation)';
+
+
+-- Down Migration
+-- Drop server inventory change history
+
+DROP INDEX IF EXISTS admiral.idx_server_inventory_changes_field;
+DROP INDEX IF EXISTS admiral.idx_server_inventory_changes_server;
+DROP TABLE IF EXISTS admiral.server_inventory_changes;
@@ -0,0 +1,64 @@
+-- Up Migration
+-- Server online/offline state machine driven by last_seen_at
+-- Digest moves admiral.servers.status between online, stale and offline and records
+-- every transition in admiral.server_events (also published on Valkey)
+
+-- ============================================================
+-- SECTION 1: Server Columns
+-- ============================================================
+
+-- Expected reporting interval (matches agent_interval, default 15s)
+ALTER TABLE admiral.servers
+ADD COLUMN IF NOT EXISTS report_interval_seconds INTEGER NOT NULL DEFAULT 15
+CHECK (report_interval_seconds > 0);
+
+-- When the status last changed
+ALTER TABLE admiral.servers
+ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN admiral.servers.report_interval_seconds IS 'Expected agent reporting interval in seconds. Used to decide when a server is stale/offline';
+COMMENT ON COLUMN admiral.servers.status_changed_at IS 'Timestamp of the last status transition';
+
+-- ============================================================
+-- SECTION 2: Server Events Table
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.server_events (
+    id BIGSERIAL PRIMARY KEY,
+    server_id TEXT NOT NULL, -- Agent's server_id (matches servers.server_id), no FK for flexibility
+    event_type TEXT NOT NULL, -- status_change, ...
+    source TEXT NOT NULL DEFAULT 'admiral', -- admiral (generated server-side), agent (reported by agent)
+    message TEXT,
+    data JSONB DEFAULT '{}'::jsonb,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_events_server ON admiral.server_events(server_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_server_events_type ON admiral.server_events(event_type, occurred_at DESC);
+
+COMMENT ON TABLE admiral.server_events IS 'Timeline of discrete server events (status transitions, ...)';
+COMMENT ON COLUMN admiral.server_events.event_type IS 'Event type (e.g., status_change)';
+COMMENT ON COLUMN admiral.server_events.data IS 'Event-specific payload (e.g., {"from": "online", "to": "stale"})';
+
+-- ============================================================
+-- SECTION 3: Settings
+-- ============================================================
+
+INSERT INTO admiral.settings (key, value, description, tier) VALUES
+    ('server_stale_after_intervals', '4', 'Mark server stale after this many missed reporting intervals', 'free'),
+    ('server_offline_after_intervals', '20', 'Mark server offline after this many missed reporting intervals', 'free')
+ON CONFLICT (key) DO NOTHING;
+
+
+-- Down Migration
+-- Remove server status tracking
+
+DELETE FROM admiral.settings WHERE key IN ('server_stale_after_intervals', 'server_offline_after_intervals');
+
+DROP INDEX IF EXISTS admiral.idx_server_events_type;
+DROP INDEX IF EXISTS admiral.idx_server_events_server;
+DROP TABLE IF EXISTS admiral.server_events;
+
+ALTER TABLE admiral.servers DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE admiral.servers DROP COLUMN IF EXISTS report_interval_seconds;
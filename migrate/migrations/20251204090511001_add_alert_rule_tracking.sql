@@ -0,0 +1,28 @@
+-- Up Migration
+-- Link alerts to the rule that opened them
+-- Used by the submarines-alerter evaluator to find open alerts per (rule, server)
+
+ALTER TABLE admiral.alerts
+ADD COLUMN IF NOT EXISTS rule_id UUID; -- References admiral.alert_rules(id), no FK for flexibility
+
+ALTER TABLE admiral.alerts
+ADD COLUMN IF NOT EXISTS last_evaluated_at TIMESTAMP WITH TIME ZONE;
+
+-- At most one open (active or acknowledged) alert per rule and server
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_open_rule_server
+    ON admiral.alerts(rule_id, server_id)
+    WHERE status IN ('active', 'acknowledged');
+
+CREATE INDEX IF NOT EXISTS idx_alerts_rule_id ON admiral.alerts(rule_id);
+
+COMMENT ON COLUMN admiral.alerts.rule_id IS 'Rule that opened this alert (NULL for alerts not created by the evaluator)';
+COMMENT ON COLUMN admiral.alerts.last_evaluated_at IS 'Last time the evaluator confirmed the condition (current_value refreshed)';
+
+
+-- Down Migration
+-- Remove rule tracking from alerts
+
+DROP INDEX IF EXISTS admiral.idx_alerts_rule_id;
+DROP INDEX IF EXISTS admiral.idx_alerts_open_rule_server;
+ALTER TABLE admiral.alerts DROP COLUMN IF EXISTS last_evaluated_at;
+ALTER TABLE admiral.alerts DROP COLUMN IF EXISTS rule_id;
@@ -0,0 +1,75 @@
+-- Up Migration
+-- Alert notification channels and delivery log
+-- The submarines-alerter notifier consumes alert state changes (nodepulse:alerts:stream)
+-- and delivers them to every enabled channel that matches the alert
+
+-- ============================================================
+-- SECTION 1: Notification Channels
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.notification_channels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK (type IN ('webhook', 'email', 'slack', 'discord')),
+
+    -- Channel configuration (type-specific)
+    -- webhook: {"url": "...", "secret": "...", "headers": {"X-Custom": "..."}}
+    -- email:   {"host": "...", "port": 587, "username": "...", "password": "...", "from": "...", "to": ["..."], "tls": "starttls|tls|none"}
+    -- slack/discord: {"url": "https://hooks.slack.com/..."}
+    config JSONB NOT NULL DEFAULT '{}'::jsonb,
+
+    -- Templates (Go text/template syntax, empty = built-in default)
+    subject_template TEXT,
+    body_template TEXT,
+
+    -- Routing
+    min_severity TEXT NOT NULL DEFAULT 'info' CHECK (min_severity IN ('info', 'warning', 'critical')),
+    events JSONB NOT NULL DEFAULT '["opened", "resolved"]'::jsonb, -- Alert event types to deliver
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_channels_enabled ON admiral.notification_channels(enabled);
+
+CREATE TRIGGER update_notification_channels_updated_at
+    BEFORE UPDATE ON admiral.notification_channels
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.notification_channels IS 'Alert notification destinations (webhook, email, Slack, Discord)';
+COMMENT ON COLUMN admiral.notification_channels.config IS 'Type-specific configuration. WARNING: contains secrets (webhook HMAC secret, SMTP password)';
+COMMENT ON COLUMN admiral.notification_channels.subject_template IS 'Go text/template for the subject/title line (empty = default)';
+COMMENT ON COLUMN admiral.notification_channels.body_template IS 'Go text/template for the message body (empty = default)';
+COMMENT ON COLUMN admiral.notification_channels.min_severity IS 'Only deliver alerts with at least this severity';
+
+-- ============================================================
+-- SECTION 2: Notification Deliveries (one row per attempt)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    channel_id UUID NOT NULL, -- References admiral.notification_channels(id), no FK for flexibility
+    alert_id UUID NOT NULL, -- References admiral.alerts(id), no FK for flexibility
+    event_type TEXT NOT NULL, -- opened, resolved, ...
+    attempt INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('success', 'failed')),
+    response_code INTEGER, -- HTTP status (webhook/slack/discord) or SMTP reply code
+    error TEXT,
+    duration_ms INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_alert ON admiral.notification_deliveries(alert_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_channel ON admiral.notification_deliveries(channel_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_status ON admiral.notification_deliveries(status);
+
+COMMENT ON TABLE admiral.notification_deliveries IS 'Alert notification delivery attempts (one row per attempt, including retries)';
+
+
+-- Down Migration
+-- Drop notification tables
+
+DROP TABLE IF EXISTS admiral.notification_deliveries;
+DROP TABLE IF EXISTS admiral.notification_channels CASCADE;
@@ -0,0 +1,20 @@
+-- Up Migration
+-- Alert event outbox
+-- Alert state changes write their notification event in the same transaction; the alerter
+-- relays the outbox to nodepulse:alerts:stream, so a committed alert always gets notified
+
+CREATE TABLE IF NOT EXISTS admiral.alert_event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    alert_id UUID NOT NULL, -- References admiral.alerts(id), no FK for flexibility
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL, -- Serialized alert event
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE admiral.alert_event_outbox IS 'Alert events committed but not yet published to the alert events stream';
+
+
+-- Down Migration
+-- Drop alert event outbox
+
+DROP TABLE IF EXISTS admiral.alert_event_outbox;
@@ -0,0 +1,118 @@
+-- Up Migration
+-- Alert silences (one-off) and maintenance windows (recurring)
+-- While a silence or window matches a firing rule, the alerter either suppresses
+-- the alert (nothing is created) or downgrades its severity by one level
+--
+-- Matchers: server_id, tag, alert_type, rule_name
+-- All non-NULL matchers must match; a row with no matchers matches every alert
+
+-- ============================================================
+-- SECTION 1: Silences
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.alert_silences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Matchers (NULL = any)
+    server_id TEXT, -- admiral.servers.id or agent server_id
+    tag TEXT, -- Server tag
+    alert_type TEXT, -- Rule metric_type (cpu_usage, disk_usage, ...)
+    rule_name TEXT, -- admiral.alert_rules.name
+
+    action TEXT NOT NULL DEFAULT 'suppress' CHECK (action IN ('suppress', 'downgrade')),
+
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    comment TEXT,
+    created_by BIGINT, -- References admiral.users(id), no FK for flexibility
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_alert_silences_range CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_silences_active ON admiral.alert_silences(ends_at, starts_at);
+
+CREATE TRIGGER update_alert_silences_updated_at
+    BEFORE UPDATE ON admiral.alert_silences
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.alert_silences IS 'One-off alert silences between starts_at and ends_at';
+COMMENT ON COLUMN admiral.alert_silences.action IS 'suppress = do not create alerts, downgrade = create with severity lowered one level';
+
+-- ============================================================
+-- SECTION 2: Maintenance Windows
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+
+    -- Matchers (NULL = any)
+    server_id TEXT,
+    tag TEXT,
+    alert_type TEXT,
+    rule_name TEXT,
+
+    action TEXT NOT NULL DEFAULT 'suppress' CHECK (action IN ('suppress', 'downgrade')),
+
+    -- Schedule (e.g. Sundays 02:00-04:00: days_of_week = [0], start_time = 02:00, end_time = 04:00)
+    days_of_week JSONB NOT NULL DEFAULT '[]'::jsonb, -- 0 = Sunday ... 6 = Saturday, empty = every day
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL, -- end_time <= start_time means the window ends the next day
+    timezone TEXT NOT NULL DEFAULT 'UTC', -- IANA name
+
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    comment TEXT,
+    created_by BIGINT, -- References admiral.users(id), no FK for flexibility
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_enabled ON admiral.maintenance_windows(enabled);
+
+CREATE TRIGGER update_maintenance_windows_updated_at
+    BEFORE UPDATE ON admiral.maintenance_windows
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.maintenance_windows IS 'Recurring weekly maintenance windows that silence matching alerts';
+COMMENT ON COLUMN admiral.maintenance_windows.days_of_week IS 'Days the window starts on (0 = Sunday), empty = every day';
+
+-- ============================================================
+-- SECTION 3: Suppression Log
+-- ============================================================
+
+-- One row per (rule, server, silence/window occurrence); repeated evaluations bump hit_count
+CREATE TABLE IF NOT EXISTS admiral.alert_suppressions (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id UUID NOT NULL, -- References admiral.alert_rules(id), no FK for flexibility
+    server_id UUID NOT NULL, -- References admiral.servers(id), no FK for flexibility
+    source_type TEXT NOT NULL CHECK (source_type IN ('silence', 'maintenance_window')),
+    source_id UUID NOT NULL,
+    occurrence_start TIMESTAMP WITH TIME ZONE NOT NULL, -- Silence starts_at or window occurrence start
+    reason TEXT NOT NULL,
+    last_value NUMERIC(10,2),
+    hit_count INTEGER NOT NULL DEFAULT 1,
+    first_suppressed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_suppressed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_alert_suppressions_occurrence UNIQUE (rule_id, server_id, source_type, source_id, occurrence_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_suppressions_server ON admiral.alert_suppressions(server_id, last_suppressed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alert_suppressions_source ON admiral.alert_suppressions(source_type, source_id);
+
+COMMENT ON TABLE admiral.alert_suppressions IS 'Alerts that would have fired but were suppressed by a silence or maintenance window';
+
+
+-- Down Migration
+-- Drop silence tables
+
+DROP TABLE IF EXISTS admiral.alert_suppressions;
+DROP TABLE IF EXISTS admiral.maintenance_windows CASCADE;
+DROP TABLE IF EXISTS admiral.alert_silences CASCADE;
@@ -0,0 +1,60 @@
+-- Up Migration
+-- Alert state transition history (timeline) and acknowledgment attribution
+-- Every transition (opened, acknowledged, unacknowledged, resolved) is appended here,
+-- by the alerter or by a user through the internal alerts API
+
+-- ============================================================
+-- SECTION 1: Attribution columns on alerts
+-- ============================================================
+
+ALTER TABLE admiral.alerts
+    ADD COLUMN IF NOT EXISTS acknowledged_by BIGINT, -- References admiral.users(id), no FK for flexibility
+    ADD COLUMN IF NOT EXISTS resolved_by BIGINT; -- NULL when resolved automatically
+
+COMMENT ON COLUMN admiral.alerts.acknowledged_by IS 'User who acknowledged the alert (admiral.users.id)';
+COMMENT ON COLUMN admiral.alerts.resolved_by IS 'User who manually resolved the alert (NULL = resolved by the alerter)';
+
+-- ============================================================
+-- SECTION 2: Alert Timeline
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.alert_timeline (
+    id BIGSERIAL PRIMARY KEY,
+    alert_id UUID NOT NULL, -- References admiral.alerts(id), no FK for flexibility
+    event_type TEXT NOT NULL CHECK (event_type IN ('opened', 'acknowledged', 'unacknowledged', 'resolved')),
+    from_status TEXT, -- NULL for opened
+    to_status TEXT NOT NULL,
+    user_id BIGINT, -- References admiral.users(id), NULL = alerter
+    comment TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_timeline_alert ON admiral.alert_timeline(alert_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_alert_timeline_event_type ON admiral.alert_timeline(event_type, created_at DESC);
+
+COMMENT ON TABLE admiral.alert_timeline IS 'Append-only alert state transitions (used for postmortems and MTTA/MTTR)';
+
+-- Backfill existing alerts so MTTA/MTTR include history
+INSERT INTO admiral.alert_timeline (alert_id, event_type, from_status, to_status, comment, created_at)
+SELECT id, 'opened', NULL, 'active', 'backfilled', created_at
+FROM admiral.alerts;
+
+INSERT INTO admiral.alert_timeline (alert_id, event_type, from_status, to_status, comment, created_at)
+SELECT id, 'acknowledged', 'active', 'acknowledged', 'backfilled', acknowledged_at
+FROM admiral.alerts
+WHERE acknowledged_at IS NOT NULL;
+
+INSERT INTO admiral.alert_timeline (alert_id, event_type, from_status, to_status, comment, created_at)
+SELECT id, 'resolved', CASE WHEN acknowledged_at IS NOT NULL THEN 'acknowledged' ELSE 'active' END, 'resolved', 'backfilled', resolved_at
+FROM admiral.alerts
+WHERE resolved_at IS NOT NULL;
+
+
+-- Down Migration
+-- Drop alert timeline and attribution columns
+
+DROP TABLE IF EXISTS admiral.alert_timeline;
+
+ALTER TABLE admiral.alerts
+    DROP COLUMN IF EXISTS resolved_by,
+    DROP COLUMN IF EXISTS acknowledged_by;
@@ -0,0 +1,116 @@
+-- Up Migration
+-- Alert notification grouping and flap detection
+-- Alerts of the same rule that share group labels (e.g. tag or metadata.datacenter)
+-- are batched into one notification; flapping alerts are held back until they stabilise
+
+-- ============================================================
+-- SECTION 1: Rule grouping and flap settings
+-- ============================================================
+
+ALTER TABLE admiral.alert_rules
+    ADD COLUMN IF NOT EXISTS group_by JSONB NOT NULL DEFAULT '[]'::jsonb,
+    ADD COLUMN IF NOT EXISTS group_wait_seconds INTEGER NOT NULL DEFAULT 30,
+    ADD COLUMN IF NOT EXISTS group_interval_seconds INTEGER NOT NULL DEFAULT 300,
+    ADD COLUMN IF NOT EXISTS flap_window_seconds INTEGER NOT NULL DEFAULT 1800,
+    ADD COLUMN IF NOT EXISTS flap_threshold INTEGER NOT NULL DEFAULT 4;
+
+COMMENT ON COLUMN admiral.alert_rules.group_by IS 'Group labels: "tag", "server", "metadata.<key>" (empty = one group per rule)';
+COMMENT ON COLUMN admiral.alert_rules.group_wait_seconds IS 'Wait before the first notification of a new group';
+COMMENT ON COLUMN admiral.alert_rules.group_interval_seconds IS 'Minimum time between notifications of the same group';
+COMMENT ON COLUMN admiral.alert_rules.flap_window_seconds IS 'Window for counting state changes; also the quiet period required to stop flapping';
+COMMENT ON COLUMN admiral.alert_rules.flap_threshold IS 'State changes within flap_window_seconds that mark an alert as flapping (0 = disabled)';
+
+-- ============================================================
+-- SECTION 2: Flap State (per rule and server)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.alert_flap_state (
+    rule_id UUID NOT NULL, -- References admiral.alert_rules(id), no FK for flexibility
+    server_id UUID NOT NULL, -- References admiral.servers(id), no FK for flexibility
+    flapping BOOLEAN NOT NULL DEFAULT FALSE,
+    flapping_since TIMESTAMP WITH TIME ZONE,
+    last_transition_at TIMESTAMP WITH TIME ZONE,
+    last_notified_type TEXT, -- Last event type sent to the notifier (opened, resolved)
+    held_event JSONB, -- Latest event withheld while flapping
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (rule_id, server_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_flap_state_flapping ON admiral.alert_flap_state(flapping) WHERE flapping;
+
+CREATE TRIGGER update_alert_flap_state_updated_at
+    BEFORE UPDATE ON admiral.alert_flap_state
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.alert_flap_state IS 'Flap detection state; notifications are held while flapping';
+
+-- ============================================================
+-- SECTION 3: Notification Groups and Queue
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.notification_groups (
+    group_key TEXT PRIMARY KEY,
+    rule_id UUID,
+    labels JSONB NOT NULL DEFAULT '{}'::jsonb,
+    group_wait_seconds INTEGER NOT NULL DEFAULT 0,
+    group_interval_seconds INTEGER NOT NULL DEFAULT 0,
+    next_flush_at TIMESTAMP WITH TIME ZONE, -- NULL = nothing queued
+    last_flushed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_groups_next_flush ON admiral.notification_groups(next_flush_at) WHERE next_flush_at IS NOT NULL;
+
+CREATE TRIGGER update_notification_groups_updated_at
+    BEFORE UPDATE ON admiral.notification_groups
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS admiral.notification_queue (
+    id BIGSERIAL PRIMARY KEY,
+    group_key TEXT NOT NULL, -- References admiral.notification_groups(group_key)
+    alert_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL, -- Serialized alert event
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_queue_group ON admiral.notification_queue(group_key, id);
+
+COMMENT ON TABLE admiral.notification_groups IS 'Notification groups with their next flush time (group wait/interval)';
+COMMENT ON TABLE admiral.notification_queue IS 'Alert events waiting for their group to be flushed';
+
+-- ============================================================
+-- SECTION 4: Grouped deliveries
+-- ============================================================
+
+ALTER TABLE admiral.notification_deliveries
+    ALTER COLUMN alert_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS group_key TEXT,
+    ADD COLUMN IF NOT EXISTS alert_ids JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+COMMENT ON COLUMN admiral.notification_deliveries.alert_id IS 'Set when the notification covers a single alert';
+COMMENT ON COLUMN admiral.notification_deliveries.alert_ids IS 'All alerts covered by the (grouped) notification';
+
+
+-- Down Migration
+-- Drop grouping and flap detection
+
+DELETE FROM admiral.notification_deliveries WHERE alert_id IS NULL;
+
+ALTER TABLE admiral.notification_deliveries
+    DROP COLUMN IF EXISTS alert_ids,
+    DROP COLUMN IF EXISTS group_key,
+    ALTER COLUMN alert_id SET NOT NULL;
+
+DROP TABLE IF EXISTS admiral.notification_queue;
+DROP TABLE IF EXISTS admiral.notification_groups CASCADE;
+DROP TABLE IF EXISTS admiral.alert_flap_state CASCADE;
+
+ALTER TABLE admiral.alert_rules
+    DROP COLUMN IF EXISTS flap_threshold,
+    DROP COLUMN IF EXISTS flap_window_seconds,
+    DROP COLUMN IF EXISTS group_interval_seconds,
+    DROP COLUMN IF EXISTS group_wait_seconds,
+    DROP COLUMN IF EXISTS group_by;
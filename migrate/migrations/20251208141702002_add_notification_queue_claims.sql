@@ -0,0 +1,26 @@
+-- Up Migration
+-- In-flight notification queue entries
+-- Queued events stay in admiral.notification_queue while their group is being delivered
+-- and are only removed once every channel accepted them; failed groups are retried
+
+ALTER TABLE admiral.notification_queue
+    ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP WITH TIME ZONE, -- Set while a flush delivers the event (NULL = waiting)
+    ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0, -- Flushes that tried to deliver the event
+    ADD COLUMN IF NOT EXISTS delivered_channels JSONB NOT NULL DEFAULT '[]'::jsonb; -- Channel IDs that already received it
+
+-- Expired claims (notifier crashed mid-delivery)
+CREATE INDEX IF NOT EXISTS idx_notification_queue_claimed_at ON admiral.notification_queue(claimed_at) WHERE claimed_at IS NOT NULL;
+
+COMMENT ON COLUMN admiral.notification_queue.claimed_at IS 'Claim time of the flush delivering the event; expired claims are released';
+COMMENT ON COLUMN admiral.notification_queue.delivered_channels IS 'Channels skipped when a partially failed group is retried';
+
+
+-- Down Migration
+-- Drop notification queue claims
+
+DROP INDEX IF EXISTS admiral.idx_notification_queue_claimed_at;
+
+ALTER TABLE admiral.notification_queue
+    DROP COLUMN IF EXISTS delivered_channels,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,139 @@
+-- Up Migration
+-- Escalation policies and on-call schedules
+-- An alert whose rule has an escalation policy pages tier 1; if it isn't acknowledged
+-- within the tier timeout the next tier is paged, and after the last tier the policy
+-- repeats every repeat_interval_minutes
+
+-- ============================================================
+-- SECTION 1: On-call Schedules
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.oncall_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+
+    -- Rotation: participants take turns for rotation_hours each, starting at rotation_start
+    participants JSONB NOT NULL DEFAULT '[]'::jsonb, -- Ordered admiral.users ids
+    rotation_hours INTEGER NOT NULL DEFAULT 168 CHECK (rotation_hours > 0), -- 168 = weekly
+    rotation_start TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP, -- First handoff (anchor)
+
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_oncall_schedules_updated_at
+    BEFORE UPDATE ON admiral.oncall_schedules
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.oncall_schedules IS 'On-call rotations over admiral.users';
+COMMENT ON COLUMN admiral.oncall_schedules.rotation_start IS 'Handoff anchor; participant[0] is on call from this moment for rotation_hours';
+
+-- Overrides (swaps, holidays) take precedence over the rotation
+CREATE TABLE IF NOT EXISTS admiral.oncall_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID NOT NULL REFERENCES admiral.oncall_schedules(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL, -- References admiral.users(id), no FK for flexibility
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_oncall_overrides_range CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_oncall_overrides_schedule ON admiral.oncall_overrides(schedule_id, starts_at, ends_at);
+
+-- ============================================================
+-- SECTION 2: Escalation Policies
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.escalation_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+
+    repeat_interval_minutes INTEGER NOT NULL DEFAULT 0, -- Restart from tier 1 this long after the last tier (0 = no repeat)
+    max_repeats INTEGER NOT NULL DEFAULT 0, -- 0 = repeat until acknowledged
+
+    -- Email channel used as SMTP transport for user and schedule targets (NULL = first enabled email channel)
+    email_channel_id UUID, -- References admiral.notification_channels(id), no FK for flexibility
+
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_escalation_policies_updated_at
+    BEFORE UPDATE ON admiral.escalation_policies
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS admiral.escalation_tiers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    policy_id UUID NOT NULL REFERENCES admiral.escalation_policies(id) ON DELETE CASCADE,
+    tier INTEGER NOT NULL CHECK (tier > 0), -- 1 = first tier
+    timeout_minutes INTEGER NOT NULL DEFAULT 15 CHECK (timeout_minutes > 0), -- Escalate if not acknowledged within
+
+    -- Who to page: [{"type": "user", "id": 1}, {"type": "schedule", "id": "<uuid>"}, {"type": "channel", "id": "<uuid>"}]
+    targets JSONB NOT NULL DEFAULT '[]'::jsonb,
+
+    CONSTRAINT uq_escalation_tiers_policy_tier UNIQUE (policy_id, tier)
+);
+
+COMMENT ON TABLE admiral.escalation_policies IS 'Alert escalation policies (tiers paged until the alert is acknowledged)';
+COMMENT ON COLUMN admiral.escalation_tiers.targets IS 'Targets: user (email), schedule (current on-call user), channel (notification channel)';
+
+ALTER TABLE admiral.alert_rules
+    ADD COLUMN IF NOT EXISTS escalation_policy_id UUID; -- References admiral.escalation_policies(id), no FK for flexibility
+
+-- ============================================================
+-- SECTION 3: Escalation State (per alert)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.alert_escalations (
+    alert_id UUID PRIMARY KEY, -- References admiral.alerts(id), no FK for flexibility
+    policy_id UUID NOT NULL,
+    next_tier INTEGER NOT NULL DEFAULT 1, -- Tier paged at next_escalation_at
+    repeat_count INTEGER NOT NULL DEFAULT 0,
+    next_escalation_at TIMESTAMP WITH TIME ZONE, -- NULL = finished
+    last_escalated_at TIMESTAMP WITH TIME ZONE,
+    stopped_at TIMESTAMP WITH TIME ZONE,
+    stop_reason TEXT, -- acknowledged, resolved, exhausted, policy removed
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_escalations_due ON admiral.alert_escalations(next_escalation_at) WHERE stopped_at IS NULL;
+
+CREATE TRIGGER update_alert_escalations_updated_at
+    BEFORE UPDATE ON admiral.alert_escalations
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.alert_escalations IS 'Escalation progress of open alerts';
+
+-- Pages are part of the alert timeline
+ALTER TABLE admiral.alert_timeline DROP CONSTRAINT IF EXISTS alert_timeline_event_type_check;
+ALTER TABLE admiral.alert_timeline ADD CONSTRAINT alert_timeline_event_type_check
+    CHECK (event_type IN ('opened', 'acknowledged', 'unacknowledged', 'resolved', 'escalated'));
+
+
+-- Down Migration
+-- Drop escalation and on-call tables
+
+DELETE FROM admiral.alert_timeline WHERE event_type = 'escalated';
+ALTER TABLE admiral.alert_timeline DROP CONSTRAINT IF EXISTS alert_timeline_event_type_check;
+ALTER TABLE admiral.alert_timeline ADD CONSTRAINT alert_timeline_event_type_check
+    CHECK (event_type IN ('opened', 'acknowledged', 'unacknowledged', 'resolved'));
+
+DROP TABLE IF EXISTS admiral.alert_escalations;
+
+ALTER TABLE admiral.alert_rules DROP COLUMN IF EXISTS escalation_policy_id;
+
+DROP TABLE IF EXISTS admiral.escalation_tiers;
+DROP TABLE IF EXISTS admiral.escalation_policies CASCADE;
+DROP TABLE IF EXISTS admiral.oncall_overrides;
+DROP TABLE IF EXISTS admiral.oncall_schedules CASCADE;
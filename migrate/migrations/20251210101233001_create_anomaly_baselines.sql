@@ -0,0 +1,60 @@
+-- Up Migration
+-- Statistical anomaly detection
+-- Rules with condition 'anomaly' compare each value against a learned per-server baseline
+-- instead of a static threshold; threshold is the number of standard deviations (k)
+
+-- ============================================================
+-- SECTION 1: Baselines (per agent server_id and metric type)
+-- ============================================================
+
+-- Overall level: exponentially weighted moving mean and variance
+CREATE TABLE IF NOT EXISTS admiral.anomaly_baselines (
+    server_id TEXT NOT NULL, -- Agent server_id (as in admiral.metrics), no FK for flexibility
+    metric_type TEXT NOT NULL, -- cpu_usage, memory_usage, ...
+    mean DOUBLE PRECISION NOT NULL DEFAULT 0,
+    variance DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sample_count BIGINT NOT NULL DEFAULT 0,
+    last_sample_at TIMESTAMP WITH TIME ZONE, -- Newest sample folded into the baseline
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (server_id, metric_type)
+);
+
+-- Seasonal profile: one bucket per hour of the week (UTC)
+CREATE TABLE IF NOT EXISTS admiral.anomaly_seasonal_baselines (
+    server_id TEXT NOT NULL,
+    metric_type TEXT NOT NULL,
+    hour_of_week SMALLINT NOT NULL CHECK (hour_of_week BETWEEN 0 AND 167), -- 0 = Sunday 00:00-01:00 UTC
+    mean DOUBLE PRECISION NOT NULL DEFAULT 0,
+    variance DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sample_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (server_id, metric_type, hour_of_week)
+);
+
+COMMENT ON TABLE admiral.anomaly_baselines IS 'EWMA baseline per server and metric, used by anomaly alert rules';
+COMMENT ON TABLE admiral.anomaly_seasonal_baselines IS 'Hour-of-week baseline buckets per server and metric';
+
+-- ============================================================
+-- SECTION 2: Default anomaly rules
+-- ============================================================
+
+COMMENT ON COLUMN admiral.alert_rules.condition IS 'gt, lt, eq, gte, lte, or anomaly (threshold = standard deviations from the learned baseline)';
+
+INSERT INTO admiral.alert_rules (name, description, metric_type, condition, threshold, duration_seconds, severity) VALUES
+    ('CPU Usage Anomaly', 'CPU usage deviates more than 4 standard deviations from its usual level for this hour of the week', 'cpu_usage', 'anomaly', 4.0, 600, 'warning'),
+    ('Memory Usage Anomaly', 'Memory usage deviates more than 4 standard deviations from its usual level for this hour of the week', 'memory_usage', 'anomaly', 4.0, 600, 'warning'),
+    ('Load Anomaly', '5 minute load average deviates more than 4 standard deviations from its usual level for this hour of the week', 'load_5min', 'anomaly', 4.0, 600, 'warning')
+ON CONFLICT (name) DO NOTHING;
+
+
+-- Down Migration
+-- Drop anomaly baselines and default anomaly rules
+
+DELETE FROM admiral.alert_rules WHERE condition = 'anomaly';
+
+COMMENT ON COLUMN admiral.alert_rules.condition IS NULL;
+
+DROP TABLE IF EXISTS admiral.anomaly_seasonal_baselines;
+DROP TABLE IF EXISTS admiral.anomaly_baselines;
@@ -0,0 +1,22 @@
+-- Up Migration
+-- Resource exhaustion forecasting
+-- Rules with metric_type disk_hours_until_full, memory_hours_until_full or swap_hours_until_full
+-- compare the predicted hours until the resource is exhausted (linear trend over
+-- forecast_lookback_seconds of admiral.metrics) against their threshold
+
+ALTER TABLE admiral.alert_rules
+    ADD COLUMN IF NOT EXISTS forecast_lookback_seconds INTEGER NOT NULL DEFAULT 86400 CHECK (forecast_lookback_seconds > 0);
+
+COMMENT ON COLUMN admiral.alert_rules.forecast_lookback_seconds IS 'Trend window of *_hours_until_full rules (default 24h)';
+
+INSERT INTO admiral.alert_rules (name, description, metric_type, condition, threshold, duration_seconds, severity, forecast_lookback_seconds) VALUES
+    ('Disk Full Forecast', 'Root filesystem is predicted to be full within 72 hours', 'disk_hours_until_full', 'lt', 72.0, 0, 'warning', 259200)
+ON CONFLICT (name) DO NOTHING;
+
+
+-- Down Migration
+-- Remove forecast rules and lookback column
+
+DELETE FROM admiral.alert_rules WHERE metric_type IN ('disk_hours_until_full', 'memory_hours_until_full', 'swap_hours_until_full');
+
+ALTER TABLE admiral.alert_rules DROP COLUMN IF EXISTS forecast_lookback_seconds;
@@ -0,0 +1,40 @@
+-- Up Migration
+-- Process alert rules
+-- Rules with metric_type process_count, process_memory_bytes or process_cpu_cores evaluate
+-- admiral.process_snapshots for the process group in process_name, e.g.
+-- process_count of nginx eq 0 for 120 seconds = "nginx is not running"
+
+ALTER TABLE admiral.alert_rules
+    ADD COLUMN IF NOT EXISTS process_name TEXT; -- Process group (admiral.process_snapshots.process_name)
+
+COMMENT ON COLUMN admiral.alert_rules.process_name IS 'Process group evaluated by process_count, process_memory_bytes and process_cpu_cores rules';
+
+-- Byte thresholds (RSS) don't fit NUMERIC(10,2)
+ALTER TABLE admiral.alert_rules ALTER COLUMN threshold TYPE NUMERIC(20,2);
+ALTER TABLE admiral.alerts ALTER COLUMN threshold_value TYPE NUMERIC(20,2);
+ALTER TABLE admiral.alerts ALTER COLUMN current_value TYPE NUMERIC(20,2);
+ALTER TABLE admiral.alert_suppressions ALTER COLUMN last_value TYPE NUMERIC(20,2);
+
+-- Process rules look up one group across all scrapes in the evaluation window
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_timestamp
+    ON admiral.process_snapshots(timestamp);
+
+
+-- Down Migration
+-- Remove process rules and restore NUMERIC(10,2) columns
+
+DROP INDEX IF EXISTS admiral.idx_process_snapshots_timestamp;
+
+-- Byte values of process alerts would overflow the narrower columns
+DELETE FROM admiral.alert_suppressions WHERE rule_id IN (
+    SELECT id FROM admiral.alert_rules WHERE metric_type IN ('process_count', 'process_memory_bytes', 'process_cpu_cores')
+);
+DELETE FROM admiral.alerts WHERE alert_type IN ('process_count', 'process_memory_bytes', 'process_cpu_cores');
+DELETE FROM admiral.alert_rules WHERE metric_type IN ('process_count', 'process_memory_bytes', 'process_cpu_cores');
+
+ALTER TABLE admiral.alert_suppressions ALTER COLUMN last_value TYPE NUMERIC(10,2);
+ALTER TABLE admiral.alerts ALTER COLUMN current_value TYPE NUMERIC(10,2);
+ALTER TABLE admiral.alerts ALTER COLUMN threshold_value TYPE NUMERIC(10,2);
+ALTER TABLE admiral.alert_rules ALTER COLUMN threshold TYPE NUMERIC(10,2);
+
+ALTER TABLE admiral.alert_rules DROP COLUMN IF EXISTS process_name;
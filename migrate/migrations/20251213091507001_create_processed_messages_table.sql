@@ -0,0 +1,23 @@
+-- Up Migration
+-- Processed stream message ledger
+-- The digest worker records each Valkey stream message ID in the same transaction as its
+-- inserts, so a message redelivered after a crash between COMMIT and XACK is skipped
+
+CREATE TABLE IF NOT EXISTS admiral.processed_messages (
+    stream TEXT NOT NULL, -- Valkey stream key (e.g. nodepulse:metrics:stream)
+    message_id TEXT NOT NULL, -- Valkey stream entry ID
+    processed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (stream, message_id)
+);
+
+-- Retention cleanup
+CREATE INDEX IF NOT EXISTS idx_processed_messages_processed_at ON admiral.processed_messages(processed_at);
+
+COMMENT ON TABLE admiral.processed_messages IS 'Stream messages already committed by the digest worker (short retention, see cleaner)';
+
+
+-- Down Migration
+-- Drop processed message ledger
+
+DROP TABLE IF EXISTS admiral.processed_messages;
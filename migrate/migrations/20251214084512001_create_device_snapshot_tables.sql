@@ -0,0 +1,103 @@
+-- Up Migration
+-- Per-device node_exporter metrics
+-- admiral.metrics only holds root filesystem totals and aggregate network/disk counters.
+-- Agents now also send per-mountpoint, per-interface and per-block-device arrays,
+-- stored one row per device per snapshot timestamp.
+
+-- ============================================================
+-- SECTION 1: Filesystems (one row per mountpoint)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.filesystem_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    server_id TEXT NOT NULL, -- Agent server_id (as in admiral.metrics), no FK for performance
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    mountpoint TEXT NOT NULL, -- e.g. /, /var/lib/postgresql
+    device TEXT, -- e.g. /dev/nvme1n1p1
+    fstype TEXT, -- e.g. ext4, xfs
+
+    total_bytes BIGINT,
+    free_bytes BIGINT,
+    available_bytes BIGINT, -- Available to unprivileged users
+    files_total BIGINT, -- Inodes
+    files_free BIGINT,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_filesystem_snapshots_server_timestamp_mountpoint UNIQUE (server_id, timestamp, mountpoint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_filesystem_snapshots_server_mountpoint
+    ON admiral.filesystem_snapshots(server_id, mountpoint, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_filesystem_snapshots_timestamp
+    ON admiral.filesystem_snapshots(timestamp);
+
+-- ============================================================
+-- SECTION 2: Network interfaces (counters)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.network_interface_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    server_id TEXT NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    interface TEXT NOT NULL, -- e.g. eth0, bond0
+
+    receive_bytes_total BIGINT,
+    transmit_bytes_total BIGINT,
+    receive_packets_total BIGINT,
+    transmit_packets_total BIGINT,
+    receive_errs_total BIGINT,
+    transmit_errs_total BIGINT,
+    receive_drop_total BIGINT,
+    transmit_drop_total BIGINT,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_network_interface_snapshots_server_timestamp_interface UNIQUE (server_id, timestamp, interface)
+);
+
+CREATE INDEX IF NOT EXISTS idx_network_interface_snapshots_server_interface
+    ON admiral.network_interface_snapshots(server_id, interface, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_network_interface_snapshots_timestamp
+    ON admiral.network_interface_snapshots(timestamp);
+
+-- ============================================================
+-- SECTION 3: Block devices (I/O counters)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.block_device_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    server_id TEXT NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    device TEXT NOT NULL, -- e.g. nvme0n1, sda
+
+    reads_completed_total BIGINT,
+    writes_completed_total BIGINT,
+    read_bytes_total BIGINT,
+    written_bytes_total BIGINT,
+    io_time_seconds_total DOUBLE PRECISION,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_block_device_snapshots_server_timestamp_device UNIQUE (server_id, timestamp, device)
+);
+
+CREATE INDEX IF NOT EXISTS idx_block_device_snapshots_server_device
+    ON admiral.block_device_snapshots(server_id, device, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_block_device_snapshots_timestamp
+    ON admiral.block_device_snapshots(timestamp);
+
+COMMENT ON TABLE admiral.filesystem_snapshots IS 'Per-mountpoint filesystem usage from node_exporter (same retention as admiral.metrics)';
+COMMENT ON TABLE admiral.network_interface_snapshots IS 'Per-interface network counters from node_exporter - use LAG to calculate rates';
+COMMENT ON TABLE admiral.block_device_snapshots IS 'Per-device disk I/O counters from node_exporter - use LAG to calculate rates';
+
+
+-- Down Migration
+-- Drop per-device snapshot tables
+
+DROP TABLE IF EXISTS admiral.block_device_snapshots;
+DROP TABLE IF EXISTS admiral.network_interface_snapshots;
+DROP TABLE IF EXISTS admiral.filesystem_snapshots;
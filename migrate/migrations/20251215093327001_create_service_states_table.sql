@@ -0,0 +1,42 @@
+-- Up Migration
+-- systemd unit state tracking
+-- Digest keeps the latest reported state of every unit in admiral.service_states and records
+-- state changes (e.g. active -> failed) and restarts in admiral.server_events
+
+CREATE TABLE IF NOT EXISTS admiral.service_states (
+    server_id TEXT NOT NULL, -- Agent's server_id (matches servers.server_id), no FK for flexibility
+    unit_name TEXT NOT NULL, -- e.g. nginx.service
+
+    load_state TEXT, -- loaded, not-found, masked, ...
+    active_state TEXT NOT NULL, -- active, inactive, failed, activating, deactivating, reloading
+    sub_state TEXT, -- running, exited, dead, auto-restart, ...
+    restarts INTEGER NOT NULL DEFAULT 0, -- systemd NRestarts (resets when the unit is restarted manually)
+
+    state_changed_at TIMESTAMP WITH TIME ZONE, -- When systemd last changed the unit state (agent clock)
+    reported_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Timestamp of the snapshot this row was taken from
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (server_id, unit_name)
+);
+
+-- Failed units across the fleet
+CREATE INDEX IF NOT EXISTS idx_service_states_active_state ON admiral.service_states(active_state, server_id);
+
+CREATE TRIGGER update_service_states_updated_at
+    BEFORE UPDATE ON admiral.service_states
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.service_states IS 'Latest systemd unit state per server, from the systemd section of agent payloads';
+COMMENT ON COLUMN admiral.server_events.event_type IS 'Event type (e.g., status_change, service_state_change, service_restart)';
+
+
+-- Down Migration
+-- Drop systemd unit state tracking
+
+DELETE FROM admiral.server_events WHERE event_type IN ('service_state_change', 'service_restart');
+
+COMMENT ON COLUMN admiral.server_events.event_type IS 'Event type (e.g., status_change)';
+
+DROP TRIGGER IF EXISTS update_service_states_updated_at ON admiral.service_states;
+DROP TABLE IF EXISTS admiral.service_states;
@@ -0,0 +1,66 @@
+-- Up Migration
+-- Host log ingestion (journald / syslog)
+-- Agents POST batches of log lines to ingest, which queues them on nodepulse:logs:stream.
+-- Digest writes them to admiral.logs, partitioned by day (UTC) so retention drops whole partitions.
+-- The cleaner creates upcoming partitions; rows outside every daily partition land in logs_default.
+
+-- ============================================================
+-- SECTION 1: Logs Table
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.logs (
+    id BIGSERIAL,
+    server_id TEXT NOT NULL, -- Agent's server_id (matches servers.server_id), no FK for performance
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL, -- When the line was logged (agent clock)
+
+    source TEXT NOT NULL DEFAULT 'journald', -- journald, syslog
+    severity SMALLINT CHECK (severity BETWEEN 0 AND 7), -- Syslog priority: 0 = emerg ... 7 = debug
+    unit TEXT, -- systemd unit or syslog identifier (e.g. nginx.service, sshd)
+    hostname TEXT,
+    pid INTEGER,
+    message TEXT NOT NULL,
+
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+) PARTITION BY RANGE (timestamp);
+
+-- Catch-all for timestamps outside the daily partitions (e.g. old lines replayed by an agent)
+CREATE TABLE IF NOT EXISTS admiral.logs_default PARTITION OF admiral.logs DEFAULT;
+
+-- Daily partitions around the migration date; the cleaner keeps creating them ahead of time
+DO $$
+DECLARE
+    day DATE;
+BEGIN
+    FOR day IN SELECT generate_series(CURRENT_DATE - 1, CURRENT_DATE + 7, INTERVAL '1 day')::date LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS admiral.%I PARTITION OF admiral.logs FOR VALUES FROM (%L) TO (%L)',
+            'logs_p' || to_char(day, 'YYYYMMDD'),
+            day::text || ' 00:00:00+00',
+            (day + 1)::text || ' 00:00:00+00'
+        );
+    END LOOP;
+END $$;
+
+-- Search: a server's logs over time, optionally narrowed to a unit
+CREATE INDEX IF NOT EXISTS idx_logs_server_timestamp ON admiral.logs(server_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_logs_server_unit_timestamp ON admiral.logs(server_id, unit, timestamp DESC);
+
+COMMENT ON TABLE admiral.logs IS 'Host log lines (journald/syslog) from agents, partitioned by day';
+COMMENT ON COLUMN admiral.logs.severity IS 'Syslog priority (0 emerg, 1 alert, 2 crit, 3 err, 4 warning, 5 notice, 6 info, 7 debug)';
+
+-- ============================================================
+-- SECTION 2: Settings
+-- ============================================================
+
+INSERT INTO admiral.settings (key, value, description, tier) VALUES
+    ('logs_retention_hours', '72', 'Keep host logs for this many hours (rounded up to whole days)', 'free')
+ON CONFLICT (key) DO NOTHING;
+
+
+-- Down Migration
+-- Drop host log storage
+
+DELETE FROM admiral.settings WHERE key = 'logs_retention_hours';
+
+-- Dropping the parent drops every partition
+DROP TABLE IF EXISTS admiral.logs;
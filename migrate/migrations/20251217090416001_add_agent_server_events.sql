@@ -0,0 +1,26 @@
+-- Up Migration
+-- Agent-reported host events (reboots, package changes, OOM kills, kernel changes)
+-- Agents POST events to ingest, which queues them on nodepulse:events:stream;
+-- digest stores them in admiral.server_events with source = 'agent'
+
+-- Agent-generated event ID, so an event re-sent after a failed request is stored once
+ALTER TABLE admiral.server_events
+ADD COLUMN IF NOT EXISTS agent_event_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_server_events_agent_event
+    ON admiral.server_events(server_id, agent_event_id)
+    WHERE agent_event_id IS NOT NULL;
+
+COMMENT ON COLUMN admiral.server_events.agent_event_id IS 'Event ID assigned by the agent (deduplicates re-sent events), NULL for server-side events';
+COMMENT ON COLUMN admiral.server_events.event_type IS 'Event type (e.g., status_change, service_state_change, service_restart, reboot, package_upgrade, oom_kill, kernel_change)';
+
+
+-- Down Migration
+-- Remove agent-reported events
+
+DELETE FROM admiral.server_events WHERE source = 'agent';
+
+COMMENT ON COLUMN admiral.server_events.event_type IS 'Event type (e.g., status_change, service_state_change, service_restart)';
+
+DROP INDEX IF EXISTS admiral.uq_server_events_agent_event;
+ALTER TABLE admiral.server_events DROP COLUMN IF EXISTS agent_event_id;
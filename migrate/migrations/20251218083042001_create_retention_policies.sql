@@ -0,0 +1,58 @@
+-- Up Migration
+-- Per-server and per-tag retention overrides
+-- retention_hours / retention_enabled stay the fleet-wide defaults; metrics_retention_hours and
+-- process_snapshots_retention_hours override them per table. Rows in admiral.retention_policies
+-- override both for one server or for every server carrying a tag (e.g. db hosts 720h, ci 6h).
+--
+-- Precedence (most specific wins): server + table, server + all, tag + table, tag + all,
+-- table setting, retention_hours. When several tags of a server match, the longest retention wins.
+
+-- ============================================================
+-- SECTION 1: Retention Policies Table
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS admiral.retention_policies (
+    id BIGSERIAL PRIMARY KEY,
+    server_id TEXT, -- Agent's server_id (matches servers.server_id), no FK so policies can be set up before the agent registers
+    tag TEXT, -- Applies to every server whose servers.tags contains this tag
+    data_type TEXT NOT NULL DEFAULT 'all' CHECK (data_type IN ('all', 'metrics', 'process_snapshots')),
+    retention_hours INTEGER NOT NULL CHECK (retention_hours > 0),
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- A policy targets exactly one server or one tag
+    CHECK ((server_id IS NULL) <> (tag IS NULL))
+);
+
+-- One policy per target and data type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_policies_server
+    ON admiral.retention_policies(server_id, data_type) WHERE server_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_policies_tag
+    ON admiral.retention_policies(tag, data_type) WHERE tag IS NOT NULL;
+
+CREATE TRIGGER update_retention_policies_updated_at
+    BEFORE UPDATE ON admiral.retention_policies
+    FOR EACH ROW EXECUTE FUNCTION admiral.update_updated_at_column();
+
+COMMENT ON TABLE admiral.retention_policies IS 'Retention overrides for one server or a server tag, evaluated by the cleaner';
+COMMENT ON COLUMN admiral.retention_policies.data_type IS 'Which data the policy applies to: all, metrics or process_snapshots';
+COMMENT ON COLUMN admiral.retention_policies.retention_hours IS 'Keep rows of matching servers for this many hours';
+
+-- ============================================================
+-- SECTION 2: Settings
+-- ============================================================
+
+INSERT INTO admiral.settings (key, value, description, tier) VALUES
+    ('metrics_retention_hours', 'null', 'Metrics retention period in hours (null = retention_hours)', 'free'),
+    ('process_snapshots_retention_hours', 'null', 'Process snapshots retention period in hours (null = retention_hours)', 'free')
+ON CONFLICT (key) DO NOTHING;
+
+
+-- Down Migration
+-- Drop retention overrides
+
+DELETE FROM admiral.settings WHERE key IN ('metrics_retention_hours', 'process_snapshots_retention_hours');
+
+DROP TRIGGER IF EXISTS update_retention_policies_updated_at ON admiral.retention_policies;
+DROP TABLE IF EXISTS admiral.retention_policies;
@@ -0,0 +1,164 @@
+-- Up Migration
+-- Daily range partitioning of admiral.metrics, admiral.process_snapshots and admiral.ssh_session_recordings
+-- Retention used to run batched DELETEs every minute, causing table bloat, heavy autovacuum and WAL spikes.
+-- Like admiral.logs, the tables are now partitioned by day (UTC) so the cleaner drops whole partitions;
+-- it also creates upcoming partitions. Rows outside every daily partition land in <table>_default;
+-- ingest clamps timestamps stamped ahead of time, and rows of a day still in <table>_default are
+-- moved into that day's partition when the cleaner creates it.
+--
+-- Existing rows are copied into the new tables, which may take a while on large installs.
+-- The primary keys become (id, timestamp) because a partitioned table's keys must include the partition key.
+
+-- ============================================================
+-- SECTION 1: Move rows into partitioned tables
+-- ============================================================
+
+DO $$
+DECLARE
+    t TEXT;
+    first_day DATE;
+    day DATE;
+    seq TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['metrics', 'process_snapshots', 'ssh_session_recordings'] LOOP
+        EXECUTE format('ALTER TABLE admiral.%I RENAME TO %I', t, t || '_legacy');
+
+        -- Same columns, defaults (including the id sequence) and column comments
+        EXECUTE format(
+            'CREATE TABLE admiral.%I (LIKE admiral.%I INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING COMMENTS) PARTITION BY RANGE (timestamp)',
+            t, t || '_legacy'
+        );
+        EXECUTE format('CREATE TABLE admiral.%I PARTITION OF admiral.%I DEFAULT', t || '_default', t);
+
+        -- Daily partitions from the oldest row (at most 90 days back, older rows go to the default
+        -- partition) until a week ahead; the cleaner keeps creating them ahead of time
+        EXECUTE format('SELECT (MIN(timestamp) AT TIME ZONE ''UTC'')::date FROM admiral.%I', t || '_legacy')
+            INTO first_day;
+        first_day := LEAST(GREATEST(COALESCE(first_day, CURRENT_DATE), CURRENT_DATE - 90), CURRENT_DATE - 1);
+
+        FOR day IN SELECT generate_series(first_day, CURRENT_DATE + 7, INTERVAL '1 day')::date LOOP
+            EXECUTE format(
+                'CREATE TABLE IF NOT EXISTS admiral.%I PARTITION OF admiral.%I FOR VALUES FROM (%L) TO (%L)',
+                t || '_p' || to_char(day, 'YYYYMMDD'),
+                t,
+                day::text || ' 00:00:00+00',
+                (day + 1)::text || ' 00:00:00+00'
+            );
+        END LOOP;
+
+        EXECUTE format('INSERT INTO admiral.%I SELECT * FROM admiral.%I', t, t || '_legacy');
+
+        -- Keep the id sequence (BIGSERIAL) when the old table is dropped
+        seq := pg_get_serial_sequence(format('admiral.%I', t || '_legacy'), 'id');
+        IF seq IS NOT NULL THEN
+            EXECUTE format('ALTER SEQUENCE %s OWNED BY admiral.%I.id', seq, t);
+        END IF;
+
+        EXECUTE format('DROP TABLE admiral.%I', t || '_legacy');
+    END LOOP;
+END $$;
+
+-- ============================================================
+-- SECTION 2: Keys and indexes (created on every partition)
+-- ============================================================
+
+-- Metrics
+ALTER TABLE admiral.metrics ADD PRIMARY KEY (id, timestamp);
+ALTER TABLE admiral.metrics
+    ADD CONSTRAINT uq_metrics_server_timestamp UNIQUE (server_id, timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_lookup
+    ON admiral.metrics(server_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_metrics_timestamp
+    ON admiral.metrics(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_metrics_server_created
+    ON admiral.metrics(server_id, created_at DESC);
+
+COMMENT ON CONSTRAINT uq_metrics_server_timestamp ON admiral.metrics IS 'Makes redelivered stream messages idempotent (ON CONFLICT target)';
+COMMENT ON TABLE admiral.metrics IS 'Raw node_exporter snapshots, partitioned by day';
+
+-- Process snapshots
+ALTER TABLE admiral.process_snapshots ADD PRIMARY KEY (id, timestamp);
+ALTER TABLE admiral.process_snapshots
+    ADD CONSTRAINT uq_process_snapshots_server_timestamp_name UNIQUE (server_id, timestamp, process_name);
+
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_lookup
+    ON admiral.process_snapshots(server_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_server_name
+    ON admiral.process_snapshots(server_id, process_name, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_server_time
+    ON admiral.process_snapshots(server_id, timestamp DESC, cpu_seconds_total DESC, memory_bytes DESC);
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_timestamp
+    ON admiral.process_snapshots(timestamp);
+
+COMMENT ON CONSTRAINT uq_process_snapshots_server_timestamp_name ON admiral.process_snapshots IS 'Makes redelivered stream messages idempotent (ON CONFLICT target)';
+COMMENT ON TABLE admiral.process_snapshots IS 'Per-process metrics from process_exporter - used for Top 10 Processes feature (partitioned by day)';
+
+-- SSH session recordings
+ALTER TABLE admiral.ssh_session_recordings ADD PRIMARY KEY (id, timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_ssh_recordings_session ON admiral.ssh_session_recordings(session_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_ssh_recordings_timestamp ON admiral.ssh_session_recordings(timestamp);
+CREATE INDEX IF NOT EXISTS idx_ssh_recordings_event_type ON admiral.ssh_session_recordings(event_type);
+
+COMMENT ON TABLE admiral.ssh_session_recordings IS 'Full SSH session recordings for compliance and troubleshooting, partitioned by day. Contains all terminal I/O. WARNING: May contain sensitive data (passwords, keys).';
+
+-- ============================================================
+-- SECTION 3: Settings
+-- ============================================================
+
+INSERT INTO admiral.settings (key, value, description, tier) VALUES
+    ('ssh_session_recording_retention_hours', 'null', 'Keep SSH session recordings for this many hours, rounded up to whole days (null = forever)', 'growth')
+ON CONFLICT (key) DO NOTHING;
+
+
+-- Down Migration
+-- Move rows back into unpartitioned tables
+
+DELETE FROM admiral.settings WHERE key = 'ssh_session_recording_retention_hours';
+
+DO $$
+DECLARE
+    t TEXT;
+    seq TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['metrics', 'process_snapshots', 'ssh_session_recordings'] LOOP
+        EXECUTE format('ALTER TABLE admiral.%I RENAME TO %I', t, t || '_partitioned');
+        EXECUTE format(
+            'CREATE TABLE admiral.%I (LIKE admiral.%I INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING COMMENTS)',
+            t, t || '_partitioned'
+        );
+        EXECUTE format('INSERT INTO admiral.%I SELECT * FROM admiral.%I', t, t || '_partitioned');
+
+        seq := pg_get_serial_sequence(format('admiral.%I', t || '_partitioned'), 'id');
+        IF seq IS NOT NULL THEN
+            EXECUTE format('ALTER SEQUENCE %s OWNED BY admiral.%I.id', seq, t);
+        END IF;
+
+        -- Dropping the parent drops every partition
+        EXECUTE format('DROP TABLE admiral.%I', t || '_partitioned');
+    END LOOP;
+END $$;
+
+ALTER TABLE admiral.metrics ADD PRIMARY KEY (id);
+ALTER TABLE admiral.metrics
+    ADD CONSTRAINT uq_metrics_server_timestamp UNIQUE (server_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_metrics_lookup ON admiral.metrics(server_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_metrics_timestamp ON admiral.metrics(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_metrics_server_created ON admiral.metrics(server_id, created_at DESC);
+
+ALTER TABLE admiral.process_snapshots ADD PRIMARY KEY (id);
+ALTER TABLE admiral.process_snapshots
+    ADD CONSTRAINT uq_process_snapshots_server_timestamp_name UNIQUE (server_id, timestamp, process_name);
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_lookup ON admiral.process_snapshots(server_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_server_name ON admiral.process_snapshots(server_id, process_name, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_server_time ON admiral.process_snapshots(server_id, timestamp DESC, cpu_seconds_total DESC, memory_bytes DESC);
+CREATE INDEX IF NOT EXISTS idx_process_snapshots_timestamp ON admiral.process_snapshots(timestamp);
+
+ALTER TABLE admiral.ssh_session_recordings ADD PRIMARY KEY (id);
+CREATE INDEX IF NOT EXISTS idx_ssh_recordings_session ON admiral.ssh_session_recordings(session_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_ssh_recordings_timestamp ON admiral.ssh_session_recordings(timestamp);
+CREATE INDEX IF NOT EXISTS idx_ssh_recordings_event_type ON admiral.ssh_session_recordings(event_type);
+
+COMMENT ON TABLE admiral.process_snapshots IS 'Per-process metrics from process_exporter - used for Top 10 Processes feature';
+COMMENT ON TABLE admiral.ssh_session_recordings IS 'Full SSH session recordings for compliance and troubleshooting. Contains all terminal I/O. WARNING: May contain sensitive data (passwords, keys).';
@@ -745,6 +745,7 @@ DB_SSLMODE=${CONFIG[DB_SSLMODE]}
 # - submarines-sshws: 6001
 # - submarines-deployer: background worker (no port)
 # - submarines-digest: background worker (no port)
+# - submarines-alerter: background worker (no port)
 # - flagship: 8090 (Nginx, hardcoded)
 GIN_MODE=${CONFIG[GIN_MODE]}
 
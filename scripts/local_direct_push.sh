@@ -45,6 +45,7 @@ SERVICES=(
     "node-pulse-migrate"
     "node-pulse-submarines-ingest"
     "node-pulse-submarines-digest"
+    "node-pulse-submarines-alerter"
     "node-pulse-submarines-deployer"
     "node-pulse-submarines-sshws"
     "node-pulse-flagship"
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/alerting"
+	"github.com/nodepulse/admiral/submarines/internal/config"
+	"github.com/nodepulse/admiral/submarines/internal/database"
+	"github.com/nodepulse/admiral/submarines/internal/health"
+	"github.com/nodepulse/admiral/submarines/internal/logger"
+	"github.com/nodepulse/admiral/submarines/internal/notifier"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+	"github.com/nodepulse/admiral/submarines/internal/retry"
+)
+
+// flushInterval is how often due notification groups are checked
+// (effective group_wait/group_interval resolution)
+const flushInterval = 5 * time.Second
+
+// escalationInterval is how often due escalation tiers are checked
+const escalationInterval = 30 * time.Second
+
+// baselineSeedInterval is how often missing anomaly baselines are seeded from history
+const baselineSeedInterval = time.Minute
+
+// relayInterval is how often the alert event outbox is published to the events stream
+const relayInterval = time.Second
+
+var (
+	// Structured logger
+	log *slog.Logger
+)
+
+func getConsumerName() string {
+	// Use hostname (Docker container ID in containerized environments)
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		// Fallback to random hex ID if hostname unavailable
+		b := make([]byte, 4)
+		rand.Read(b)
+		hostname = fmt.Sprintf("%x", b)
+	}
+	return fmt.Sprintf("alerter-%s", hostname)
+}
+
+func main() {
+	// Initialize structured logger
+	log = logger.New()
+
+	// Load configuration
+	cfg := config.Load()
+	evalInterval := time.Duration(cfg.AlertEvalInterval) * time.Second
+	if evalInterval <= 0 {
+		evalInterval = 30 * time.Second
+	}
+
+	log.Info("Starting alerter",
+		slog.String("eval_interval", evalInterval.String()))
+
+	// Initialize database
+	db, err := database.New(cfg)
+	if err != nil {
+		log.Error("Failed to initialize database", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	// Initialize the queue (Valkey, or the embedded backend)
+	q, _, err := queue.Open(cfg)
+	if err != nil {
+		log.Error("Failed to initialize queue", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer q.Close()
+
+	// Create cancellable context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Create evaluator instance
+	evaluator := alerting.New(db.DB)
+
+	// Create notifier and its consumer group (retry handles Valkey not being fully ready)
+	alertNotifier := notifier.New(db.DB, q, getConsumerName())
+	err = retry.WithExponentialBackoff(ctx, retry.DefaultConfig(), "Create notifier consumer group", func() error {
+		return alertNotifier.Init(ctx)
+	})
+	if err != nil {
+		log.Error("Failed to create notifier consumer group", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Setup evaluation ticker
+	evalTicker := time.NewTicker(evalInterval)
+	defer evalTicker.Stop()
+
+	// Setup graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	// Start health check HTTP server
+	go startHealthServer(db, q)
+
+	log.Info("Alerter ready")
+
+	// Queue alert events and deliver notification groups in the background
+	go runNotifier(ctx, alertNotifier)
+	go runFlusher(ctx, alertNotifier)
+
+	// Page escalation tiers of unacknowledged alerts
+	go runEscalations(ctx, alertNotifier)
+
+	// Seed anomaly baselines outside the evaluation transaction
+	go runBaselineSeeder(ctx, alerting.NewBaselineSeeder(db.DB))
+
+	// Publish committed alert events (evaluator and user actions) to the events stream
+	go runOutboxRelay(ctx, db, q)
+
+	// Run evaluation immediately on startup
+	runEvaluation(ctx, evaluator, evalInterval)
+
+	running := true
+	for running {
+		select {
+		case sig := <-sigChan:
+			log.Info("Received shutdown signal",
+				slog.String("signal", sig.String()))
+			cancel()
+			running = false
+
+		case <-evalTicker.C:
+			runEvaluation(ctx, evaluator, evalInterval)
+		}
+	}
+
+	log.Info("Alerter stopped gracefully")
+}
+
+func runEvaluation(ctx context.Context, e *alerting.Evaluator, interval time.Duration) {
+	// Never let a cycle run into the next one
+	evalCtx, cancel := context.WithTimeout(ctx, interval)
+	defer cancel()
+
+	start := time.Now()
+	result, err := e.Run(evalCtx)
+	if err != nil {
+		log.Error("Alert evaluation failed",
+			slog.String("error", err.Error()))
+		return
+	}
+
+	log.Debug("Alert evaluation completed",
+		slog.Int("rules", result.Rules),
+		slog.Int("opened", result.Opened),
+		slog.Int("updated", result.Updated),
+		slog.Int("resolved", result.Resolved),
+		slog.Int("suppressed", result.Suppressed),
+		slog.Int("held", result.Held),
+		slog.Int("events", len(result.Events)),
+		slog.Duration("duration", time.Since(start)))
+}
+
+func runBaselineSeeder(ctx context.Context, seeder *alerting.BaselineSeeder) {
+	ticker := time.NewTicker(baselineSeedInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			seeded, err := seeder.Run(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Error("Anomaly baseline seeding failed", slog.String("error", err.Error()))
+				continue
+			}
+			if seeded > 0 {
+				log.Info("Seeded anomaly baselines", slog.Int("count", seeded))
+			}
+		}
+	}
+}
+
+func runOutboxRelay(ctx context.Context, db *database.DB, q queue.Queue) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Unpublished events stay in the outbox and are retried on the next tick
+			published, err := alerting.RelayOutbox(ctx, db.DB, q)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Error("Failed to publish alert events",
+					slog.Int("published", published),
+					slog.String("error", err.Error()))
+				continue
+			}
+			if published > 0 {
+				log.Debug("Published alert events", slog.Int("count", published))
+			}
+		}
+	}
+}
+
+func runNotifier(ctx context.Context, n *notifier.Notifier) {
+	for ctx.Err() == nil {
+		// Blocks up to 5s waiting for new events
+		count, err := n.ProcessBatch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error("Notifier batch failed", slog.String("error", err.Error()))
+			time.Sleep(time.Second)
+			continue
+		}
+		if count > 0 {
+			log.Debug("Queued alert events", slog.Int("count", count))
+		}
+	}
+}
+
+func runFlusher(ctx context.Context, n *notifier.Notifier) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := n.Flush(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Error("Notification flush failed", slog.String("error", err.Error()))
+				continue
+			}
+			if sent > 0 {
+				log.Debug("Sent grouped notifications", slog.Int("count", sent))
+			}
+		}
+	}
+}
+
+func runEscalations(ctx context.Context, n *notifier.Notifier) {
+	ticker := time.NewTicker(escalationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			paged, err := n.Escalate(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Error("Escalation run failed", slog.String("error", err.Error()))
+				continue
+			}
+			if paged > 0 {
+				log.Info("Paged escalation targets", slog.Int("count", paged))
+			}
+		}
+	}
+}
+
+func startHealthServer(db *database.DB, q queue.Queue) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", health.Handler(db, q, "alerter", "1.0.0"))
+
+	server := &http.Server{
+		Addr:    ":8082",
+		Handler: mux,
+	}
+
+	log.Info("Health check server started", slog.String("addr", ":8082"))
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Error("Health server failed", slog.String("error", err.Error()))
+	}
+}
@@ -16,8 +16,8 @@ import (
 	"github.com/lib/pq"
 	"github.com/nodepulse/admiral/submarines/internal/config"
 	"github.com/nodepulse/admiral/submarines/internal/database"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
 	"github.com/nodepulse/admiral/submarines/internal/sshws"
-	"github.com/nodepulse/admiral/submarines/internal/valkey"
 )
 
 const (
@@ -37,7 +37,7 @@ type DeploymentMessage struct {
 
 var (
 	db     *database.DB
-	vk     *valkey.Client
+	vk     queue.Queue
 	cfg    *config.Config
 	logger *log.Logger
 )
@@ -58,13 +58,18 @@ func main() {
 	defer db.Close()
 	logger.Println("✓ Connected to PostgreSQL")
 
-	// Connect to Valkey
-	vk, err = valkey.New(cfg)
+	// Connect to the queue
+	// Flagship publishes deployment jobs to Valkey, so with the embedded backend the
+	// deployer would wait on a stream nothing writes to
+	if cfg.QueueBackend == queue.BackendEmbedded {
+		logger.Fatalf("QUEUE_BACKEND=%s is not supported by the deployer: Flagship publishes deployments to Valkey", queue.BackendEmbedded)
+	}
+	vk, _, err = queue.Open(cfg)
 	if err != nil {
-		logger.Fatalf("Failed to connect to Valkey: %v", err)
+		logger.Fatalf("Failed to connect to queue: %v", err)
 	}
 	defer vk.Close()
-	logger.Println("✓ Connected to Valkey")
+	logger.Printf("✓ Connected to queue (%s)", cfg.QueueBackend)
 
 	// Ensure consumer group exists
 	// Use "$" to process only NEW messages from this point forward
@@ -110,7 +115,7 @@ func processDeployments(ctx context.Context) error {
 	// Phase 1: Read pending messages for THIS consumer (messages we claimed but didn't ACK)
 	// Phase 2: Read new messages that haven't been delivered to ANY consumer yet
 
-	var allMessages []valkey.StreamMessage
+	var allMessages []queue.Message
 
 	// Phase 1: Check for pending messages using "0"
 	pendingMessages, err := vk.XReadGroup(ctx, ConsumerGroup, ConsumerName, StreamKey, "0", BatchSize)
@@ -157,7 +162,7 @@ func processDeployments(ctx context.Context) error {
 	return nil
 }
 
-func handleDeployment(ctx context.Context, msg valkey.StreamMessage) error {
+func handleDeployment(ctx context.Context, msg queue.Message) error {
 	// Parse deployment message from stream fields
 	deploymentID := msg.Fields["deployment_id"]
 	playbook := msg.Fields["playbook"]
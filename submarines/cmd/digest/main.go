@@ -19,22 +19,45 @@ import (
 	"github.com/nodepulse/admiral/submarines/internal/health"
 	"github.com/nodepulse/admiral/submarines/internal/logger"
 	"github.com/nodepulse/admiral/submarines/internal/processor"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
 	"github.com/nodepulse/admiral/submarines/internal/retry"
+	"github.com/nodepulse/admiral/submarines/internal/serverstatus"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
 	"github.com/nodepulse/admiral/submarines/internal/valkey"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	streamKey     = handlers.MetricsStreamKey
 	consumerGroup = "submarines-digest"
-	dlqStreamKey  = "nodepulse:metrics:dlq" // Dead letter queue for poison messages
-	batchSize     = 100                      // Process up to 100 messages per read
-	idleSleep     = 5                        // seconds to sleep when no messages
-	maxRetries    = 5                        // Max delivery attempts before moving to DLQ
+	batchSize     = 100 // Process up to 100 messages per read (per stream)
+	idleSleep     = 5   // seconds to sleep when no messages
+	maxRetries    = 5   // Max delivery attempts before moving to DLQ
+
+	// claimMinIdle is how long another consumer's pending message may sit before it's
+	// taken over (e.g. from a digest replica that was scaled down or crashed)
+	claimMinIdle = 5 * time.Minute
 )
 
+// digestStream is a stream consumed by the digest worker
+type digestStream struct {
+	key     string
+	dlqKey  string // Dead letter queue for poison messages
+	process func(ctx context.Context, db *database.DB, stream string, msg queue.Message) error
+}
+
+// streams are read in order every processing cycle
+var streams = []digestStream{
+	{key: handlers.MetricsStreamKey, dlqKey: handlers.MetricsDLQKey, process: processMessage},
+	{key: handlers.LogsStreamKey, dlqKey: "nodepulse:logs:dlq", process: processLogMessage},
+	{key: handlers.EventsStreamKey, dlqKey: "nodepulse:events:dlq", process: processEventMessage},
+}
+
 var (
 	// Generate unique consumer name for horizontal scaling
 	consumerName = getConsumerName()
+	// Queue backend, reported as messaging.system on spans
+	queueBackend = queue.BackendValkey
 	// Structured logger
 	log *slog.Logger
 )
@@ -73,26 +96,46 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize Valkey
-	valkeyClient, err := valkey.New(cfg)
+	// Initialize the queue (valkeyClient is nil with the embedded backend)
+	q, valkeyClient, err := queue.Open(cfg)
 	if err != nil {
-		log.Error("Failed to initialize Valkey", slog.String("error", err.Error()))
+		log.Error("Failed to initialize queue", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
-	defer valkeyClient.Close()
+	defer q.Close()
+	if cfg.QueueBackend != "" {
+		queueBackend = cfg.QueueBackend
+	}
 
 	// Create cancellable context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Create consumer group with retry strategy (handles Valkey not being fully ready)
-	err = retry.WithExponentialBackoff(ctx, retry.DefaultConfig(), "Create consumer group", func() error {
-		return valkeyClient.XGroupCreate(ctx, streamKey, consumerGroup, "0")
-	})
+	// Initialize tracing (continues traces started by ingest)
+	shutdownTracing, err := tracing.Init(ctx, cfg, "submarines-digest")
 	if err != nil {
-		log.Error("Failed to create consumer group", slog.String("error", err.Error()))
+		log.Error("Failed to initialize tracing", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+	defer func() {
+		// Flush pending spans with a fresh context (ctx is cancelled by then)
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		shutdownTracing(flushCtx)
+	}()
+
+	// Create consumer groups with retry strategy (handles Valkey not being fully ready)
+	for _, s := range streams {
+		err = retry.WithExponentialBackoff(ctx, retry.DefaultConfig(), "Create consumer group", func() error {
+			return q.XGroupCreate(ctx, s.key, consumerGroup, "0")
+		})
+		if err != nil {
+			log.Error("Failed to create consumer group",
+				slog.String("stream", s.key),
+				slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
 
 	// Create cleaner instance
 	cleanerInstance := cleaner.New(db.DB, cfg)
@@ -101,16 +144,24 @@ func main() {
 	cleanupTicker := time.NewTicker(1 * time.Minute)
 	defer cleanupTicker.Stop()
 
+	// Create server status monitor (online/stale/offline from last_seen_at)
+	statusMonitor := serverstatus.New(db.DB, valkeyClient)
+
+	// Setup status ticker (runs every 30 seconds)
+	statusTicker := time.NewTicker(30 * time.Second)
+	defer statusTicker.Stop()
+
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	// Start health check HTTP server
-	go startHealthServer(db, valkeyClient)
+	go startHealthServer(db, q)
 
 	log.Info("Digest worker ready",
 		slog.String("cleanup_interval", "1 minute"),
-		slog.String("stream", streamKey),
+		slog.String("status_interval", "30 seconds"),
+		slog.Int("streams", len(streams)),
 		slog.String("consumer_group", consumerGroup))
 
 	// Run cleanup immediately on startup
@@ -131,10 +182,14 @@ func main() {
 			// Run cleanup in background (don't block digest processing)
 			go runCleanup(ctx, cleanerInstance)
 
+		case <-statusTicker.C:
+			// Evaluate server statuses in background (don't block digest processing)
+			go runStatusMonitor(ctx, statusMonitor)
+
 		default:
 			// Create context with timeout for each processing cycle
 			processCtx, processCancel := context.WithTimeout(ctx, 30*time.Second)
-			err := processMessages(processCtx, valkeyClient, db)
+			err := processMessages(processCtx, q, db)
 			processCancel()
 
 			// Check if shutdown was requested
@@ -155,7 +210,7 @@ func main() {
 	log.Info("Digest worker stopped gracefully")
 }
 
-func processMessages(ctx context.Context, valkeyClient *valkey.Client, db *database.DB) error {
+func processMessages(ctx context.Context, q queue.Queue, db *database.DB) error {
 	// Health check database before processing
 	if err := db.Ping(ctx); err != nil {
 		log.Error("Database health check failed",
@@ -163,50 +218,68 @@ func processMessages(ctx context.Context, valkeyClient *valkey.Client, db *datab
 		return fmt.Errorf("database unhealthy: %w", err)
 	}
 
-	// Health check Valkey before processing
-	if err := valkeyClient.Ping(ctx); err != nil {
-		log.Error("Valkey health check failed",
+	// Health check the queue before processing
+	if err := q.Ping(ctx); err != nil {
+		log.Error("Queue health check failed",
 			slog.String("error", err.Error()))
-		return fmt.Errorf("valkey unhealthy: %w", err)
+		return fmt.Errorf("queue unhealthy: %w", err)
+	}
+
+	read := 0
+	for _, s := range streams {
+		n, err := processStream(ctx, q, db, s)
+		if err != nil {
+			return err
+		}
+		read += n
+	}
+
+	if read == 0 {
+		// No messages, take a longer break to reduce polling
+		time.Sleep(idleSleep * time.Second)
 	}
 
+	return nil
+}
+
+// processStream processes one batch of a stream and returns the number of messages read
+func processStream(ctx context.Context, q queue.Queue, db *database.DB, s digestStream) (int, error) {
 	// Check for poison messages and move to DLQ
-	if err := handlePoisonMessages(ctx, valkeyClient); err != nil {
+	if err := handlePoisonMessages(ctx, q, s); err != nil {
 		log.Error("Failed to handle poison messages",
+			slog.String("stream", s.key),
 			slog.String("error", err.Error()))
 		// Don't return error - continue processing new messages
 	}
 
 	// Try to read pending messages first (messages that were delivered but not ACKed)
 	// Use "0" to read pending messages for this consumer
-	messages, err := valkeyClient.XReadGroup(ctx, consumerGroup, consumerName, streamKey, "0", batchSize)
+	messages, err := q.XReadGroup(ctx, consumerGroup, consumerName, s.key, "0", batchSize)
 	if err != nil {
 		log.Error("Failed to read pending messages from stream",
 			slog.String("error", err.Error()),
-			slog.String("stream", streamKey))
-		return err
+			slog.String("stream", s.key))
+		return 0, err
 	}
 
 	// If no pending messages, read new messages
 	if len(messages) == 0 {
-		messages, err = valkeyClient.XReadGroup(ctx, consumerGroup, consumerName, streamKey, ">", batchSize)
+		messages, err = q.XReadGroup(ctx, consumerGroup, consumerName, s.key, ">", batchSize)
 		if err != nil {
 			log.Error("Failed to read new messages from stream",
 				slog.String("error", err.Error()),
-				slog.String("stream", streamKey))
-			return err
+				slog.String("stream", s.key))
+			return 0, err
 		}
 	}
 
 	if len(messages) == 0 {
-		// No messages, take a longer break to reduce polling
-		time.Sleep(idleSleep * time.Second)
-		return nil
+		return 0, nil
 	}
 
 	log.Debug("Read messages from stream",
 		slog.Int("count", len(messages)),
-		slog.String("stream", streamKey))
+		slog.String("stream", s.key))
 	successCount := 0
 	errorCount := 0
 	processedIDs := make([]string, 0, len(messages))
@@ -218,9 +291,14 @@ func processMessages(ctx context.Context, valkeyClient *valkey.Client, db *datab
 			break
 		}
 
-		if err := processMessage(ctx, db, msg); err != nil {
+		msgCtx, span := startMessageSpan(ctx, s, msg)
+		err := s.process(msgCtx, db, s.key, msg)
+		tracing.End(span, err)
+		if err != nil {
 			log.Error("Failed to process message",
+				slog.String("stream", s.key),
 				slog.String("message_id", msg.ID),
+				slog.String("trace_id", tracing.TraceID(msgCtx)),
 				slog.String("error", err.Error()))
 			errorCount++
 			// Don't ACK failed messages - they'll be retried
@@ -228,7 +306,7 @@ func processMessages(ctx context.Context, valkeyClient *valkey.Client, db *datab
 		}
 
 		// Acknowledge successful processing
-		valkeyClient.XAck(ctx, streamKey, consumerGroup, msg.ID)
+		q.XAck(ctx, s.key, consumerGroup, msg.ID)
 		processedIDs = append(processedIDs, msg.ID)
 		successCount++
 	}
@@ -236,28 +314,58 @@ func processMessages(ctx context.Context, valkeyClient *valkey.Client, db *datab
 	// Delete processed messages from stream to free memory
 	// This prevents unbounded stream growth that caused the original issue
 	if len(processedIDs) > 0 {
-		if err := valkeyClient.XDel(ctx, streamKey, processedIDs...); err != nil {
+		if err := q.XDel(ctx, s.key, processedIDs...); err != nil {
 			log.Warn("Failed to delete processed messages from stream",
+				slog.String("stream", s.key),
 				slog.String("error", err.Error()),
 				slog.Int("count", len(processedIDs)))
 		}
 	}
 
 	if successCount > 0 {
-		log.Info("Successfully inserted metrics to PostgreSQL",
+		log.Info("Successfully inserted messages to PostgreSQL",
+			slog.String("stream", s.key),
 			slog.Int("count", successCount))
 	}
 	if errorCount > 0 {
 		log.Warn("Failed to process messages",
+			slog.String("stream", s.key),
 			slog.Int("count", errorCount))
 	}
 
-	return nil
+	return len(messages), nil
 }
 
-func handlePoisonMessages(ctx context.Context, valkeyClient *valkey.Client) error {
+// startMessageSpan continues the trace carried in the message fields (set by ingest)
+// A "stream wait" span covers the time from XADD (encoded in the message ID) until now,
+// so queue wait and processing time show up side by side
+func startMessageSpan(ctx context.Context, s digestStream, msg queue.Message) (context.Context, trace.Span) {
+	ctx = tracing.Extract(ctx, msg.Fields)
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", queueBackend),
+		attribute.String("messaging.destination.name", s.key),
+		attribute.String("messaging.message.id", msg.ID),
+		attribute.String("messaging.consumer.group.name", consumerGroup),
+		attribute.String("server_id", msg.Fields["server_id"]),
+	}
+
+	if enqueuedAt, ok := valkey.StreamIDTime(msg.ID); ok {
+		wait := time.Since(enqueuedAt)
+		attrs = append(attrs, attribute.Int64("messaging.queue_wait_ms", wait.Milliseconds()))
+		_, waitSpan := tracing.Tracer().Start(ctx, "stream wait",
+			trace.WithTimestamp(enqueuedAt), trace.WithAttributes(attrs[:3]...))
+		waitSpan.End()
+	}
+
+	return tracing.Tracer().Start(ctx, "processMessage",
+		trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...))
+}
+
+// handlePoisonMessages moves messages that failed maxRetries times to the DLQ and
+// claims messages left pending by other consumers for longer than claimMinIdle
+func handlePoisonMessages(ctx context.Context, q queue.Queue, s digestStream) error {
 	// Check pending messages for high retry counts
-	pending, err := valkeyClient.XPending(ctx, streamKey, consumerGroup, 100)
+	pending, err := q.XPending(ctx, s.key, consumerGroup, 100)
 	if err != nil {
 		return fmt.Errorf("failed to get pending messages: %w", err)
 	}
@@ -267,10 +375,11 @@ func handlePoisonMessages(ctx context.Context, valkeyClient *valkey.Client) erro
 	}
 
 	poisonCount := 0
+	abandoned := []string{}
 	for _, msg := range pending {
 		if msg.DeliveryCount >= maxRetries {
 			// Fetch full message data
-			fullMessages, err := valkeyClient.XRange(ctx, streamKey, msg.ID)
+			fullMessages, err := q.XRange(ctx, s.key, msg.ID)
 			if err != nil || len(fullMessages) == 0 {
 				log.Warn("Failed to fetch poison message",
 					slog.String("message_id", msg.ID),
@@ -279,7 +388,7 @@ func handlePoisonMessages(ctx context.Context, valkeyClient *valkey.Client) erro
 			}
 
 			// Move to DLQ
-			err = valkeyClient.MoveToDLQ(ctx, streamKey, dlqStreamKey, msg.ID, fullMessages[0].Fields, msg.DeliveryCount)
+			err = q.MoveToDLQ(ctx, s.key, s.dlqKey, msg.ID, fullMessages[0].Fields, msg.DeliveryCount)
 			if err != nil {
 				log.Error("Failed to move message to DLQ",
 					slog.String("message_id", msg.ID),
@@ -288,21 +397,39 @@ func handlePoisonMessages(ctx context.Context, valkeyClient *valkey.Client) erro
 			}
 
 			// ACK the poison message to remove from pending
-			valkeyClient.XAck(ctx, streamKey, consumerGroup, msg.ID)
+			q.XAck(ctx, s.key, consumerGroup, msg.ID)
 			poisonCount++
+			continue
+		}
+
+		if msg.Consumer != consumerName && msg.ElapsedTime >= claimMinIdle {
+			abandoned = append(abandoned, msg.ID)
+		}
+	}
+
+	// Claimed messages are re-read with this consumer's pending messages
+	if len(abandoned) > 0 {
+		claimed, err := q.XClaim(ctx, s.key, consumerGroup, consumerName, claimMinIdle, abandoned...)
+		if err != nil {
+			return fmt.Errorf("failed to claim abandoned messages: %w", err)
+		}
+		if len(claimed) > 0 {
+			log.Info("Claimed messages abandoned by other consumers",
+				slog.String("stream", s.key),
+				slog.Int("count", len(claimed)))
 		}
 	}
 
 	if poisonCount > 0 {
 		log.Warn("Moved poison messages to dead letter queue",
 			slog.Int("count", poisonCount),
-			slog.String("dlq_stream", dlqStreamKey))
+			slog.String("dlq_stream", s.dlqKey))
 	}
 
 	return nil
 }
 
-func processMessage(ctx context.Context, db *database.DB, msg valkey.StreamMessage) error {
+func processMessage(ctx context.Context, db *database.DB, stream string, msg queue.Message) error {
 	// Extract server_id and raw payload from stream message (new simplified format)
 	serverID, ok := msg.Fields["server_id"]
 	if !ok {
@@ -315,12 +442,42 @@ func processMessage(ctx context.Context, db *database.DB, msg valkey.StreamMessa
 	}
 
 	// Process with transaction - all-or-nothing approach (with context timeout)
-	return processor.ProcessMessageWithTransaction(ctx, db, serverID, payloadJSON)
+	return processor.ProcessMessageWithTransaction(ctx, db, stream, msg.ID, serverID, payloadJSON)
+}
+
+// processLogMessage writes a batch of log lines from nodepulse:logs:stream
+func processLogMessage(ctx context.Context, db *database.DB, stream string, msg queue.Message) error {
+	serverID, ok := msg.Fields["server_id"]
+	if !ok {
+		return fmt.Errorf("missing server_id in message")
+	}
+
+	payloadJSON, ok := msg.Fields["payload"]
+	if !ok {
+		return fmt.Errorf("missing payload in message")
+	}
+
+	return processor.ProcessLogsWithTransaction(ctx, db, stream, msg.ID, serverID, payloadJSON)
 }
 
-func startHealthServer(db *database.DB, valkeyClient *valkey.Client) {
+// processEventMessage stores a batch of agent events from nodepulse:events:stream
+func processEventMessage(ctx context.Context, db *database.DB, stream string, msg queue.Message) error {
+	serverID, ok := msg.Fields["server_id"]
+	if !ok {
+		return fmt.Errorf("missing server_id in message")
+	}
+
+	payloadJSON, ok := msg.Fields["payload"]
+	if !ok {
+		return fmt.Errorf("missing payload in message")
+	}
+
+	return processor.ProcessEventsWithTransaction(ctx, db, stream, msg.ID, serverID, payloadJSON)
+}
+
+func startHealthServer(db *database.DB, q queue.Queue) {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/health", health.Handler(db, valkeyClient, "1.0.0"))
+	mux.HandleFunc("/health", health.Handler(db, q, "digest-worker", "1.0.0"))
 
 	server := &http.Server{
 		Addr:    ":8081",
@@ -349,3 +506,14 @@ func runCleanup(ctx context.Context, c *cleaner.Cleaner) {
 		log.Info("Cleanup completed successfully")
 	}
 }
+
+func runStatusMonitor(ctx context.Context, m *serverstatus.Monitor) {
+	// Create context with timeout for this run (shorter than the ticker interval)
+	statusCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	if err := m.Run(statusCtx); err != nil {
+		log.Error("Server status evaluation failed",
+			slog.String("error", err.Error()))
+	}
+}
@@ -1,18 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/nodepulse/admiral/submarines/internal/config"
 	"github.com/nodepulse/admiral/submarines/internal/database"
 	"github.com/nodepulse/admiral/submarines/internal/handlers"
-	"github.com/nodepulse/admiral/submarines/internal/valkey"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+	"github.com/nodepulse/admiral/submarines/internal/spool"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
 	"github.com/nodepulse/admiral/submarines/internal/validation"
+	"github.com/nodepulse/admiral/submarines/internal/valkey"
 )
 
+// valkeyReconnectInterval is how often ingest retries Valkey in spool-only mode
+const valkeyReconnectInterval = 5 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.Load()
@@ -27,12 +36,42 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize Valkey
-	valkeyClient, err := valkey.New(cfg)
+	// Initialize tracing (W3C trace context is forwarded to digest through the streams)
+	shutdownTracing, err := tracing.Init(context.Background(), cfg, "submarines-ingest")
 	if err != nil {
-		log.Fatalf("Failed to initialize Valkey: %v", err)
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Initialize the disk spool (metrics that can't be published while the queue is unavailable)
+	var metricsSpool *spool.Spool
+	if cfg.IngestSpoolEnabled {
+		metricsSpool, err = spool.Open(spool.Options{
+			Dir:          cfg.IngestSpoolDir,
+			MaxBytes:     int64(cfg.IngestSpoolMaxMB) << 20,
+			SegmentBytes: int64(cfg.IngestSpoolSegmentMB) << 20,
+			Fsync:        cfg.IngestSpoolFsync,
+		})
+		if err != nil {
+			log.Fatalf("Failed to open spool: %v", err)
+		}
+		defer metricsSpool.Close()
+	}
+
+	// Initialize the queue (valkeyClient is nil with the embedded backend)
+	// When Valkey is unreachable at startup and the spool is enabled, ingest starts in
+	// spool-only mode and keeps connecting in the background; server IDs are then
+	// validated without the Valkey cache and stream introspection answers 503 until connected
+	var reconnecting *queue.Reconnecting
+	q, valkeyClient, err := queue.Open(cfg)
+	if errors.Is(err, valkey.ErrUnreachable) && metricsSpool != nil {
+		log.Printf("[WARN] Valkey unreachable, starting in spool-only mode: %v", err)
+		reconnecting = queue.OpenReconnecting(cfg, valkeyReconnectInterval)
+		q, valkeyClient = reconnecting, nil
+	} else if err != nil {
+		log.Fatalf("Failed to initialize queue: %v", err)
 	}
-	defer valkeyClient.Close()
+	defer q.Close()
 
 	// Initialize router
 	router := gin.Default()
@@ -54,26 +93,50 @@ func main() {
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
+		body := gin.H{
 			"status":  "ok",
 			"service": "node-pulse-ingest",
 			"env":     env,
-		})
+		}
+		if metricsSpool != nil {
+			body["spool"] = metricsSpool.Stats()
+		}
+		c.JSON(200, body)
 	})
 
-	// Initialize server ID validator (with Valkey caching)
+	// Initialize server ID validator (with Valkey caching, database only without Valkey)
 	// This validator runs REGARDLESS of mTLS state - it's an independent security layer
 	serverIDValidator := validation.NewServerIDValidator(db.DB, valkeyClient.GetClient(), cfg.ServerIDCacheTTL)
 
+	// Stream introspection needs a Valkey client, which may still be connecting
+	streamClient := func() (*valkey.Client, error) {
+		return nil, errors.New("stream introspection requires the valkey queue backend")
+	}
+	switch {
+	case valkeyClient != nil:
+		streamClient = func() (*valkey.Client, error) { return valkeyClient, nil }
+	case reconnecting != nil:
+		streamClient = reconnecting.Client
+	}
+
 	// Initialize handlers (with server ID validation)
-	prometheusHandler := handlers.NewPrometheusHandler(db, valkeyClient, serverIDValidator)
+	prometheusHandler := handlers.NewPrometheusHandler(db, q, serverIDValidator, metricsSpool)
 	certificateHandler := handlers.NewCertificateHandler(db.DB, cfg)
+	alertHandler := handlers.NewAlertHandler(db.DB)
+	silenceHandler := handlers.NewSilenceHandler(db.DB)
+	onCallHandler := handlers.NewOnCallHandler(db.DB)
+	forecastHandler := handlers.NewForecastHandler(db.DB)
+	fleetHandler := handlers.NewFleetHandler(db.DB)
+	logHandler := handlers.NewLogHandler(db.DB, q, serverIDValidator)
+	eventHandler := handlers.NewEventHandler(db.DB, q, serverIDValidator)
 
 	// Ingest routes (for agents only)
 	// mTLS is handled at Caddy layer (optional, enabled via dashboard)
 	// Server ID validation happens in handler regardless of mTLS
 	router.POST("/metrics/prometheus", prometheusHandler.IngestPrometheusMetrics)
 	router.GET("/metrics/prometheus/health", prometheusHandler.HealthCheck)
+	router.POST("/logs", logHandler.IngestLogs)
+	router.POST("/events", eventHandler.IngestEvents)
 
 	// Internal API routes (for Flagship/deployer only, not exposed publicly)
 	internal := router.Group("/internal")
@@ -86,6 +149,52 @@ func main() {
 
 		// CA management
 		internal.POST("/ca/create", certificateHandler.CreateCA)
+
+		// Alerts
+		internal.GET("/alerts", alertHandler.ListAlerts)
+		internal.GET("/alerts/stats", alertHandler.GetAlertStats)
+		internal.GET("/alerts/:id", alertHandler.GetAlert)
+		internal.POST("/alerts/:id/acknowledge", alertHandler.AcknowledgeAlert)
+		internal.POST("/alerts/:id/unacknowledge", alertHandler.UnacknowledgeAlert)
+		internal.POST("/alerts/:id/resolve", alertHandler.ResolveAlert)
+
+		// Alert silences and maintenance windows
+		internal.GET("/silences", silenceHandler.ListSilences)
+		internal.POST("/silences", silenceHandler.CreateSilence)
+		internal.DELETE("/silences/:id", silenceHandler.ExpireSilence)
+		internal.GET("/maintenance-windows", silenceHandler.ListMaintenanceWindows)
+		internal.POST("/maintenance-windows", silenceHandler.CreateMaintenanceWindow)
+		internal.PATCH("/maintenance-windows/:id", silenceHandler.UpdateMaintenanceWindow)
+		internal.DELETE("/maintenance-windows/:id", silenceHandler.DeleteMaintenanceWindow)
+
+		// On-call
+		internal.GET("/oncall/schedules/:id/current", onCallHandler.GetCurrentOnCall)
+
+		// Forecasts
+		internal.GET("/forecasts", forecastHandler.ListForecasts)
+
+		// Fleet queries
+		internal.GET("/fleet/top", fleetHandler.TopServers)
+		internal.GET("/fleet/servers", fleetHandler.FilterServers)
+		internal.GET("/fleet/aggregate", fleetHandler.AggregateMetric)
+		internal.GET("/fleet/processes/top", fleetHandler.TopProcesses)
+
+		// Host logs
+		internal.GET("/logs", logHandler.SearchLogs)
+
+		// Server event timeline
+		internal.GET("/events", eventHandler.ListEvents)
+
+		// Stream and consumer group state (XINFO, Valkey backend only - 503 otherwise)
+		internal.GET("/streams", handlers.NewStreamHandler(streamClient).ListStreams)
+	}
+
+	// Replay spooled metrics once the queue is reachable again (oldest first)
+	if metricsSpool != nil {
+		replayCtx, stopReplay := context.WithCancel(context.Background())
+		defer stopReplay()
+		go metricsSpool.Run(replayCtx, time.Second, 500, prometheusHandler.ReplaySpooled)
+		log.Printf("Spool: %s (max %d MB, fsync %s)", cfg.IngestSpoolDir, cfg.IngestSpoolMaxMB, cfg.IngestSpoolFsync)
 	}
 
 	// Start server
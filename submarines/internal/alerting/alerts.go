@@ -0,0 +1,258 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+var (
+	// ErrAlertNotFound is returned when an alert ID doesn't exist
+	ErrAlertNotFound = errors.New("alert not found")
+
+	// ErrInvalidTransition is returned when an action isn't allowed from the alert's current status
+	ErrInvalidTransition = errors.New("invalid alert state transition")
+)
+
+// alertColumns is the column list scanned by scanAlert
+const alertColumns = `
+	id, server_id, rule_id::text, alert_type, severity, message,
+	threshold_value, current_value, COALESCE(metadata, '{}'::jsonb), COALESCE(status, 'active'),
+	acknowledged_at, acknowledged_by, resolved_at, resolved_by, last_evaluated_at,
+	created_at, updated_at
+`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlert(row rowScanner) (*models.Alert, error) {
+	var a models.Alert
+	var metadata []byte
+	err := row.Scan(
+		&a.ID, &a.ServerID, &a.RuleID, &a.AlertType, &a.Severity, &a.Message,
+		&a.ThresholdValue, &a.CurrentValue, &metadata, &a.Status,
+		&a.AcknowledgedAt, &a.AcknowledgedBy, &a.ResolvedAt, &a.ResolvedBy, &a.LastEvaluatedAt,
+		&a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.Metadata = metadata
+	return &a, nil
+}
+
+// AlertFilter selects alerts for ListAlerts (zero values are ignored)
+type AlertFilter struct {
+	Statuses  []string
+	Severity  string
+	ServerID  string // admiral.servers.id
+	RuleID    string
+	AlertType string
+	Since     time.Time // created_at >= Since
+	Until     time.Time // created_at < Until
+	Limit     int
+	Offset    int
+}
+
+// ListAlerts returns alerts matching the filter, newest first, and the total match count
+func ListAlerts(ctx context.Context, db *sql.DB, f AlertFilter) ([]models.Alert, int, error) {
+	conditions := []string{}
+	args := []any{}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if len(f.Statuses) > 0 {
+		placeholders := make([]string, len(f.Statuses))
+		for i, status := range f.Statuses {
+			args = append(args, status)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, "COALESCE(status, 'active') IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.Severity != "" {
+		add("severity = $%d", f.Severity)
+	}
+	if f.ServerID != "" {
+		add("server_id = $%d", f.ServerID)
+	}
+	if f.RuleID != "" {
+		add("rule_id = $%d", f.RuleID)
+	}
+	if f.AlertType != "" {
+		add("alert_type = $%d", f.AlertType)
+	}
+	if !f.Since.IsZero() {
+		add("created_at >= $%d", f.Since)
+	}
+	if !f.Until.IsZero() {
+		add("created_at < $%d", f.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM admiral.alerts "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count alerts: %w", err)
+	}
+
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit, max(f.Offset, 0))
+	query := fmt.Sprintf("SELECT %s FROM admiral.alerts %s ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d",
+		alertColumns, where, len(args)-1, len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []models.Alert{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, *a)
+	}
+
+	return alerts, total, rows.Err()
+}
+
+// GetAlert returns a single alert
+func GetAlert(ctx context.Context, db *sql.DB, alertID string) (*models.Alert, error) {
+	a, err := scanAlert(db.QueryRowContext(ctx, "SELECT "+alertColumns+" FROM admiral.alerts WHERE id = $1", alertID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAlertNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert %s: %w", alertID, err)
+	}
+	return a, nil
+}
+
+// Acknowledge marks an active alert as acknowledged by a user
+func Acknowledge(ctx context.Context, db *sql.DB, alertID string, userID *int64, comment string) (*models.Alert, *Event, error) {
+	return transition(ctx, db, alertID, EventAcknowledged,
+		[]string{models.AlertStatusActive}, models.AlertStatusAcknowledged, userID, comment, `
+		UPDATE admiral.alerts
+		SET status = $2, acknowledged_at = NOW(), acknowledged_by = $3
+		WHERE id = $1
+	`, userID)
+}
+
+// Unacknowledge returns an acknowledged alert to active
+func Unacknowledge(ctx context.Context, db *sql.DB, alertID string, userID *int64, comment string) (*models.Alert, *Event, error) {
+	return transition(ctx, db, alertID, EventUnacknowledged,
+		[]string{models.AlertStatusAcknowledged}, models.AlertStatusActive, userID, comment, `
+		UPDATE admiral.alerts
+		SET status = $2, acknowledged_at = NULL, acknowledged_by = NULL
+		WHERE id = $1
+	`)
+}
+
+// Resolve manually resolves an open alert
+// If a rule's condition still holds, the alerter opens a new alert on its next cycle
+func Resolve(ctx context.Context, db *sql.DB, alertID string, userID *int64, comment string) (*models.Alert, *Event, error) {
+	return transition(ctx, db, alertID, EventResolved,
+		[]string{models.AlertStatusActive, models.AlertStatusAcknowledged}, models.AlertStatusResolved, userID, comment, `
+		UPDATE admiral.alerts
+		SET status = $2, resolved_at = NOW(), resolved_by = $3,
+		    metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('resolution', 'manually resolved')
+		WHERE id = $1
+	`, userID)
+}
+
+// transition applies a user action to an alert and appends it to the timeline and the outbox
+// update receives $1 = alert ID, $2 = new status, followed by args
+func transition(ctx context.Context, db *sql.DB, alertID, eventType string, from []string, to string, userID *int64, comment, update string, args ...any) (*models.Alert, *Event, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	var current string
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(status, 'active') FROM admiral.alerts WHERE id = $1 FOR UPDATE`, alertID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrAlertNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock alert %s: %w", alertID, err)
+	}
+
+	if !slices.Contains(from, current) {
+		return nil, nil, fmt.Errorf("%w: cannot mark %s alert as %s", ErrInvalidTransition, current, eventType)
+	}
+
+	if _, err := tx.ExecContext(ctx, update, append([]any{alertID, to}, args...)...); err != nil {
+		return nil, nil, fmt.Errorf("failed to update alert %s: %w", alertID, err)
+	}
+
+	if err := recordTimeline(ctx, tx, alertID, eventType, current, to, userID, comment); err != nil {
+		return nil, nil, err
+	}
+
+	alert, err := scanAlert(tx.QueryRowContext(ctx, "SELECT "+alertColumns+" FROM admiral.alerts WHERE id = $1", alertID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reload alert %s: %w", alertID, err)
+	}
+
+	event := eventFromAlert(alert, eventType, userID, comment)
+	if err := writeOutbox(ctx, tx, []Event{*event}); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return alert, event, nil
+}
+
+// eventFromAlert builds a notification event for a user-driven transition
+func eventFromAlert(a *models.Alert, eventType string, userID *int64, comment string) *Event {
+	event := &Event{
+		Type:         eventType,
+		AlertID:      a.ID,
+		ServerID:     a.ServerID,
+		AlertType:    a.AlertType,
+		Severity:     a.Severity,
+		Message:      a.Message,
+		Threshold:    a.ThresholdValue,
+		CurrentValue: a.CurrentValue,
+		Reason:       comment,
+		UserID:       userID,
+		OccurredAt:   time.Now(),
+	}
+	if a.RuleID != nil {
+		event.RuleID = *a.RuleID
+	}
+
+	var meta struct {
+		RuleName string `json:"rule_name"`
+		Hostname string `json:"hostname"`
+	}
+	if err := json.Unmarshal(a.Metadata, &meta); err == nil {
+		event.RuleName = meta.RuleName
+		event.Hostname = meta.Hostname
+	}
+
+	return event
+}
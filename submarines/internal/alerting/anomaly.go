@@ -0,0 +1,512 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// conditionAnomaly marks rules compared against a learned baseline
+// The rule's threshold is the number of standard deviations (k) a value may deviate
+const conditionAnomaly = "anomaly"
+
+const (
+	// hoursPerWeek is the number of seasonal buckets
+	hoursPerWeek = 7 * 24
+
+	// ewmaAlpha is the minimum weight of a new sample in the overall baseline
+	// Until 1/alpha samples are seen the baseline is a plain running mean
+	ewmaAlpha = 0.01
+
+	// seasonalAlpha is the minimum weight of a new sample in an hour-of-week bucket
+	// A bucket gets one hour of samples per week, so it adapts over a few weeks
+	seasonalAlpha = 0.005
+
+	// minBaselineSamples is how many samples the overall baseline needs before scoring
+	minBaselineSamples = 120
+
+	// minSeasonalSamples is how many samples a bucket needs before it replaces the overall baseline
+	minSeasonalSamples = 60
+
+	// clampSigma limits how far an anomalous value pulls the baseline
+	clampSigma = 3.0
+
+	// baselineHistory is how much admiral.metrics history seeds a new baseline
+	baselineHistory = 14 * 24 * time.Hour
+
+	// maxSeedsPerRun bounds history scans per BaselineSeeder run
+	maxSeedsPerRun = 10
+
+	// emptySeedRetry is how long a baseline whose history had nothing to learn from
+	// (e.g. swap_usage on a server without swap) is skipped before it is tried again
+	emptySeedRetry = time.Hour
+)
+
+// minStdDev keeps near-constant series from turning noise into anomalies
+// Percentages are in points, load averages in absolute load
+var minStdDev = map[string]float64{
+	"cpu_usage":    2.0,
+	"cpu_iowait":   1.0,
+	"memory_usage": 1.0,
+	"swap_usage":   1.0,
+	"disk_usage":   0.5,
+	"load_1min":    0.2,
+	"load_5min":    0.1,
+	"load_15min":   0.1,
+}
+
+// stats is an exponentially weighted mean and variance
+type stats struct {
+	Mean     float64
+	Variance float64
+	Count    int64
+}
+
+// add folds a value in with weight max(1/n, minAlpha)
+func (s *stats) add(value, minAlpha float64) {
+	s.Count++
+	alpha := math.Max(1/float64(s.Count), minAlpha)
+	diff := value - s.Mean
+	incr := alpha * diff
+	s.Mean += incr
+	s.Variance = (1 - alpha) * (s.Variance + diff*incr)
+}
+
+type baselineKey struct {
+	serverID   string // Agent server_id
+	metricType string
+}
+
+// baseline is the learned normal behaviour of one metric on one server
+type baseline struct {
+	overall      stats
+	seasonal     [hoursPerWeek]stats
+	lastSampleAt time.Time
+	dirty        map[int]bool // Seasonal buckets changed since loading
+}
+
+// anomalyScore describes how far a value is from its baseline
+type anomalyScore struct {
+	Expected float64 // Baseline mean for the value's hour of the week
+	StdDev   float64
+	Sigma    float64 // Signed deviation in standard deviations
+	Seasonal bool    // Whether the hour-of-week bucket was used
+}
+
+// hourOfWeek returns the seasonal bucket of a timestamp (0 = Sunday 00:00 UTC)
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// ready reports whether the baseline has seen enough data to score values
+func (b *baseline) ready() bool {
+	return b.overall.Count >= minBaselineSamples
+}
+
+// score compares a value against the seasonal bucket of its timestamp,
+// falling back to the overall baseline while the bucket is still learning
+func (b *baseline) score(metricType string, p point) anomalyScore {
+	s := b.overall
+	bucket := b.seasonal[hourOfWeek(p.Timestamp)]
+	seasonal := bucket.Count >= minSeasonalSamples
+	if seasonal {
+		s = bucket
+	}
+
+	floor, ok := minStdDev[metricType]
+	if !ok {
+		floor = 0.01
+	}
+	stdDev := math.Max(math.Sqrt(s.Variance), floor)
+	return anomalyScore{
+		Expected: s.Mean,
+		StdDev:   stdDev,
+		Sigma:    (p.Value - s.Mean) / stdDev,
+		Seasonal: seasonal,
+	}
+}
+
+// learn folds points newer than the last learned sample into the baseline
+// Anomalous values are clamped so an incident doesn't become the new normal at once
+func (b *baseline) learn(metricType string, points []point) int {
+	learned := 0
+	for _, p := range points {
+		if !p.Timestamp.After(b.lastSampleAt) {
+			continue
+		}
+
+		value := p.Value
+		if b.ready() {
+			sc := b.score(metricType, p)
+			limit := clampSigma * sc.StdDev
+			value = math.Max(sc.Expected-limit, math.Min(sc.Expected+limit, value))
+		}
+
+		b.overall.add(value, ewmaAlpha)
+		bucket := hourOfWeek(p.Timestamp)
+		b.seasonal[bucket].add(value, seasonalAlpha)
+		if b.dirty == nil {
+			b.dirty = make(map[int]bool)
+		}
+		b.dirty[bucket] = true
+		b.lastSampleAt = p.Timestamp
+		learned++
+	}
+	return learned
+}
+
+// evaluateAnomaly decides the rule state for one server's series (oldest first)
+// Every point in the last duration_seconds must deviate by more than threshold standard deviations
+func evaluateAnomaly(rule *models.AlertRule, b *baseline, points []point, now time.Time) (ruleState, float64, *anomalyScore) {
+	if len(points) == 0 || b == nil || !b.ready() {
+		return stateUnknown, 0, nil
+	}
+
+	latest := points[len(points)-1]
+	if now.Sub(latest.Timestamp) > maxSampleAge {
+		return stateUnknown, latest.Value, nil
+	}
+
+	sc := b.score(rule.MetricType, latest)
+	if math.Abs(sc.Sigma) <= rule.Threshold {
+		return stateOK, latest.Value, &sc
+	}
+
+	breachStart := latest.Timestamp
+	for i := len(points) - 1; i >= 0; i-- {
+		if s := b.score(rule.MetricType, points[i]); math.Abs(s.Sigma) <= rule.Threshold {
+			break
+		}
+		breachStart = points[i].Timestamp
+	}
+
+	if latest.Timestamp.Sub(breachStart) >= time.Duration(rule.DurationSeconds)*time.Second {
+		return stateFiring, latest.Value, &sc
+	}
+	return statePending, latest.Value, &sc
+}
+
+// baselineStore holds the baselines used in one evaluation cycle
+type baselineStore struct {
+	baselines map[baselineKey]*baseline
+	used      map[baselineKey]bool
+}
+
+// loadBaselines reads the baselines of the given metric types
+func loadBaselines(ctx context.Context, q queryer, metricTypes []string) (*baselineStore, error) {
+	store := &baselineStore{
+		baselines: make(map[baselineKey]*baseline),
+		used:      make(map[baselineKey]bool),
+	}
+	if len(metricTypes) == 0 {
+		return store, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT server_id, metric_type, mean, variance, sample_count, last_sample_at
+		FROM admiral.anomaly_baselines
+		WHERE metric_type = ANY($1)
+	`, pq.Array(metricTypes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query anomaly baselines: %w", err)
+	}
+	for rows.Next() {
+		var key baselineKey
+		var lastSampleAt sql.NullTime
+		b := &baseline{}
+		if err := rows.Scan(&key.serverID, &key.metricType,
+			&b.overall.Mean, &b.overall.Variance, &b.overall.Count, &lastSampleAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan anomaly baseline: %w", err)
+		}
+		b.lastSampleAt = lastSampleAt.Time
+		store.baselines[key] = b
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT server_id, metric_type, hour_of_week, mean, variance, sample_count
+		FROM admiral.anomaly_seasonal_baselines
+		WHERE metric_type = ANY($1)
+	`, pq.Array(metricTypes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query seasonal baselines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key baselineKey
+		var hour int
+		var s stats
+		if err := rows.Scan(&key.serverID, &key.metricType, &hour, &s.Mean, &s.Variance, &s.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan seasonal baseline: %w", err)
+		}
+		if b, ok := store.baselines[key]; ok && hour >= 0 && hour < hoursPerWeek {
+			b.seasonal[hour] = s
+		}
+	}
+
+	return store, rows.Err()
+}
+
+// get returns the baseline of a server and metric
+// Returns nil until BaselineSeeder created it from admiral.metrics history
+func (s *baselineStore) get(key baselineKey) *baseline {
+	b, ok := s.baselines[key]
+	if ok {
+		s.used[key] = true
+	}
+	return b
+}
+
+// learn folds this cycle's samples into every baseline a rule used
+func (s *baselineStore) learn(samples map[string][]sample) {
+	for key := range s.used {
+		fn, ok := metricTypes[key.metricType]
+		if !ok {
+			continue
+		}
+		s.baselines[key].learn(key.metricType, computeSeries(fn, samples[key.serverID]))
+	}
+}
+
+// save persists baselines that changed in this cycle
+func (s *baselineStore) save(ctx context.Context, tx *sql.Tx) error {
+	for key, b := range s.baselines {
+		if len(b.dirty) == 0 {
+			continue
+		}
+
+		var lastSampleAt any
+		if !b.lastSampleAt.IsZero() {
+			lastSampleAt = b.lastSampleAt
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO admiral.anomaly_baselines (server_id, metric_type, mean, variance, sample_count, last_sample_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (server_id, metric_type) DO UPDATE
+			SET mean = EXCLUDED.mean, variance = EXCLUDED.variance, sample_count = EXCLUDED.sample_count,
+			    last_sample_at = EXCLUDED.last_sample_at, updated_at = NOW()
+		`, key.serverID, key.metricType, b.overall.Mean, b.overall.Variance, b.overall.Count, lastSampleAt)
+		if err != nil {
+			return fmt.Errorf("failed to save %s baseline for %s: %w", key.metricType, key.serverID, err)
+		}
+
+		for hour := range b.dirty {
+			bucket := b.seasonal[hour]
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO admiral.anomaly_seasonal_baselines (server_id, metric_type, hour_of_week, mean, variance, sample_count)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (server_id, metric_type, hour_of_week) DO UPDATE
+				SET mean = EXCLUDED.mean, variance = EXCLUDED.variance, sample_count = EXCLUDED.sample_count,
+				    updated_at = NOW()
+			`, key.serverID, key.metricType, hour, bucket.Mean, bucket.Variance, bucket.Count)
+			if err != nil {
+				return fmt.Errorf("failed to save seasonal %s baseline for %s: %w", key.metricType, key.serverID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// BaselineSeeder creates missing baselines of enabled anomaly rules from admiral.metrics history
+// It runs outside the evaluation transaction: scanning baselineHistory of raw samples is too
+// slow for the advisory-locked evaluation cycle. Baselines that already exist are left untouched,
+// so concurrent alerter instances may both seed without harm
+type BaselineSeeder struct {
+	db      *sql.DB
+	retryAt map[baselineKey]time.Time // Baselines whose history had nothing to learn from
+}
+
+// NewBaselineSeeder creates a new BaselineSeeder instance
+func NewBaselineSeeder(db *sql.DB) *BaselineSeeder {
+	return &BaselineSeeder{
+		db:      db,
+		retryAt: make(map[baselineKey]time.Time),
+	}
+}
+
+// Run seeds up to maxSeedsPerRun missing baselines
+// Returns the number of baselines created
+func (s *BaselineSeeder) Run(ctx context.Context) (int, error) {
+	now := time.Now()
+	for key, at := range s.retryAt {
+		if !now.Before(at) {
+			delete(s.retryAt, key)
+		}
+	}
+
+	missing, err := missingBaselines(ctx, s.db, now.Add(-baselineHistory), s.retryAt)
+	if err != nil {
+		return 0, err
+	}
+
+	seeded := 0
+	for _, key := range missing {
+		if ctx.Err() != nil {
+			break
+		}
+
+		samples, err := loadServerSamples(ctx, s.db, key.serverID, now.Add(-baselineHistory))
+		if err != nil {
+			return seeded, err
+		}
+
+		b := &baseline{}
+		learned := b.learn(key.metricType, computeSeries(metricTypes[key.metricType], samples))
+		if learned == 0 {
+			// Nothing to learn from yet - skip it for a while so it doesn't take
+			// the place of other missing baselines on every run
+			s.retryAt[key] = now.Add(emptySeedRetry)
+			continue
+		}
+
+		created, err := insertBaseline(ctx, s.db, key, b)
+		if err != nil {
+			return seeded, err
+		}
+		if created {
+			seeded++
+			log.Printf("[INFO] Seeded %s anomaly baseline for %s from %d samples", key.metricType, key.serverID, learned)
+		}
+	}
+	return seeded, nil
+}
+
+// missingBaselines lists up to maxSeedsPerRun (server, metric) pairs an enabled
+// anomaly rule applies to that have no baseline yet, skipping servers without
+// metrics since the given time and pairs in skip
+func missingBaselines(ctx context.Context, db *sql.DB, since time.Time, skip map[baselineKey]time.Time) ([]baselineKey, error) {
+	rules, err := loadRules(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalyRules := []*models.AlertRule{}
+	metrics := []string{}
+	for i := range rules {
+		rule := &rules[i]
+		if _, ok := metricTypes[rule.MetricType]; !ok || rule.Condition != conditionAnomaly {
+			continue
+		}
+		anomalyRules = append(anomalyRules, rule)
+		metrics = append(metrics, rule.MetricType)
+	}
+	if len(anomalyRules) == 0 {
+		return nil, nil
+	}
+
+	targets, err := loadTargets(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	withHistory, err := serversWithHistory(ctx, db, since)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := loadBaselines(ctx, db, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[baselineKey]bool)
+	missing := []baselineKey{}
+	for _, rule := range anomalyRules {
+		for j := range targets {
+			t := &targets[j]
+			if !withHistory[t.ServerID] || !ruleApplies(rule, t) {
+				continue
+			}
+			key := baselineKey{serverID: t.ServerID, metricType: rule.MetricType}
+			if _, skipped := skip[key]; skipped || seen[key] || existing.baselines[key] != nil {
+				continue
+			}
+			seen[key] = true
+			missing = append(missing, key)
+			if len(missing) >= maxSeedsPerRun {
+				return missing, nil
+			}
+		}
+	}
+	return missing, nil
+}
+
+// serversWithHistory returns the agent server_ids with admiral.metrics samples since the given time
+func serversWithHistory(ctx context.Context, db *sql.DB, since time.Time) (map[string]bool, error) {
+	query := `
+		SELECT s.server_id
+		FROM admiral.servers s
+		WHERE s.status IS DISTINCT FROM 'inactive'
+		  AND EXISTS (
+			SELECT 1 FROM admiral.metrics m
+			WHERE m.server_id = s.server_id AND m.timestamp > $1
+		  )
+	`
+
+	rows, err := db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query servers with metrics history: %w", err)
+	}
+	defer rows.Close()
+
+	servers := make(map[string]bool)
+	for rows.Next() {
+		var serverID string
+		if err := rows.Scan(&serverID); err != nil {
+			return nil, fmt.Errorf("failed to scan server: %w", err)
+		}
+		servers[serverID] = true
+	}
+
+	return servers, rows.Err()
+}
+
+// insertBaseline stores a seeded baseline unless one exists already
+func insertBaseline(ctx context.Context, db *sql.DB, key baselineKey, b *baseline) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO admiral.anomaly_baselines (server_id, metric_type, mean, variance, sample_count, last_sample_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (server_id, metric_type) DO NOTHING
+	`, key.serverID, key.metricType, b.overall.Mean, b.overall.Variance, b.overall.Count, b.lastSampleAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to seed %s baseline for %s: %w", key.metricType, key.serverID, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	for hour := range b.dirty {
+		bucket := b.seasonal[hour]
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO admiral.anomaly_seasonal_baselines (server_id, metric_type, hour_of_week, mean, variance, sample_count)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (server_id, metric_type, hour_of_week) DO NOTHING
+		`, key.serverID, key.metricType, hour, bucket.Mean, bucket.Variance, bucket.Count)
+		if err != nil {
+			return false, fmt.Errorf("failed to seed seasonal %s baseline for %s: %w", key.metricType, key.serverID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,360 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+const (
+	// maxPagesPerRun bounds how many due escalations one run claims
+	maxPagesPerRun = 50
+
+	// pageClaimTimeout re-arms a claimed tier whose page never completed (e.g. crashed notifier)
+	// Must exceed the longest delivery of a page (retries across all targets)
+	pageClaimTimeout = 10 * time.Minute
+
+	// pageRetryDelay is the wait before a tier that reached none of its targets is paged again
+	pageRetryDelay = time.Minute
+)
+
+// Escalation target types (admiral.escalation_tiers.targets)
+const (
+	TargetUser     = "user"     // admiral.users.id, paged by email
+	TargetSchedule = "schedule" // admiral.oncall_schedules.id, the current on-call user is paged by email
+	TargetChannel  = "channel"  // admiral.notification_channels.id
+)
+
+// EscalationTarget is one entry of a tier's targets
+type EscalationTarget struct {
+	Type string          `json:"type"`
+	ID   json.RawMessage `json:"id"` // Number for users, UUID string otherwise
+}
+
+// Page is a tier of an escalation policy that has to be notified now
+// The escalation only advances once the page reached a target (CompletePage),
+// a page that reached nobody re-arms the tier (RetryPage)
+type Page struct {
+	Event          *Event
+	PolicyID       string
+	EmailChannelID string // Email transport for user and schedule targets (empty = first email channel)
+	Tier           int
+	Repeat         int
+	Targets        []EscalationTarget
+
+	claimedTier int           // next_tier when claimed, guards against concurrent changes
+	nextTier    int           // 0 = policy exhausted after this page
+	nextRepeat  int           // repeat_count after this page
+	wait        time.Duration // Until the next tier is due
+}
+
+type escalationTier struct {
+	tier    int
+	timeout time.Duration
+	targets []EscalationTarget
+}
+
+// ClaimEscalations starts escalations for new active alerts, stops those whose alert
+// was acknowledged or resolved, and claims the tiers that are due
+// A claimed tier isn't due again until pageClaimTimeout, so it is paged by one notifier only
+func ClaimEscalations(ctx context.Context, db *sql.DB) ([]Page, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	if err := startEscalations(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := stopEscalations(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	pages, err := claimDuePages(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pages, nil
+}
+
+// startEscalations creates escalation state for active alerts whose rule has a policy
+// Alerts that were acknowledged and then unacknowledged start over from tier 1
+func startEscalations(ctx context.Context, tx *sql.Tx) error {
+	query := `
+		INSERT INTO admiral.alert_escalations (alert_id, policy_id, next_escalation_at)
+		SELECT a.id, p.id, NOW()
+		FROM admiral.alerts a
+		JOIN admiral.alert_rules r ON r.id = a.rule_id
+		JOIN admiral.escalation_policies p ON p.id = r.escalation_policy_id AND p.enabled = true
+		WHERE COALESCE(a.status, 'active') = 'active'
+		ON CONFLICT (alert_id) DO UPDATE
+		SET next_tier = 1, repeat_count = 0, next_escalation_at = NOW(),
+		    stopped_at = NULL, stop_reason = NULL
+		WHERE admiral.alert_escalations.stop_reason = 'acknowledged'
+	`
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to start escalations: %w", err)
+	}
+	return nil
+}
+
+// stopEscalations ends escalations that no longer need to page anyone
+func stopEscalations(ctx context.Context, tx *sql.Tx) error {
+	query := `
+		UPDATE admiral.alert_escalations e
+		SET stopped_at = NOW(), next_escalation_at = NULL,
+		    stop_reason = CASE
+		        WHEN a.id IS NULL THEN 'alert removed'
+		        WHEN COALESCE(a.status, 'active') <> 'active' THEN a.status
+		        ELSE 'policy removed'
+		    END
+		FROM admiral.alert_escalations cur
+		LEFT JOIN admiral.alerts a ON a.id = cur.alert_id
+		LEFT JOIN admiral.escalation_policies p ON p.id = cur.policy_id AND p.enabled = true
+		WHERE e.alert_id = cur.alert_id
+		  AND e.stopped_at IS NULL
+		  AND (a.id IS NULL OR COALESCE(a.status, 'active') <> 'active' OR p.id IS NULL)
+	`
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to stop escalations: %w", err)
+	}
+	return nil
+}
+
+type dueEscalation struct {
+	alertID        string
+	policyID       string
+	nextTier       int
+	repeatCount    int
+	repeatInterval time.Duration
+	maxRepeats     int
+	emailChannelID sql.NullString
+}
+
+// claimDuePages locks due escalations and claims their current tier
+func claimDuePages(ctx context.Context, tx *sql.Tx) ([]Page, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT e.alert_id, e.policy_id, e.next_tier, e.repeat_count,
+		       p.repeat_interval_minutes, p.max_repeats, p.email_channel_id
+		FROM admiral.alert_escalations e
+		JOIN admiral.escalation_policies p ON p.id = e.policy_id
+		WHERE e.stopped_at IS NULL AND e.next_escalation_at <= NOW()
+		ORDER BY e.next_escalation_at
+		LIMIT $1
+		FOR UPDATE OF e SKIP LOCKED
+	`, maxPagesPerRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim escalations: %w", err)
+	}
+
+	due := []dueEscalation{}
+	for rows.Next() {
+		var d dueEscalation
+		var repeatMinutes int
+		if err := rows.Scan(&d.alertID, &d.policyID, &d.nextTier, &d.repeatCount,
+			&repeatMinutes, &d.maxRepeats, &d.emailChannelID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan escalation: %w", err)
+		}
+		d.repeatInterval = time.Duration(repeatMinutes) * time.Minute
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tiersByPolicy := make(map[string][]escalationTier)
+	pages := []Page{}
+	for _, d := range due {
+		tiers, ok := tiersByPolicy[d.policyID]
+		if !ok {
+			tiers, err = loadTiers(ctx, tx, d.policyID)
+			if err != nil {
+				return nil, err
+			}
+			tiersByPolicy[d.policyID] = tiers
+		}
+
+		page, err := advance(ctx, tx, d, tiers)
+		if err != nil {
+			return nil, err
+		}
+		if page != nil {
+			pages = append(pages, *page)
+		}
+	}
+
+	return pages, nil
+}
+
+// advance claims the due tier and works out the next one
+// After the last tier the policy restarts from tier 1 every repeat interval,
+// until max_repeats is reached
+func advance(ctx context.Context, tx *sql.Tx, d dueEscalation, tiers []escalationTier) (*Page, error) {
+	// Tiers may have been edited since the escalation started, page the next remaining one
+	idx := -1
+	for i, t := range tiers {
+		if t.tier >= d.nextTier {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil, stopEscalation(ctx, tx, d.alertID, "exhausted")
+	}
+	current := tiers[idx]
+
+	nextTier, repeatCount := 0, d.repeatCount
+	var wait time.Duration
+	switch {
+	case idx+1 < len(tiers):
+		nextTier, wait = tiers[idx+1].tier, current.timeout
+	case d.repeatInterval > 0 && (d.maxRepeats == 0 || d.repeatCount < d.maxRepeats):
+		nextTier, wait = tiers[0].tier, d.repeatInterval
+		repeatCount++
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE admiral.alert_escalations
+		SET next_escalation_at = NOW() + make_interval(secs => $2)
+		WHERE alert_id = $1
+	`, d.alertID, pageClaimTimeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim escalation of alert %s: %w", d.alertID, err)
+	}
+
+	alert, err := scanAlert(tx.QueryRowContext(ctx, "SELECT "+alertColumns+" FROM admiral.alerts WHERE id = $1", d.alertID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert %s: %w", d.alertID, err)
+	}
+
+	reason := fmt.Sprintf("escalation tier %d", current.tier)
+	if d.repeatCount > 0 {
+		reason += fmt.Sprintf(", repeat %d", d.repeatCount)
+	}
+
+	return &Page{
+		Event:          eventFromAlert(alert, EventEscalated, nil, reason),
+		PolicyID:       d.policyID,
+		EmailChannelID: d.emailChannelID.String,
+		Tier:           current.tier,
+		Repeat:         d.repeatCount,
+		Targets:        current.targets,
+		claimedTier:    d.nextTier,
+		nextTier:       nextTier,
+		nextRepeat:     repeatCount,
+		wait:           wait,
+	}, nil
+}
+
+// CompletePage advances an escalation after its page reached at least one target
+// and records the page in the alert timeline
+func CompletePage(ctx context.Context, db *sql.DB, page *Page, reached int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	// An escalation stopped meanwhile (acknowledged, resolved) stays stopped
+	if page.nextTier == 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE admiral.alert_escalations
+			SET next_tier = $2, last_escalated_at = NOW(), next_escalation_at = NULL,
+			    stopped_at = NOW(), stop_reason = 'exhausted'
+			WHERE alert_id = $1 AND stopped_at IS NULL AND next_tier = $3 AND repeat_count = $4
+		`, page.Event.AlertID, page.Tier, page.claimedTier, page.Repeat)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE admiral.alert_escalations
+			SET next_tier = $2, repeat_count = $3, last_escalated_at = NOW(),
+			    next_escalation_at = NOW() + make_interval(secs => $4)
+			WHERE alert_id = $1 AND stopped_at IS NULL AND next_tier = $5 AND repeat_count = $6
+		`, page.Event.AlertID, page.nextTier, page.nextRepeat, page.wait.Seconds(), page.claimedTier, page.Repeat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to advance escalation of alert %s: %w", page.Event.AlertID, err)
+	}
+
+	reason := page.Event.Reason
+	if reached < len(page.Targets) {
+		reason += fmt.Sprintf(" (%d of %d targets reached)", reached, len(page.Targets))
+	}
+	if err := recordTimeline(ctx, tx, page.Event.AlertID, EventEscalated,
+		models.AlertStatusActive, models.AlertStatusActive, nil, reason); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RetryPage re-arms a tier whose page reached none of its targets
+func RetryPage(ctx context.Context, db *sql.DB, page *Page) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE admiral.alert_escalations
+		SET next_escalation_at = NOW() + make_interval(secs => $2)
+		WHERE alert_id = $1 AND stopped_at IS NULL AND next_tier = $3 AND repeat_count = $4
+	`, page.Event.AlertID, pageRetryDelay.Seconds(), page.claimedTier, page.Repeat)
+	if err != nil {
+		return fmt.Errorf("failed to re-arm escalation of alert %s: %w", page.Event.AlertID, err)
+	}
+	return nil
+}
+
+func stopEscalation(ctx context.Context, tx *sql.Tx, alertID, reason string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE admiral.alert_escalations
+		SET stopped_at = NOW(), next_escalation_at = NULL, stop_reason = $2
+		WHERE alert_id = $1
+	`, alertID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to stop escalation of alert %s: %w", alertID, err)
+	}
+	return nil
+}
+
+// loadTiers reads a policy's tiers in order
+func loadTiers(ctx context.Context, tx *sql.Tx, policyID string) ([]escalationTier, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT tier, timeout_minutes, targets
+		FROM admiral.escalation_tiers
+		WHERE policy_id = $1
+		ORDER BY tier
+	`, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tiers of escalation policy %s: %w", policyID, err)
+	}
+	defer rows.Close()
+
+	tiers := []escalationTier{}
+	for rows.Next() {
+		var t escalationTier
+		var timeoutMinutes int
+		var targets []byte
+		if err := rows.Scan(&t.tier, &timeoutMinutes, &targets); err != nil {
+			return nil, fmt.Errorf("failed to scan escalation tier: %w", err)
+		}
+		if err := json.Unmarshal(targets, &t.targets); err != nil {
+			return nil, fmt.Errorf("invalid targets in tier %d of escalation policy %s: %w", t.tier, policyID, err)
+		}
+		t.timeout = time.Duration(timeoutMinutes) * time.Minute
+		tiers = append(tiers, t)
+	}
+
+	return tiers, rows.Err()
+}
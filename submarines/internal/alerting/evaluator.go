@@ -0,0 +1,589 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+const (
+	// maxSampleAge is how old the latest sample may be before a series is considered unknown
+	// (offline servers are handled by the server status monitor, not by metric rules)
+	maxSampleAge = 5 * time.Minute
+
+	// windowMargin is added to the longest rule duration when loading samples
+	windowMargin = 5 * time.Minute
+
+	// advisoryLockKey ensures only one alerter instance evaluates at a time
+	advisoryLockKey = "admiral.alert_evaluator"
+)
+
+// ruleState is the outcome of evaluating a rule against one server
+type ruleState int
+
+const (
+	stateUnknown ruleState = iota // No recent data - leave alerts untouched
+	stateOK                       // Condition not met - resolve open alert
+	statePending                  // Condition met but not for duration_seconds yet
+	stateFiring                   // Condition met for duration_seconds - open alert
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// openAlert is an active or acknowledged alert created by a rule
+type openAlert struct {
+	ID       string
+	RuleID   string
+	ServerID string // admiral.servers.id
+	Status   string
+}
+
+type alertKey struct {
+	ruleID   string
+	serverID string
+}
+
+// Result summarizes a single evaluation cycle
+type Result struct {
+	Rules      int
+	Opened     int
+	Updated    int
+	Resolved   int
+	Suppressed int     // Firing rules held back by a silence or maintenance window
+	Held       int     // Notifications held back because the alert is flapping
+	Events     []Event // State changes committed (and written to the outbox) in this cycle
+}
+
+// Evaluator evaluates enabled admiral.alert_rules against recent admiral.metrics
+// and opens/resolves alerts in admiral.alerts
+type Evaluator struct {
+	db        *sql.DB
+	forecasts *forecastCache
+}
+
+// New creates a new Evaluator instance
+func New(db *sql.DB) *Evaluator {
+	return &Evaluator{
+		db:        db,
+		forecasts: newForecastCache(),
+	}
+}
+
+// Run executes one evaluation cycle
+func (e *Evaluator) Run(ctx context.Context) (*Result, error) {
+	result := &Result{}
+
+	enabled, err := e.alertingEnabled(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alerting settings: %w", err)
+	}
+	if !enabled {
+		return result, nil
+	}
+
+	rules, err := loadRules(ctx, e.db)
+	if err != nil {
+		return nil, err
+	}
+	result.Rules = len(rules)
+
+	// Trend fits are cached across cycles and refitted outside the locked transaction
+	if err := e.forecasts.refresh(ctx, e.db, rules, time.Now()); err != nil {
+		return nil, err
+	}
+
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock(hashtext($1))", advisoryLockKey).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !locked {
+		return &Result{}, nil
+	}
+
+	targets, err := loadTargets(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	window := windowMargin
+	for _, rule := range rules {
+		if d := time.Duration(rule.DurationSeconds)*time.Second + windowMargin; d > window {
+			window = d
+		}
+	}
+
+	samples, err := loadSamples(ctx, tx, window)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalyMetrics := []string{}
+	for _, rule := range rules {
+		if rule.Condition == conditionAnomaly && !slices.Contains(anomalyMetrics, rule.MetricType) {
+			anomalyMetrics = append(anomalyMetrics, rule.MetricType)
+		}
+	}
+	baselines, err := loadBaselines(ctx, tx, anomalyMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	processSamples, err := loadProcessSamples(ctx, tx, processNames(rules), window)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := loadOpenAlerts(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	silences, err := loadSilences(ctx, tx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	activeRules := make(map[string]bool, len(rules))
+	evaluated := make(map[alertKey]bool) // Every (rule, server) pair checked in this cycle
+
+	for i := range rules {
+		rule := &rules[i]
+		activeRules[rule.ID] = true
+
+		fn, ok := metricTypes[rule.MetricType]
+		resource, isForecast := forecastMetricTypes[rule.MetricType]
+		processFn, isProcess := processMetricTypes[rule.MetricType]
+		if !ok && !isForecast && !isProcess {
+			log.Printf("[WARN] Alert rule %q uses unsupported metric_type %q, skipping", rule.Name, rule.MetricType)
+			continue
+		}
+		if isProcess && rule.ProcessName == nil {
+			log.Printf("[WARN] Alert rule %q uses %s without process_name, skipping", rule.Name, rule.MetricType)
+			continue
+		}
+
+		for j := range targets {
+			t := &targets[j]
+			if !ruleApplies(rule, t) {
+				continue
+			}
+			evaluated[alertKey{ruleID: rule.ID, serverID: t.ID}] = true
+
+			var state ruleState
+			var value float64
+			var score *anomalyScore
+			switch {
+			case isForecast:
+				key := forecastKey{lookback: rule.ForecastLookbackSeconds, serverID: t.ServerID, resource: resource}
+				state, value = evaluateForecast(rule, e.forecasts.forecasts[key], latestSampleAt(samples[t.ServerID]), now)
+			case isProcess:
+				key := processKey{serverID: t.ServerID, processName: *rule.ProcessName}
+				state, value = evaluate(rule, computeProcessSeries(processFn, processSamples[key]), now)
+			case rule.Condition == conditionAnomaly:
+				points := computeSeries(fn, samples[t.ServerID])
+				b := baselines.get(baselineKey{serverID: t.ServerID, metricType: rule.MetricType})
+				state, value, score = evaluateAnomaly(rule, b, points, now)
+			default:
+				state, value = evaluate(rule, computeSeries(fn, samples[t.ServerID]), now)
+			}
+			existing, isOpen := open[alertKey{ruleID: rule.ID, serverID: t.ID}]
+
+			switch {
+			case state == stateFiring && !isOpen:
+				silenced := findSilence(silences, rule, t)
+				if silenced != nil && silenced.Action == silenceSuppress {
+					first, err := recordSuppression(ctx, tx, silenced, rule, t, value)
+					if err != nil {
+						return nil, err
+					}
+					if first {
+						log.Printf("[ALERT] %s on %s %s", rule.Name, t.Hostname, silenced.reason())
+					}
+					result.Suppressed++
+					continue
+				}
+
+				event, err := openRuleAlert(ctx, tx, rule, t, value, score, silenced)
+				if err != nil {
+					return nil, err
+				}
+				if event != nil {
+					result.Opened++
+					if err := e.emit(ctx, tx, result, rule, t, event); err != nil {
+						return nil, err
+					}
+				}
+
+			case (state == stateFiring || state == statePending) && isOpen:
+				if err := refreshAlert(ctx, tx, existing.ID, value); err != nil {
+					return nil, err
+				}
+				result.Updated++
+
+			case state == stateOK && isOpen:
+				event, err := resolveAlert(ctx, tx, existing, &value, "condition cleared")
+				if err != nil {
+					return nil, err
+				}
+				result.Resolved++
+				if err := e.emit(ctx, tx, result, rule, t, event); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// Learn from this cycle's samples after scoring them
+	baselines.learn(samples)
+	if err := baselines.save(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	// Resolve alerts whose rule was disabled or deleted, or no longer applies to the
+	// server (server_ids/server_tags changed, server made inactive or removed)
+	for key, alert := range open {
+		if evaluated[key] {
+			continue
+		}
+		reason := "rule no longer applies to server"
+		if !activeRules[alert.RuleID] {
+			reason = "rule disabled or deleted"
+		}
+		event, err := resolveAlert(ctx, tx, alert, nil, reason)
+		if err != nil {
+			return nil, err
+		}
+		result.Events = append(result.Events, *event)
+		result.Resolved++
+	}
+
+	// Notify the settled state of alerts that stopped flapping
+	released, err := releaseStableFlaps(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	result.Events = append(result.Events, released...)
+
+	// Notifications are published from the outbox by RelayOutbox
+	if err := writeOutbox(ctx, tx, result.Events); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// emit assigns an event to its notification group and queues it for publishing
+// unless the alert is flapping
+func (e *Evaluator) emit(ctx context.Context, tx *sql.Tx, result *Result, rule *models.AlertRule, t *target, event *Event) error {
+	event.setGroup(rule, t)
+
+	held, err := trackTransition(ctx, tx, rule, t, event)
+	if err != nil {
+		return err
+	}
+	if held {
+		result.Held++
+		return nil
+	}
+
+	result.Events = append(result.Events, *event)
+	return nil
+}
+
+// evaluate decides the rule state for one server's series (oldest first)
+// The condition must hold for every point in the last duration_seconds
+func evaluate(rule *models.AlertRule, points []point, now time.Time) (ruleState, float64) {
+	if len(points) == 0 {
+		return stateUnknown, 0
+	}
+
+	latest := points[len(points)-1]
+	if now.Sub(latest.Timestamp) > maxSampleAge {
+		return stateUnknown, latest.Value
+	}
+
+	if !compare(rule.Condition, latest.Value, rule.Threshold) {
+		return stateOK, latest.Value
+	}
+
+	// Walk back while the condition holds to find when the breach started
+	breachStart := latest.Timestamp
+	for i := len(points) - 1; i >= 0; i-- {
+		if !compare(rule.Condition, points[i].Value, rule.Threshold) {
+			break
+		}
+		breachStart = points[i].Timestamp
+	}
+
+	if latest.Timestamp.Sub(breachStart) >= time.Duration(rule.DurationSeconds)*time.Second {
+		return stateFiring, latest.Value
+	}
+	return statePending, latest.Value
+}
+
+// compare applies a rule condition (gt, lt, eq, gte, lte)
+func compare(condition string, value, threshold float64) bool {
+	switch condition {
+	case "gt":
+		return value > threshold
+	case "gte":
+		return value >= threshold
+	case "lt":
+		return value < threshold
+	case "lte":
+		return value <= threshold
+	case "eq":
+		return math.Abs(value-threshold) < 0.005 // NUMERIC(20,2) precision
+	default:
+		return false
+	}
+}
+
+// conditionSymbol returns a human-readable operator for alert messages
+func conditionSymbol(condition string) string {
+	switch condition {
+	case "gt":
+		return ">"
+	case "gte":
+		return ">="
+	case "lt":
+		return "<"
+	case "lte":
+		return "<="
+	case "eq":
+		return "="
+	default:
+		return condition
+	}
+}
+
+// round2 rounds to NUMERIC(20,2) precision
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// loadOpenAlerts reads active/acknowledged alerts created by rules
+func loadOpenAlerts(ctx context.Context, q queryer) (map[alertKey]openAlert, error) {
+	query := `
+		SELECT id, rule_id, server_id, status
+		FROM admiral.alerts
+		WHERE rule_id IS NOT NULL AND status IN ($1, $2)
+		FOR UPDATE
+	`
+
+	rows, err := q.QueryContext(ctx, query, models.AlertStatusActive, models.AlertStatusAcknowledged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open alerts: %w", err)
+	}
+	defer rows.Close()
+
+	open := make(map[alertKey]openAlert)
+	for rows.Next() {
+		var a openAlert
+		if err := rows.Scan(&a.ID, &a.RuleID, &a.ServerID, &a.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan open alert: %w", err)
+		}
+		open[alertKey{ruleID: a.RuleID, serverID: a.ServerID}] = a
+	}
+
+	return open, rows.Err()
+}
+
+// openRuleAlert inserts a new active alert for a firing rule
+// Anomaly rules store the baseline as threshold_value and the deviation in metadata
+// A downgrading silence lowers the severity and is recorded in metadata
+// Returns nil event when another evaluator opened the same alert concurrently
+func openRuleAlert(ctx context.Context, tx *sql.Tx, rule *models.AlertRule, t *target, value float64, score *anomalyScore, silenced *silence) (*Event, error) {
+	metric := rule.MetricType
+	if rule.ProcessName != nil {
+		metric = fmt.Sprintf("%s of %s", rule.MetricType, *rule.ProcessName)
+	}
+
+	message := fmt.Sprintf("%s: %s is %.2f (%s %.2f) on %s",
+		rule.Name, metric, value, conditionSymbol(rule.Condition), rule.Threshold, t.Hostname)
+	threshold := round2(rule.Threshold)
+	if score != nil {
+		message = fmt.Sprintf("%s: %s is %.2f (%+.1f sigma from baseline %.2f ± %.2f) on %s",
+			rule.Name, metric, value, score.Sigma, score.Expected, score.StdDev, t.Hostname)
+		threshold = round2(score.Expected)
+	}
+
+	severity := rule.Severity
+	reason := ""
+	meta := map[string]any{
+		"rule_name":        rule.Name,
+		"metric_type":      rule.MetricType,
+		"condition":        rule.Condition,
+		"duration_seconds": rule.DurationSeconds,
+		"agent_server_id":  t.ServerID,
+		"hostname":         t.Hostname,
+	}
+	if rule.ProcessName != nil {
+		meta["process_name"] = *rule.ProcessName
+	}
+	if score != nil {
+		meta["anomaly"] = map[string]any{
+			"sigma":    math.Round(score.Sigma*100) / 100,
+			"k":        rule.Threshold,
+			"expected": round2(score.Expected),
+			"std_dev":  round2(score.StdDev),
+			"seasonal": score.Seasonal,
+		}
+	}
+	if silenced != nil {
+		severity = downgradeSeverity(rule.Severity)
+		reason = silenced.reason()
+		meta["original_severity"] = rule.Severity
+		meta["silence"] = map[string]any{
+			"source": silenced.Source,
+			"id":     silenced.ID,
+			"name":   silenced.Name,
+			"reason": reason,
+		}
+	}
+
+	metadata, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal alert metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO admiral.alerts (
+			server_id, rule_id, alert_type, severity, message,
+			threshold_value, current_value, metadata, status, last_evaluated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (rule_id, server_id) WHERE status IN ('active', 'acknowledged') DO NOTHING
+		RETURNING id, created_at
+	`
+
+	current := round2(value)
+	event := &Event{
+		Type:         EventOpened,
+		RuleID:       rule.ID,
+		RuleName:     rule.Name,
+		ServerID:     t.ID,
+		Hostname:     t.Hostname,
+		AlertType:    rule.MetricType,
+		Severity:     severity,
+		Message:      message,
+		Threshold:    &threshold,
+		CurrentValue: &current,
+		Reason:       reason,
+	}
+
+	err = tx.QueryRowContext(ctx, query,
+		t.ID, rule.ID, rule.MetricType, severity, message,
+		threshold, current, metadata, models.AlertStatusActive,
+	).Scan(&event.AlertID, &event.OccurredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open alert for rule %q on %s: %w", rule.Name, t.Hostname, err)
+	}
+
+	if err := recordTimeline(ctx, tx, event.AlertID, EventOpened, "", models.AlertStatusActive, nil, reason); err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		log.Printf("[ALERT] Opened (%s): %s", reason, message)
+	} else {
+		log.Printf("[ALERT] Opened: %s", message)
+	}
+	return event, nil
+}
+
+// refreshAlert updates current_value of an open alert
+func refreshAlert(ctx context.Context, tx *sql.Tx, alertID string, value float64) error {
+	query := `
+		UPDATE admiral.alerts
+		SET current_value = $1, last_evaluated_at = NOW()
+		WHERE id = $2
+	`
+
+	if _, err := tx.ExecContext(ctx, query, round2(value), alertID); err != nil {
+		return fmt.Errorf("failed to refresh alert %s: %w", alertID, err)
+	}
+	return nil
+}
+
+// resolveAlert marks an open alert as resolved
+func resolveAlert(ctx context.Context, tx *sql.Tx, alert openAlert, value *float64, reason string) (*Event, error) {
+	query := `
+		UPDATE admiral.alerts
+		SET status = $1,
+		    resolved_at = NOW(),
+		    current_value = COALESCE($2, current_value),
+		    last_evaluated_at = NOW(),
+		    metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('resolution', $3::text)
+		WHERE id = $4
+		RETURNING server_id, COALESCE(rule_id::text, ''), alert_type, severity, message,
+		          threshold_value, current_value,
+		          COALESCE(metadata->>'rule_name', ''), COALESCE(metadata->>'hostname', ''),
+		          resolved_at
+	`
+
+	var current any
+	if value != nil {
+		current = round2(*value)
+	}
+
+	event := &Event{Type: EventResolved, AlertID: alert.ID, Reason: reason}
+	err := tx.QueryRowContext(ctx, query, models.AlertStatusResolved, current, reason, alert.ID).Scan(
+		&event.ServerID, &event.RuleID, &event.AlertType, &event.Severity, &event.Message,
+		&event.Threshold, &event.CurrentValue,
+		&event.RuleName, &event.Hostname,
+		&event.OccurredAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve alert %s: %w", alert.ID, err)
+	}
+
+	if err := recordTimeline(ctx, tx, alert.ID, EventResolved, alert.Status, models.AlertStatusResolved, nil, reason); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ALERT] Resolved %s (%s)", alert.ID, reason)
+	return event, nil
+}
+
+// alertingEnabled reads the alerting_enabled flag from admiral.settings (default: true)
+func (e *Evaluator) alertingEnabled(ctx context.Context) (bool, error) {
+	var value models.JSONValue
+	err := e.db.QueryRowContext(ctx, `SELECT value FROM admiral.settings WHERE key = 'alerting_enabled'`).Scan(&value)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	enabled, err := value.Bool()
+	if err != nil {
+		return true, nil
+	}
+	return enabled, nil
+}
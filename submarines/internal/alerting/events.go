@@ -0,0 +1,78 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+)
+
+// EventsStreamKey is the stream alert state changes are published to
+// Consumed by the notifier (consumer group submarines-notifier)
+const EventsStreamKey = "nodepulse:alerts:stream"
+
+// Alert event types
+const (
+	EventOpened         = "opened"
+	EventAcknowledged   = "acknowledged"
+	EventUnacknowledged = "unacknowledged"
+	EventResolved       = "resolved"
+	EventEscalated      = "escalated"
+)
+
+// Event describes a single alert state change
+type Event struct {
+	Type         string   `json:"type"` // opened, acknowledged, unacknowledged, resolved, escalated
+	AlertID      string   `json:"alert_id"`
+	RuleID       string   `json:"rule_id,omitempty"`
+	RuleName     string   `json:"rule_name,omitempty"`
+	ServerID     string   `json:"server_id"` // admiral.servers.id
+	Hostname     string   `json:"hostname,omitempty"`
+	AlertType    string   `json:"alert_type"`
+	Severity     string   `json:"severity"`
+	Message      string   `json:"message"`
+	Threshold    *float64 `json:"threshold,omitempty"`
+	CurrentValue *float64 `json:"current_value,omitempty"`
+	Reason       string   `json:"reason,omitempty"`
+	UserID       *int64   `json:"user_id,omitempty"` // Set for user actions (acknowledge, manual resolve)
+
+	// Notification grouping (empty GroupKey = notify per alert without waiting)
+	GroupKey      string            `json:"group_key,omitempty"`
+	GroupLabels   map[string]string `json:"group_labels,omitempty"`
+	GroupWait     int               `json:"group_wait_seconds,omitempty"`
+	GroupInterval int               `json:"group_interval_seconds,omitempty"`
+	OccurredAt    time.Time         `json:"occurred_at"`
+}
+
+// setGroup assigns the rule's notification group to an event
+// Without group_by all of a rule's alerts share one group, so a fleet-wide
+// outage produces one notification per rule rather than one per server
+func (e *Event) setGroup(rule *models.AlertRule, t *target) {
+	e.GroupLabels = groupLabels(rule, t)
+	keyLabels := e.GroupLabels
+	if _, ok := keyLabels["server"]; ok {
+		// Hostnames aren't unique, key per-server groups by server ID
+		keyLabels = maps.Clone(keyLabels)
+		keyLabels["server"] = t.ID
+	}
+	e.GroupKey = groupKey(rule.ID, keyLabels)
+	e.GroupWait = rule.GroupWaitSeconds
+	e.GroupInterval = rule.GroupIntervalSeconds
+}
+
+// ParseEvent decodes an event from a stream message
+func ParseEvent(msg queue.Message) (*Event, error) {
+	payload, ok := msg.Fields["payload"]
+	if !ok {
+		return nil, fmt.Errorf("missing payload in message")
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return nil, fmt.Errorf("invalid event payload: %w", err)
+	}
+	return &event, nil
+}
@@ -0,0 +1,144 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// trackTransition records a rule alert state change for flap detection
+// An alert is flapping once it changed state flap_threshold times within
+// flap_window_seconds; it stays flapping until no change happened for a full window
+// Returns true when the event must be held back because the alert is flapping
+func trackTransition(ctx context.Context, tx *sql.Tx, rule *models.AlertRule, t *target, event *Event) (bool, error) {
+	if rule.FlapThreshold <= 0 {
+		return false, nil
+	}
+
+	var flapping bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT flapping FROM admiral.alert_flap_state
+		WHERE rule_id = $1 AND server_id = $2
+		FOR UPDATE
+	`, rule.ID, t.ID).Scan(&flapping)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to load flap state for rule %q on %s: %w", rule.Name, t.Hostname, err)
+	}
+
+	if !flapping {
+		// Includes the transition recorded in this transaction
+		var changes int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM admiral.alert_timeline tl
+			JOIN admiral.alerts a ON a.id = tl.alert_id
+			WHERE a.rule_id = $1 AND a.server_id = $2
+			  AND tl.event_type IN ('opened', 'resolved')
+			  AND tl.created_at >= NOW() - make_interval(secs => $3)
+		`, rule.ID, t.ID, rule.FlapWindowSeconds).Scan(&changes)
+		if err != nil {
+			return false, fmt.Errorf("failed to count state changes for rule %q on %s: %w", rule.Name, t.Hostname, err)
+		}
+
+		if changes >= rule.FlapThreshold {
+			flapping = true
+			log.Printf("[ALERT] %s on %s is flapping (%d state changes in %ds), holding notifications",
+				rule.Name, t.Hostname, changes, rule.FlapWindowSeconds)
+		}
+	}
+
+	var held any // NULL unless flapping
+	if flapping {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal held event: %w", err)
+		}
+		held = payload
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO admiral.alert_flap_state (
+			rule_id, server_id, flapping, flapping_since, last_transition_at, last_notified_type, held_event
+		) VALUES (
+			$1, $2, $3, CASE WHEN $3 THEN NOW() END, NOW(), CASE WHEN $3 THEN NULL ELSE $4 END, $5
+		)
+		ON CONFLICT (rule_id, server_id) DO UPDATE SET
+			flapping = EXCLUDED.flapping,
+			flapping_since = CASE WHEN EXCLUDED.flapping THEN COALESCE(admiral.alert_flap_state.flapping_since, NOW()) END,
+			last_transition_at = NOW(),
+			last_notified_type = CASE WHEN EXCLUDED.flapping THEN admiral.alert_flap_state.last_notified_type ELSE EXCLUDED.last_notified_type END,
+			held_event = EXCLUDED.held_event
+	`, rule.ID, t.ID, flapping, event.Type, held)
+	if err != nil {
+		return false, fmt.Errorf("failed to update flap state for rule %q on %s: %w", rule.Name, t.Hostname, err)
+	}
+
+	return flapping, nil
+}
+
+// releaseStableFlaps ends flapping for alerts without state changes for a full flap window
+// Returns the latest held event of each alert whose state differs from what was last notified
+func releaseStableFlaps(ctx context.Context, tx *sql.Tx) ([]Event, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT f.rule_id, f.server_id, f.held_event, COALESCE(f.last_notified_type, '')
+		FROM admiral.alert_flap_state f
+		LEFT JOIN admiral.alert_rules r ON r.id = f.rule_id
+		WHERE f.flapping
+		  AND f.last_transition_at < NOW() - make_interval(secs => COALESCE(r.flap_window_seconds, 1800))
+		FOR UPDATE OF f
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flapping alerts: %w", err)
+	}
+
+	type stable struct {
+		ruleID, serverID string
+		held             []byte
+		lastNotified     string
+	}
+	var candidates []stable
+	for rows.Next() {
+		var s stable
+		if err := rows.Scan(&s.ruleID, &s.serverID, &s.held, &s.lastNotified); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan flap state: %w", err)
+		}
+		candidates = append(candidates, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	events := []Event{}
+	for _, s := range candidates {
+		notified := s.lastNotified
+
+		var event Event
+		if len(s.held) > 0 && json.Unmarshal(s.held, &event) == nil && event.Type != s.lastNotified {
+			if event.Reason != "" {
+				event.Reason += "; "
+			}
+			event.Reason += "stabilised after flapping"
+			events = append(events, event)
+			notified = event.Type
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE admiral.alert_flap_state
+			SET flapping = false, flapping_since = NULL, held_event = NULL, last_notified_type = NULLIF($3, '')
+			WHERE rule_id = $1 AND server_id = $2
+		`, s.ruleID, s.serverID, notified)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear flap state: %w", err)
+		}
+
+		log.Printf("[ALERT] Rule %s on server %s stopped flapping", s.ruleID, s.serverID)
+	}
+
+	return events, nil
+}
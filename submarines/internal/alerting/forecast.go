@@ -0,0 +1,109 @@
+package alerting
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/forecast"
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// forecastMetricTypes maps forecast rule metric types to the resource they predict
+// Their value is the predicted hours until the resource is exhausted, e.g. "lt 72" = full within 72h
+var forecastMetricTypes = map[string]string{
+	"disk_hours_until_full":   forecast.ResourceDisk,
+	"memory_hours_until_full": forecast.ResourceMemory,
+	"swap_hours_until_full":   forecast.ResourceSwap,
+}
+
+type forecastKey struct {
+	lookback int    // Seconds
+	serverID string // Agent server_id
+	resource string
+}
+
+// forecastRefreshInterval is how long fitted trends are reused between evaluation cycles
+// A trend over a multi-hour lookback barely moves within a few cycles
+const forecastRefreshInterval = 10 * time.Minute
+
+// forecastCache keeps fitted trends per lookback across evaluation cycles
+type forecastCache struct {
+	forecasts   map[forecastKey]*forecast.Forecast
+	refreshedAt map[int]time.Time // Per lookback (seconds)
+}
+
+func newForecastCache() *forecastCache {
+	return &forecastCache{
+		forecasts:   make(map[forecastKey]*forecast.Forecast),
+		refreshedAt: make(map[int]time.Time),
+	}
+}
+
+// refresh refits the trends of every lookback used by forecast rules that are older
+// than forecastRefreshInterval, and forgets lookbacks no rule uses anymore
+// Called outside the evaluation transaction, the fits scan the whole lookback window
+func (c *forecastCache) refresh(ctx context.Context, q queryer, rules []models.AlertRule, now time.Time) error {
+	used := make(map[int]bool)
+	for _, rule := range rules {
+		if _, ok := forecastMetricTypes[rule.MetricType]; ok {
+			used[rule.ForecastLookbackSeconds] = true
+		}
+	}
+
+	for key := range c.forecasts {
+		if !used[key.lookback] {
+			delete(c.forecasts, key)
+		}
+	}
+	for lookback := range c.refreshedAt {
+		if !used[lookback] {
+			delete(c.refreshedAt, lookback)
+		}
+	}
+
+	for lookback := range used {
+		if now.Sub(c.refreshedAt[lookback]) < forecastRefreshInterval {
+			continue
+		}
+
+		results, err := forecast.Compute(ctx, q, time.Duration(lookback)*time.Second, "")
+		if err != nil {
+			return err
+		}
+
+		for key := range c.forecasts {
+			if key.lookback == lookback {
+				delete(c.forecasts, key)
+			}
+		}
+		for i := range results {
+			f := &results[i]
+			c.forecasts[forecastKey{lookback: lookback, serverID: f.ServerID, resource: f.Resource}] = f
+		}
+		c.refreshedAt[lookback] = now
+	}
+	return nil
+}
+
+// evaluateForecast decides the rule state from a server's predicted hours until full
+// The trend may be up to forecastRefreshInterval old: hours are counted from now to the
+// predicted exhaustion time, and freshness is judged by the server's latest sample
+// The lookback window already smooths the trend, so duration_seconds is not applied
+func evaluateForecast(rule *models.AlertRule, f *forecast.Forecast, latestAt, now time.Time) (ruleState, float64) {
+	if f == nil {
+		return stateUnknown, 0
+	}
+
+	value := f.Hours()
+	if f.PredictedFullAt != nil {
+		value = math.Max(0, round2(f.PredictedFullAt.Sub(now).Hours()))
+	}
+	if now.Sub(latestAt) > maxSampleAge {
+		return stateUnknown, value
+	}
+	if compare(rule.Condition, value, rule.Threshold) {
+		return stateFiring, value
+	}
+	return stateOK, value
+}
@@ -0,0 +1,205 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// sample is a single admiral.metrics row with the columns rules can evaluate
+type sample struct {
+	Timestamp time.Time
+
+	CPUIdleSeconds   float64
+	CPUIowaitSeconds float64
+	CPUSystemSeconds float64
+	CPUUserSeconds   float64
+	CPUStealSeconds  float64
+
+	MemoryTotalBytes     int64
+	MemoryAvailableBytes int64
+	SwapTotalBytes       int64
+	SwapFreeBytes        int64
+	DiskTotalBytes       int64
+	DiskAvailableBytes   int64
+
+	Load1Min  float64
+	Load5Min  float64
+	Load15Min float64
+}
+
+// point is a computed metric value at a timestamp
+type point struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// metricFunc computes a metric value from the current sample (and the previous one for counters)
+// Returns false when the value cannot be computed (first sample, counter reset, missing data)
+type metricFunc func(prev, cur *sample) (float64, bool)
+
+// metricTypes maps admiral.alert_rules.metric_type to its computation
+// Percentages are 0-100, load values are raw load averages
+var metricTypes = map[string]metricFunc{
+	"cpu_usage":    cpuUsage,
+	"cpu_iowait":   cpuIowait,
+	"memory_usage": memoryUsage,
+	"swap_usage":   swapUsage,
+	"disk_usage":   diskUsage,
+	"load_1min":    func(_, cur *sample) (float64, bool) { return cur.Load1Min, true },
+	"load_5min":    func(_, cur *sample) (float64, bool) { return cur.Load5Min, true },
+	"load_15min":   func(_, cur *sample) (float64, bool) { return cur.Load15Min, true },
+}
+
+// IsSupportedMetricType reports whether the evaluator can compute a metric type
+func IsSupportedMetricType(metricType string) bool {
+	if _, ok := forecastMetricTypes[metricType]; ok {
+		return true
+	}
+	if _, ok := processMetricTypes[metricType]; ok {
+		return true
+	}
+	_, ok := metricTypes[metricType]
+	return ok
+}
+
+func cpuTotal(s *sample) float64 {
+	return s.CPUIdleSeconds + s.CPUIowaitSeconds + s.CPUSystemSeconds + s.CPUUserSeconds + s.CPUStealSeconds
+}
+
+// cpuUsage calculates busy CPU percentage from counter deltas between two samples
+func cpuUsage(prev, cur *sample) (float64, bool) {
+	if prev == nil {
+		return 0, false
+	}
+	total := cpuTotal(cur) - cpuTotal(prev)
+	idle := cur.CPUIdleSeconds - prev.CPUIdleSeconds
+	if total <= 0 || idle < 0 {
+		return 0, false // Counter reset (reboot) or no progress
+	}
+	return 100 * (1 - idle/total), true
+}
+
+// cpuIowait calculates I/O wait percentage from counter deltas between two samples
+func cpuIowait(prev, cur *sample) (float64, bool) {
+	if prev == nil {
+		return 0, false
+	}
+	total := cpuTotal(cur) - cpuTotal(prev)
+	iowait := cur.CPUIowaitSeconds - prev.CPUIowaitSeconds
+	if total <= 0 || iowait < 0 {
+		return 0, false
+	}
+	return 100 * iowait / total, true
+}
+
+func memoryUsage(_, cur *sample) (float64, bool) {
+	if cur.MemoryTotalBytes <= 0 {
+		return 0, false
+	}
+	return 100 * float64(cur.MemoryTotalBytes-cur.MemoryAvailableBytes) / float64(cur.MemoryTotalBytes), true
+}
+
+func swapUsage(_, cur *sample) (float64, bool) {
+	if cur.SwapTotalBytes <= 0 {
+		return 0, false // No swap configured
+	}
+	return 100 * float64(cur.SwapTotalBytes-cur.SwapFreeBytes) / float64(cur.SwapTotalBytes), true
+}
+
+func diskUsage(_, cur *sample) (float64, bool) {
+	if cur.DiskTotalBytes <= 0 {
+		return 0, false
+	}
+	return 100 * float64(cur.DiskTotalBytes-cur.DiskAvailableBytes) / float64(cur.DiskTotalBytes), true
+}
+
+// latestSampleAt returns the timestamp of the newest sample (zero when there are none)
+func latestSampleAt(samples []sample) time.Time {
+	if len(samples) == 0 {
+		return time.Time{}
+	}
+	return samples[len(samples)-1].Timestamp
+}
+
+// computeSeries applies a metric function to an ordered (oldest first) list of samples
+func computeSeries(fn metricFunc, samples []sample) []point {
+	points := make([]point, 0, len(samples))
+	for i := range samples {
+		var prev *sample
+		if i > 0 {
+			prev = &samples[i-1]
+		}
+		if value, ok := fn(prev, &samples[i]); ok {
+			points = append(points, point{Timestamp: samples[i].Timestamp, Value: value})
+		}
+	}
+	return points
+}
+
+// sampleColumns are the admiral.metrics columns read into a sample (after server_id)
+const sampleColumns = `
+	timestamp,
+	COALESCE(cpu_idle_seconds, 0), COALESCE(cpu_iowait_seconds, 0), COALESCE(cpu_system_seconds, 0),
+	COALESCE(cpu_user_seconds, 0), COALESCE(cpu_steal_seconds, 0),
+	COALESCE(memory_total_bytes, 0), COALESCE(memory_available_bytes, 0),
+	COALESCE(swap_total_bytes, 0), COALESCE(swap_free_bytes, 0),
+	COALESCE(disk_total_bytes, 0), COALESCE(disk_available_bytes, 0),
+	COALESCE(load_1min, 0), COALESCE(load_5min, 0), COALESCE(load_15min, 0)
+`
+
+// loadSamples reads recent admiral.metrics rows grouped by agent server_id (oldest first)
+func loadSamples(ctx context.Context, q queryer, window time.Duration) (map[string][]sample, error) {
+	query := `
+		SELECT server_id, ` + sampleColumns + `
+		FROM admiral.metrics
+		WHERE timestamp > NOW() - make_interval(secs => $1)
+		ORDER BY server_id, timestamp ASC
+	`
+
+	return querySamples(ctx, q, query, window.Seconds())
+}
+
+// loadServerSamples reads one server's admiral.metrics rows since a point in time (oldest first)
+func loadServerSamples(ctx context.Context, q queryer, serverID string, since time.Time) ([]sample, error) {
+	query := `
+		SELECT server_id, ` + sampleColumns + `
+		FROM admiral.metrics
+		WHERE server_id = $1 AND timestamp > $2
+		ORDER BY timestamp ASC
+	`
+
+	samples, err := querySamples(ctx, q, query, serverID, since)
+	if err != nil {
+		return nil, err
+	}
+	return samples[serverID], nil
+}
+
+func querySamples(ctx context.Context, q queryer, query string, args ...any) (map[string][]sample, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	defer rows.Close()
+
+	samples := make(map[string][]sample)
+	for rows.Next() {
+		var serverID string
+		var s sample
+		if err := rows.Scan(
+			&serverID, &s.Timestamp,
+			&s.CPUIdleSeconds, &s.CPUIowaitSeconds, &s.CPUSystemSeconds,
+			&s.CPUUserSeconds, &s.CPUStealSeconds,
+			&s.MemoryTotalBytes, &s.MemoryAvailableBytes,
+			&s.SwapTotalBytes, &s.SwapFreeBytes,
+			&s.DiskTotalBytes, &s.DiskAvailableBytes,
+			&s.Load1Min, &s.Load5Min, &s.Load15Min,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan metric: %w", err)
+		}
+		samples[serverID] = append(samples[serverID], s)
+	}
+
+	return samples, rows.Err()
+}
@@ -0,0 +1,127 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+)
+
+// relayBatchSize bounds how many outbox events one relay transaction publishes
+const relayBatchSize = 100
+
+// writeOutbox stores events in admiral.alert_event_outbox within the transaction
+// that changed the alerts, so committing an alert state change guarantees its notification
+func writeOutbox(ctx context.Context, tx *sql.Tx, events []Event) error {
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal alert event: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO admiral.alert_event_outbox (alert_id, event_type, payload)
+			VALUES ($1, $2, $3)
+		`, event.AlertID, event.Type, payload)
+		if err != nil {
+			return fmt.Errorf("failed to write alert event to outbox: %w", err)
+		}
+	}
+	return nil
+}
+
+// RelayOutbox publishes outbox events to the alert events stream, oldest first
+// Events are removed in the transaction that locked them, after they were published.
+// A crash between publishing and committing publishes an event twice; the notifier
+// reports the latest event per alert, so duplicates collapse into one notification
+// Returns the number of events published
+func RelayOutbox(ctx context.Context, db *sql.DB, q queue.Queue) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		published, err := relayBatch(ctx, db, q)
+		total += published
+		if err != nil {
+			return total, err
+		}
+		if published < relayBatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+func relayBatch(ctx context.Context, db *sql.DB, q queue.Queue) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, alert_id, event_type, payload
+		FROM admiral.alert_event_outbox
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, relayBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read alert event outbox: %w", err)
+	}
+
+	type outboxEvent struct {
+		id        int64
+		alertID   string
+		eventType string
+		payload   string
+	}
+	events := []outboxEvent{}
+	for rows.Next() {
+		var e outboxEvent
+		if err := rows.Scan(&e.id, &e.alertID, &e.eventType, &e.payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// Stop at the first failure to keep events in order; the rest stays in the outbox
+	published := []int64{}
+	var publishErr error
+	for _, e := range events {
+		_, err := q.XAdd(ctx, EventsStreamKey, map[string]string{
+			"type":     e.eventType,
+			"alert_id": e.alertID,
+			"payload":  e.payload,
+		})
+		if err != nil {
+			publishErr = fmt.Errorf("failed to publish alert event %d: %w", e.id, err)
+			break
+		}
+		published = append(published, e.id)
+	}
+
+	if len(published) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM admiral.alert_event_outbox WHERE id = ANY($1)
+		`, pq.Array(published)); err != nil {
+			return 0, fmt.Errorf("failed to remove published outbox events: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			log.Printf("[WARN] %d alert events were published but stay in the outbox: %v", len(published), err)
+			return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	}
+
+	return len(published), publishErr
+}
@@ -0,0 +1,137 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// processSample is one process group in one admiral.process_snapshots scrape
+// A group missing from a scrape of its server is reported with NumProcs = 0
+type processSample struct {
+	Timestamp       time.Time
+	NumProcs        int
+	CPUSecondsTotal sql.NullFloat64
+	MemoryBytes     sql.NullInt64
+}
+
+// processMetricFunc computes a process group value from the current sample (and the previous one for counters)
+type processMetricFunc func(prev, cur *processSample) (float64, bool)
+
+// processMetricTypes maps process rule metric types (evaluated for alert_rules.process_name)
+var processMetricTypes = map[string]processMetricFunc{
+	"process_count":        processCount,
+	"process_memory_bytes": processMemory,
+	"process_cpu_cores":    processCPUCores,
+}
+
+type processKey struct {
+	serverID    string // Agent server_id
+	processName string
+}
+
+func processCount(_, cur *processSample) (float64, bool) {
+	return float64(cur.NumProcs), true
+}
+
+// processMemory is the resident memory of the group (0 when no process is running)
+func processMemory(_, cur *processSample) (float64, bool) {
+	if cur.NumProcs == 0 {
+		return 0, true
+	}
+	if !cur.MemoryBytes.Valid {
+		return 0, false
+	}
+	return float64(cur.MemoryBytes.Int64), true
+}
+
+// processCPUCores is the CPU time consumed per second between two scrapes
+func processCPUCores(prev, cur *processSample) (float64, bool) {
+	if cur.NumProcs == 0 {
+		return 0, true
+	}
+	if prev == nil || !prev.CPUSecondsTotal.Valid || !cur.CPUSecondsTotal.Valid {
+		return 0, false
+	}
+	elapsed := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+	used := cur.CPUSecondsTotal.Float64 - prev.CPUSecondsTotal.Float64
+	if elapsed <= 0 || used < 0 {
+		return 0, false // Counter reset (group restarted)
+	}
+	return used / elapsed, true
+}
+
+// computeProcessSeries applies a process metric function to an ordered (oldest first) list of samples
+func computeProcessSeries(fn processMetricFunc, samples []processSample) []point {
+	points := make([]point, 0, len(samples))
+	for i := range samples {
+		var prev *processSample
+		if i > 0 {
+			prev = &samples[i-1]
+		}
+		if value, ok := fn(prev, &samples[i]); ok {
+			points = append(points, point{Timestamp: samples[i].Timestamp, Value: value})
+		}
+	}
+	return points
+}
+
+// processNames returns the process groups referenced by process rules
+func processNames(rules []models.AlertRule) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if _, ok := processMetricTypes[rule.MetricType]; !ok || rule.ProcessName == nil || seen[*rule.ProcessName] {
+			continue
+		}
+		seen[*rule.ProcessName] = true
+		names = append(names, *rule.ProcessName)
+	}
+	return names
+}
+
+// loadProcessSamples reads recent scrapes of the given process groups (oldest first)
+// Every scrape of a server yields a sample per group, so absent groups show up as 0 processes
+func loadProcessSamples(ctx context.Context, q queryer, names []string, window time.Duration) (map[processKey][]processSample, error) {
+	samples := make(map[processKey][]processSample)
+	if len(names) == 0 {
+		return samples, nil
+	}
+
+	query := `
+		WITH scrapes AS (
+			SELECT DISTINCT server_id, timestamp
+			FROM admiral.process_snapshots
+			WHERE timestamp > NOW() - make_interval(secs => $1)
+		)
+		SELECT s.server_id, n.process_name, s.timestamp,
+		       COALESCE(p.num_procs, 0), p.cpu_seconds_total, p.memory_bytes
+		FROM scrapes s
+		CROSS JOIN unnest($2::text[]) AS n(process_name)
+		LEFT JOIN admiral.process_snapshots p
+		       ON p.server_id = s.server_id AND p.timestamp = s.timestamp AND p.process_name = n.process_name
+		ORDER BY s.server_id, n.process_name, s.timestamp ASC
+	`
+
+	rows, err := q.QueryContext(ctx, query, window.Seconds(), pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query process snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key processKey
+		var s processSample
+		if err := rows.Scan(&key.serverID, &key.processName, &s.Timestamp,
+			&s.NumProcs, &s.CPUSecondsTotal, &s.MemoryBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan process snapshot: %w", err)
+		}
+		samples[key] = append(samples[key], s)
+	}
+
+	return samples, rows.Err()
+}
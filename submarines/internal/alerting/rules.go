@@ -0,0 +1,186 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// target is a server an alert rule can apply to
+type target struct {
+	ID       string // admiral.servers.id (UUID) - used by admiral.alerts.server_id
+	ServerID string // Agent's server_id - used by admiral.metrics.server_id
+	Hostname string
+	Tags     []string
+	Metadata map[string]any
+}
+
+// loadRules reads all enabled rules from admiral.alert_rules
+func loadRules(ctx context.Context, q queryer) ([]models.AlertRule, error) {
+	query := `
+		SELECT id, name, description, metric_type, condition, threshold,
+		       COALESCE(duration_seconds, 0), severity, enabled,
+		       COALESCE(server_ids, '[]'::jsonb), COALESCE(server_tags, '[]'::jsonb),
+		       group_by, group_wait_seconds, group_interval_seconds,
+		       flap_window_seconds, flap_threshold, forecast_lookback_seconds, process_name,
+		       created_at, updated_at
+		FROM admiral.alert_rules
+		WHERE enabled = true
+		ORDER BY name
+	`
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.AlertRule{}
+	for rows.Next() {
+		var rule models.AlertRule
+		var serverIDs, serverTags, groupBy []byte
+		if err := rows.Scan(
+			&rule.ID, &rule.Name, &rule.Description, &rule.MetricType, &rule.Condition, &rule.Threshold,
+			&rule.DurationSeconds, &rule.Severity, &rule.Enabled,
+			&serverIDs, &serverTags,
+			&groupBy, &rule.GroupWaitSeconds, &rule.GroupIntervalSeconds,
+			&rule.FlapWindowSeconds, &rule.FlapThreshold, &rule.ForecastLookbackSeconds, &rule.ProcessName,
+			&rule.CreatedAt, &rule.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+
+		rule.ServerIDs = parseStringArray(serverIDs)
+		rule.ServerTags = parseStringArray(serverTags)
+		rule.GroupBy = parseStringArray(groupBy)
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// loadTargets reads all servers that rules can be evaluated against
+func loadTargets(ctx context.Context, q queryer) ([]target, error) {
+	query := `
+		SELECT id, server_id, COALESCE(NULLIF(hostname, ''), name, server_id),
+		       COALESCE(tags, '[]'::jsonb), COALESCE(metadata, '{}'::jsonb)
+		FROM admiral.servers
+		WHERE status IS DISTINCT FROM 'inactive'
+	`
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query servers: %w", err)
+	}
+	defer rows.Close()
+
+	targets := []target{}
+	for rows.Next() {
+		var t target
+		var tags, metadata []byte
+		if err := rows.Scan(&t.ID, &t.ServerID, &t.Hostname, &tags, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan server: %w", err)
+		}
+		t.Tags = parseStringArray(tags)
+		if err := json.Unmarshal(metadata, &t.Metadata); err != nil {
+			t.Metadata = nil
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
+}
+
+// ruleApplies reports whether a rule targets the given server
+// Empty server_ids and server_tags means the rule applies to all servers
+// server_ids may contain either admiral.servers.id or the agent's server_id
+func ruleApplies(rule *models.AlertRule, t *target) bool {
+	if len(rule.ServerIDs) == 0 && len(rule.ServerTags) == 0 {
+		return true
+	}
+
+	if slices.Contains(rule.ServerIDs, t.ID) || slices.Contains(rule.ServerIDs, t.ServerID) {
+		return true
+	}
+
+	for _, tag := range rule.ServerTags {
+		if slices.Contains(t.Tags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseStringArray decodes a JSONB array of strings, ignoring non-string items
+func parseStringArray(raw []byte) []string {
+	var items []any
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// groupLabels resolves a rule's group_by labels for a server
+// Supported labels: "tag" (first rule tag the server has, else its first tag),
+// "server" (hostname) and "metadata.<key>" (server metadata value)
+// An empty group_by yields no labels, i.e. one group for the whole rule
+func groupLabels(rule *models.AlertRule, t *target) map[string]string {
+	labels := make(map[string]string, len(rule.GroupBy))
+	for _, label := range rule.GroupBy {
+		switch {
+		case label == "tag":
+			labels[label] = groupTag(rule, t)
+		case label == "server":
+			labels[label] = t.Hostname
+		case strings.HasPrefix(label, "metadata."):
+			if v, ok := t.Metadata[strings.TrimPrefix(label, "metadata.")]; ok && v != nil {
+				labels[label] = fmt.Sprint(v)
+			} else {
+				labels[label] = ""
+			}
+		}
+	}
+	return labels
+}
+
+func groupTag(rule *models.AlertRule, t *target) string {
+	for _, tag := range rule.ServerTags {
+		if slices.Contains(t.Tags, tag) {
+			return tag
+		}
+	}
+	if len(t.Tags) > 0 {
+		tags := slices.Clone(t.Tags)
+		slices.Sort(tags)
+		return tags[0]
+	}
+	return ""
+}
+
+// groupKey builds a stable notification group key from a rule and its labels
+func groupKey(ruleID string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var sb strings.Builder
+	sb.WriteString("rule:" + ruleID)
+	for _, k := range keys {
+		sb.WriteString("|" + k + "=" + labels[k])
+	}
+	return sb.String()
+}
@@ -0,0 +1,201 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+var (
+	// ErrSilenceNotFound is returned when a silence ID doesn't exist (or already expired)
+	ErrSilenceNotFound = errors.New("silence not found")
+
+	// ErrMaintenanceWindowNotFound is returned when a maintenance window ID doesn't exist
+	ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+
+	// ErrMaintenanceWindowExists is returned when a maintenance window name is taken
+	ErrMaintenanceWindowExists = errors.New("maintenance window name already exists")
+)
+
+const silenceColumns = `
+	id, server_id, tag, alert_type, rule_name, action, starts_at, ends_at,
+	comment, created_by, created_at, updated_at
+`
+
+const maintenanceWindowColumns = `
+	id, name, server_id, tag, alert_type, rule_name, action,
+	days_of_week, to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS'), timezone,
+	enabled, comment, created_by, created_at, updated_at
+`
+
+func scanSilence(row rowScanner) (*models.AlertSilence, error) {
+	var s models.AlertSilence
+	err := row.Scan(&s.ID, &s.ServerID, &s.Tag, &s.AlertType, &s.RuleName, &s.Action,
+		&s.StartsAt, &s.EndsAt, &s.Comment, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanMaintenanceWindow(row rowScanner) (*models.MaintenanceWindow, error) {
+	var w models.MaintenanceWindow
+	var days []byte
+	err := row.Scan(&w.ID, &w.Name, &w.ServerID, &w.Tag, &w.AlertType, &w.RuleName, &w.Action,
+		&days, &w.StartTime, &w.EndTime, &w.Timezone,
+		&w.Enabled, &w.Comment, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(days, &w.DaysOfWeek); err != nil {
+		return nil, fmt.Errorf("invalid days_of_week in maintenance window %q: %w", w.Name, err)
+	}
+	return &w, nil
+}
+
+// ListSilences returns silences that haven't ended yet, or all of them, newest first
+func ListSilences(ctx context.Context, db *sql.DB, includeExpired bool) ([]models.AlertSilence, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+silenceColumns+`
+		FROM admiral.alert_silences
+		WHERE $1 OR ends_at > NOW()
+		ORDER BY starts_at DESC
+	`, includeExpired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert silences: %w", err)
+	}
+	defer rows.Close()
+
+	silences := []models.AlertSilence{}
+	for rows.Next() {
+		s, err := scanSilence(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert silence: %w", err)
+		}
+		silences = append(silences, *s)
+	}
+	return silences, rows.Err()
+}
+
+// CreateSilence stores a silence; it applies from the next evaluation cycle
+func CreateSilence(ctx context.Context, db *sql.DB, s *models.AlertSilence) (*models.AlertSilence, error) {
+	created, err := scanSilence(db.QueryRowContext(ctx, `
+		INSERT INTO admiral.alert_silences (
+			server_id, tag, alert_type, rule_name, action, starts_at, ends_at, comment, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+silenceColumns,
+		s.ServerID, s.Tag, s.AlertType, s.RuleName, s.Action, s.StartsAt, s.EndsAt, s.Comment, s.CreatedBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert silence: %w", err)
+	}
+	return created, nil
+}
+
+// ExpireSilence ends a silence now; a silence that hasn't started yet is removed
+func ExpireSilence(ctx context.Context, db *sql.DB, id string) error {
+	res, err := db.ExecContext(ctx, `
+		DELETE FROM admiral.alert_silences WHERE id = $1 AND starts_at > NOW()
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove alert silence %s: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	res, err = db.ExecContext(ctx, `
+		UPDATE admiral.alert_silences SET ends_at = NOW() WHERE id = $1 AND ends_at > NOW()
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to expire alert silence %s: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSilenceNotFound
+	}
+	return nil
+}
+
+// ListMaintenanceWindows returns every maintenance window by name
+func ListMaintenanceWindows(ctx context.Context, db *sql.DB) ([]models.MaintenanceWindow, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+maintenanceWindowColumns+`
+		FROM admiral.maintenance_windows
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := []models.MaintenanceWindow{}
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, *w)
+	}
+	return windows, rows.Err()
+}
+
+// CreateMaintenanceWindow stores a recurring maintenance window
+func CreateMaintenanceWindow(ctx context.Context, db *sql.DB, w *models.MaintenanceWindow) (*models.MaintenanceWindow, error) {
+	days, err := json.Marshal(w.DaysOfWeek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal days_of_week: %w", err)
+	}
+
+	created, err := scanMaintenanceWindow(db.QueryRowContext(ctx, `
+		INSERT INTO admiral.maintenance_windows (
+			name, server_id, tag, alert_type, rule_name, action,
+			days_of_week, start_time, end_time, timezone, enabled, comment, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING `+maintenanceWindowColumns,
+		w.Name, w.ServerID, w.Tag, w.AlertType, w.RuleName, w.Action,
+		days, w.StartTime, w.EndTime, w.Timezone, w.Enabled, w.Comment, w.CreatedBy,
+	))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrMaintenanceWindowExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	return created, nil
+}
+
+// SetMaintenanceWindowEnabled enables or disables a maintenance window
+func SetMaintenanceWindowEnabled(ctx context.Context, db *sql.DB, id string, enabled bool) (*models.MaintenanceWindow, error) {
+	w, err := scanMaintenanceWindow(db.QueryRowContext(ctx, `
+		UPDATE admiral.maintenance_windows SET enabled = $2
+		WHERE id = $1
+		RETURNING `+maintenanceWindowColumns,
+		id, enabled,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrMaintenanceWindowNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update maintenance window %s: %w", id, err)
+	}
+	return w, nil
+}
+
+// DeleteMaintenanceWindow removes a maintenance window
+// Suppressions it recorded stay in admiral.alert_suppressions
+func DeleteMaintenanceWindow(ctx context.Context, db *sql.DB, id string) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM admiral.maintenance_windows WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window %s: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMaintenanceWindowNotFound
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// Silence actions (admiral.alert_silences.action, admiral.maintenance_windows.action)
+const (
+	silenceSuppress  = "suppress"
+	silenceDowngrade = "downgrade"
+)
+
+// Silence sources (admiral.alert_suppressions.source_type)
+const (
+	sourceSilence           = "silence"
+	sourceMaintenanceWindow = "maintenance_window"
+)
+
+// silence is an active silence or the current occurrence of a maintenance window
+type silence struct {
+	Source string // silence, maintenance_window
+	ID     string
+	Name   string
+	Action string
+
+	// Matchers (empty = any)
+	ServerID  string
+	Tag       string
+	AlertType string
+	RuleName  string
+
+	// Current occurrence
+	Start time.Time
+	End   time.Time
+}
+
+// matches reports whether the silence covers a rule firing on a server
+func (s *silence) matches(rule *models.AlertRule, t *target) bool {
+	if s.ServerID != "" && s.ServerID != t.ID && s.ServerID != t.ServerID {
+		return false
+	}
+	if s.Tag != "" && !slices.Contains(t.Tags, s.Tag) {
+		return false
+	}
+	if s.AlertType != "" && s.AlertType != rule.MetricType {
+		return false
+	}
+	if s.RuleName != "" && s.RuleName != rule.Name {
+		return false
+	}
+	return true
+}
+
+// reason describes the silence for alert metadata and the suppression log
+func (s *silence) reason() string {
+	verb := "suppressed"
+	if s.Action == silenceDowngrade {
+		verb = "downgraded"
+	}
+	return fmt.Sprintf("%s by %s %q until %s", verb, s.Source, s.Name, s.End.UTC().Format(time.RFC3339))
+}
+
+// findSilence returns the silence to apply to a rule firing on a server
+// Suppressing silences win over downgrading ones
+func findSilence(silences []silence, rule *models.AlertRule, t *target) *silence {
+	var match *silence
+	for i := range silences {
+		s := &silences[i]
+		if !s.matches(rule, t) {
+			continue
+		}
+		if s.Action == silenceSuppress {
+			return s
+		}
+		if match == nil {
+			match = s
+		}
+	}
+	return match
+}
+
+// downgradeSeverity lowers a severity by one level (info stays info)
+func downgradeSeverity(severity string) string {
+	switch severity {
+	case "critical":
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// loadSilences reads silences and maintenance windows active at now
+func loadSilences(ctx context.Context, q queryer, now time.Time) ([]silence, error) {
+	query := `
+		SELECT id, COALESCE(NULLIF(comment, ''), id::text), action,
+		       COALESCE(server_id, ''), COALESCE(tag, ''), COALESCE(alert_type, ''), COALESCE(rule_name, ''),
+		       starts_at, ends_at
+		FROM admiral.alert_silences
+		WHERE starts_at <= $1 AND ends_at > $1
+		ORDER BY starts_at
+	`
+
+	rows, err := q.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert silences: %w", err)
+	}
+	defer rows.Close()
+
+	silences := []silence{}
+	for rows.Next() {
+		s := silence{Source: sourceSilence}
+		if err := rows.Scan(&s.ID, &s.Name, &s.Action,
+			&s.ServerID, &s.Tag, &s.AlertType, &s.RuleName,
+			&s.Start, &s.End); err != nil {
+			return nil, fmt.Errorf("failed to scan alert silence: %w", err)
+		}
+		silences = append(silences, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	windows, err := loadMaintenanceWindows(ctx, q, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(silences, windows...), nil
+}
+
+// loadMaintenanceWindows reads enabled maintenance windows and keeps those active at now
+func loadMaintenanceWindows(ctx context.Context, q queryer, now time.Time) ([]silence, error) {
+	query := `
+		SELECT id, name, action,
+		       COALESCE(server_id, ''), COALESCE(tag, ''), COALESCE(alert_type, ''), COALESCE(rule_name, ''),
+		       days_of_week, start_time::text, end_time::text, timezone
+		FROM admiral.maintenance_windows
+		WHERE enabled = true
+		ORDER BY name
+	`
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	active := []silence{}
+	for rows.Next() {
+		s := silence{Source: sourceMaintenanceWindow}
+		var days []byte
+		var startTime, endTime, timezone string
+		if err := rows.Scan(&s.ID, &s.Name, &s.Action,
+			&s.ServerID, &s.Tag, &s.AlertType, &s.RuleName,
+			&days, &startTime, &endTime, &timezone); err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+
+		var weekdays []int
+		if err := json.Unmarshal(days, &weekdays); err != nil {
+			log.Printf("[WARN] Maintenance window %q has invalid days_of_week, skipping: %v", s.Name, err)
+			continue
+		}
+
+		start, end, ok, err := windowOccurrence(weekdays, startTime, endTime, timezone, now)
+		if err != nil {
+			log.Printf("[WARN] Maintenance window %q is invalid, skipping: %v", s.Name, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		s.Start, s.End = start, end
+		active = append(active, s)
+	}
+
+	return active, rows.Err()
+}
+
+// windowOccurrence returns the occurrence of a weekly window containing now
+// Windows may cross midnight, so the occurrence that started yesterday is checked too
+func windowOccurrence(weekdays []int, startTime, endTime, timezone string, now time.Time) (time.Time, time.Time, bool, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("unknown timezone %q: %w", timezone, err)
+	}
+
+	start, err := time.Parse("15:04:05", startTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("invalid start_time %q: %w", startTime, err)
+	}
+	end, err := time.Parse("15:04:05", endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("invalid end_time %q: %w", endTime, err)
+	}
+
+	length := end.Sub(start)
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+
+	local := now.In(loc)
+	for _, dayOffset := range []int{0, -1} {
+		day := local.AddDate(0, 0, dayOffset)
+		if len(weekdays) > 0 && !slices.Contains(weekdays, int(day.Weekday())) {
+			continue
+		}
+
+		occurrenceStart := time.Date(day.Year(), day.Month(), day.Day(),
+			start.Hour(), start.Minute(), start.Second(), 0, loc)
+		occurrenceEnd := occurrenceStart.Add(length)
+		if !now.Before(occurrenceStart) && now.Before(occurrenceEnd) {
+			return occurrenceStart, occurrenceEnd, true, nil
+		}
+	}
+
+	return time.Time{}, time.Time{}, false, nil
+}
+
+// recordSuppression logs a suppressed alert, once per silence occurrence
+// Returns true when this is the first suppression for the occurrence
+func recordSuppression(ctx context.Context, tx *sql.Tx, s *silence, rule *models.AlertRule, t *target, value float64) (bool, error) {
+	query := `
+		INSERT INTO admiral.alert_suppressions (
+			rule_id, server_id, source_type, source_id, occurrence_start, reason, last_value
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (rule_id, server_id, source_type, source_id, occurrence_start) DO UPDATE SET
+			hit_count = admiral.alert_suppressions.hit_count + 1,
+			last_value = EXCLUDED.last_value,
+			last_suppressed_at = NOW()
+		RETURNING (xmax = 0)
+	`
+
+	var inserted bool
+	err := tx.QueryRowContext(ctx, query,
+		rule.ID, t.ID, s.Source, s.ID, s.Start, s.reason(), round2(value),
+	).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("failed to record suppression for rule %q on %s: %w", rule.Name, t.Hostname, err)
+	}
+	return inserted, nil
+}
@@ -0,0 +1,96 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// recordTimeline appends a state transition to admiral.alert_timeline
+// userID is nil for transitions made by the alerter
+func recordTimeline(ctx context.Context, tx *sql.Tx, alertID, eventType, fromStatus, toStatus string, userID *int64, comment string) error {
+	query := `
+		INSERT INTO admiral.alert_timeline (alert_id, event_type, from_status, to_status, user_id, comment)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''))
+	`
+
+	if _, err := tx.ExecContext(ctx, query, alertID, eventType, fromStatus, toStatus, userID, comment); err != nil {
+		return fmt.Errorf("failed to record %s transition for alert %s: %w", eventType, alertID, err)
+	}
+	return nil
+}
+
+// Timeline returns all transitions of an alert, oldest first
+func Timeline(ctx context.Context, q queryer, alertID string) ([]models.AlertTimelineEntry, error) {
+	query := `
+		SELECT id, alert_id, event_type, from_status, to_status, user_id, comment, created_at
+		FROM admiral.alert_timeline
+		WHERE alert_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert timeline: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AlertTimelineEntry{}
+	for rows.Next() {
+		var e models.AlertTimelineEntry
+		if err := rows.Scan(&e.ID, &e.AlertID, &e.EventType, &e.FromStatus, &e.ToStatus,
+			&e.UserID, &e.Comment, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert timeline entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// ResponseStats computes MTTA/MTTR per rule for alerts created since the given time
+// MTTA uses the first acknowledgment, MTTR the last resolution
+func ResponseStats(ctx context.Context, q queryer, since time.Time) ([]models.AlertResponseStats, error) {
+	query := `
+		WITH per_alert AS (
+			SELECT a.id,
+			       a.rule_id::text AS rule_id,
+			       COALESCE(a.metadata->>'rule_name', a.alert_type) AS rule_name,
+			       a.created_at,
+			       MIN(t.created_at) FILTER (WHERE t.event_type = 'acknowledged') AS first_ack_at,
+			       MAX(t.created_at) FILTER (WHERE t.event_type = 'resolved') AS resolved_at
+			FROM admiral.alerts a
+			LEFT JOIN admiral.alert_timeline t ON t.alert_id = a.id
+			WHERE a.created_at >= $1
+			GROUP BY a.id
+		)
+		SELECT rule_id, rule_name,
+		       COUNT(*), COUNT(first_ack_at), COUNT(resolved_at),
+		       AVG(EXTRACT(EPOCH FROM first_ack_at - created_at)),
+		       AVG(EXTRACT(EPOCH FROM resolved_at - created_at))
+		FROM per_alert
+		GROUP BY rule_id, rule_name
+		ORDER BY COUNT(*) DESC, rule_name
+	`
+
+	rows, err := q.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert response stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.AlertResponseStats{}
+	for rows.Next() {
+		var s models.AlertResponseStats
+		if err := rows.Scan(&s.RuleID, &s.RuleName, &s.Alerts, &s.Acknowledged, &s.Resolved,
+			&s.MTTASeconds, &s.MTTRSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan alert response stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
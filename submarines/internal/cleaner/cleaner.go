@@ -3,6 +3,7 @@ package cleaner
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -25,18 +26,22 @@ func New(db *sql.DB, cfg *config.Config) *Cleaner {
 }
 
 // Run executes all cleanup jobs
+// A failing job doesn't skip the others (e.g. one table's partitions can't be created);
+// the failures are logged and returned together
 func (c *Cleaner) Run(ctx context.Context) error {
 	logInfo("Starting cleanup jobs...")
 	start := time.Now()
 
-	// Job 1: Metrics retention cleanup
-	if err := c.CleanOldMetrics(ctx); err != nil {
-		return fmt.Errorf("metrics cleanup failed: %w", err)
-	}
-
-	// Job 2: Process snapshots retention cleanup
-	if err := c.CleanOldProcessSnapshots(ctx); err != nil {
-		return fmt.Errorf("process snapshots cleanup failed: %w", err)
+	jobs := []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		{"metrics", c.CleanOldMetrics},                             // Job 1: Metrics retention cleanup
+		{"process snapshots", c.CleanOldProcessSnapshots},          // Job 2: Process snapshots retention cleanup
+		{"processed messages", c.CleanProcessedMessages},           // Job 3: Processed message ledger cleanup
+		{"device snapshots", c.CleanOldDeviceSnapshots},            // Job 4: Per-device snapshots (filesystems, network interfaces, block devices)
+		{"logs", c.CleanOldLogs},                                   // Job 5: Host logs partitions and retention
+		{"ssh session recordings", c.CleanOldSSHSessionRecordings}, // Job 6: SSH session recordings partitions and retention
 	}
 
 	// Future jobs can be added here:
@@ -44,7 +49,25 @@ func (c *Cleaner) Run(ctx context.Context) error {
 	// - c.CleanResolvedAlerts(ctx)
 	// - c.CompactValkeyStreams(ctx)
 
+	var errs []error
+	for _, job := range jobs {
+		if err := job.run(ctx); err != nil {
+			log.Printf("[ERROR] %s cleanup failed: %v", job.name, err)
+			errs = append(errs, fmt.Errorf("%s cleanup failed: %w", job.name, err))
+		}
+
+		// Stop early once the run timed out, every later job would fail the same way
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("cleanup cancelled: %w", ctx.Err()))
+			break
+		}
+	}
+
 	duration := time.Since(start)
+	if len(errs) > 0 {
+		logInfo(fmt.Sprintf("Cleanup jobs completed with %d failure(s) in %v", len(errs), duration))
+		return errors.Join(errs...)
+	}
 	logInfo(fmt.Sprintf("All cleanup jobs completed in %v", duration))
 	return nil
 }
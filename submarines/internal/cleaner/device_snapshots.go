@@ -0,0 +1,129 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+)
+
+// deviceSnapshotTables hold per-device node_exporter rows, kept as long as admiral.metrics
+var deviceSnapshotTables = []string{
+	"admiral.filesystem_snapshots",
+	"admiral.network_interface_snapshots",
+	"admiral.block_device_snapshots",
+}
+
+// CleanOldDeviceSnapshots removes per-mountpoint, per-interface and per-block-device
+// snapshots older than the metrics retention policy (including per-server overrides)
+func (c *Cleaner) CleanOldDeviceSnapshots(ctx context.Context) error {
+	logInfo("Starting device snapshots retention cleanup...")
+
+	plan, err := c.getRetentionPlan(ctx, dataTypeMetrics)
+	if err != nil {
+		return fmt.Errorf("failed to read retention settings: %w", err)
+	}
+
+	if !plan.Enabled {
+		logInfo("Device snapshots retention cleanup is disabled, skipping...")
+		return nil
+	}
+
+	for _, table := range deviceSnapshotTables {
+		if err := c.cleanTableWithPlan(ctx, table, plan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowScope narrows a cleanup to some rows of a table, e.g. the servers of a retention policy
+// condition may reference args as $1, $2, ...
+type rowScope struct {
+	condition   string
+	args        []any
+	description string
+}
+
+// where returns the scope as an extra WHERE condition
+func (s rowScope) where() string {
+	if s.condition == "" {
+		return ""
+	}
+	return "AND " + s.condition
+}
+
+// label describes the cleaned rows for logging
+func (s rowScope) label(table string) string {
+	if s.description == "" {
+		return table
+	}
+	return fmt.Sprintf("%s (%s)", table, s.description)
+}
+
+// cleanRows deletes rows within scope older than retentionHours from a table with id and timestamp columns
+func (c *Cleaner) cleanRows(ctx context.Context, table string, retentionHours int, scope rowScope) error {
+	label := scope.label(table)
+
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM %s
+		WHERE timestamp < NOW() - INTERVAL '%d hours'
+		%s
+	`, table, retentionHours, scope.where())
+
+	var totalRows int64
+	if err := c.db.QueryRowContext(ctx, countQuery, scope.args...).Scan(&totalRows); err != nil {
+		return fmt.Errorf("failed to count old rows in %s: %w", table, err)
+	}
+
+	if totalRows == 0 {
+		logInfo(fmt.Sprintf("✓ No old rows in %s to clean up (retention: %dh)", label, retentionHours))
+		return nil
+	}
+
+	logInfo(fmt.Sprintf("⚠ Found %d rows in %s older than %d hours - starting deletion...", totalRows, label, retentionHours))
+
+	if c.cfg.DryRun {
+		logInfo(fmt.Sprintf("[DRY RUN] Would delete %d old rows from %s", totalRows, label))
+		return nil
+	}
+
+	// Delete in batches to avoid long-running transactions
+	const batchSize = 10000
+	deletedTotal := int64(0)
+
+	for {
+		deleteQuery := fmt.Sprintf(`
+			DELETE FROM %s
+			WHERE timestamp < NOW() - INTERVAL '%d hours'
+			AND id IN (
+				SELECT id FROM %s
+				WHERE timestamp < NOW() - INTERVAL '%d hours'
+				%s
+				ORDER BY timestamp ASC
+				LIMIT %d
+			)
+		`, table, retentionHours, table, retentionHours, scope.where(), batchSize)
+
+		result, err := c.db.ExecContext(ctx, deleteQuery, scope.args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete old rows from %s: %w", table, err)
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			break // No more rows to delete
+		}
+		deletedTotal += rowsAffected
+
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cleanup cancelled: %w", ctx.Err())
+		default:
+			// Continue
+		}
+	}
+
+	logInfo(fmt.Sprintf("✅ Cleanup complete - deleted %d old rows from %s", deletedTotal, label))
+	return nil
+}
@@ -0,0 +1,61 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+const (
+	// logsTable is the partitioned host log table (in the admiral schema)
+	logsTable = "logs"
+
+	// defaultLogsRetentionHours applies when logs_retention_hours is not set
+	defaultLogsRetentionHours = 72
+)
+
+// CleanOldLogs creates upcoming daily partitions of admiral.logs and drops
+// partitions whose whole day is older than logs_retention_hours
+func (c *Cleaner) CleanOldLogs(ctx context.Context) error {
+	logInfo("Starting logs retention cleanup...")
+
+	if err := c.ensurePartitions(ctx, logsTable); err != nil {
+		return err
+	}
+
+	retentionSettings, err := c.getLogsRetentionSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read logs retention settings: %w", err)
+	}
+
+	if !retentionSettings.Enabled {
+		logInfo("Logs retention cleanup is disabled, skipping...")
+		return nil
+	}
+
+	return c.dropExpiredPartitions(ctx, logsTable, retentionSettings.RetentionHours)
+}
+
+// getLogsRetentionSettings reads logs_retention_hours from admiral.settings
+// Logs follow the global retention_enabled switch
+func (c *Cleaner) getLogsRetentionSettings(ctx context.Context) (*models.RetentionSettings, error) {
+	global, err := c.getRetentionSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.RetentionSettings{
+		RetentionHours: defaultLogsRetentionHours,
+		Enabled:        global.Enabled,
+	}
+
+	hours, err := c.getHoursSetting(ctx, "logs_retention_hours")
+	if err != nil {
+		return nil, err
+	}
+	if hours > 0 {
+		settings.RetentionHours = hours
+	}
+	return settings, nil
+}
@@ -8,86 +8,33 @@ import (
 	"github.com/nodepulse/admiral/submarines/internal/models"
 )
 
-// CleanOldMetrics removes metrics older than retention policy
+// metricsTable is the partitioned metrics table (in the admiral schema)
+const metricsTable = "metrics"
+
+// CleanOldMetrics creates upcoming daily partitions of admiral.metrics and removes metrics
+// older than retention policy: whole partitions once past the longest retention, rows of
+// servers with a shorter retention policy (per server or per tag) within the remaining ones
 func (c *Cleaner) CleanOldMetrics(ctx context.Context) error {
 	logInfo("Starting metrics retention cleanup...")
 
-	// Read retention settings from admiral.settings
-	retentionSettings, err := c.getRetentionSettings(ctx)
+	if err := c.ensurePartitions(ctx, metricsTable); err != nil {
+		return err
+	}
+
+	// Read retention settings and policies from admiral.settings and admiral.retention_policies
+	plan, err := c.getRetentionPlan(ctx, dataTypeMetrics)
 	if err != nil {
 		return fmt.Errorf("failed to read retention settings: %w", err)
 	}
 
-	if !retentionSettings.Enabled {
+	if !plan.Enabled {
 		logInfo("Metrics retention cleanup is disabled, skipping...")
 		return nil
 	}
 
-	logInfo(fmt.Sprintf("Retention policy: %d hours", retentionSettings.RetentionHours))
-
-	// Calculate total rows to delete (for logging)
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*)
-		FROM admiral.metrics
-		WHERE timestamp < NOW() - INTERVAL '%d hours'
-	`, retentionSettings.RetentionHours)
-
-	var totalRows int64
-	if err := c.db.QueryRowContext(ctx, countQuery).Scan(&totalRows); err != nil {
-		return fmt.Errorf("failed to count old metrics: %w", err)
-	}
-
-	if totalRows == 0 {
-		logInfo(fmt.Sprintf("✓ No old metrics to clean up (retention: %dh, all metrics are recent)", retentionSettings.RetentionHours))
-		return nil
-	}
-
-	logInfo(fmt.Sprintf("⚠ Found %d metrics older than %d hours - starting deletion...", totalRows, retentionSettings.RetentionHours))
-
-	if c.cfg.DryRun {
-		logInfo(fmt.Sprintf("[DRY RUN] Would delete %d old metric records", totalRows))
-		return nil
-	}
-
-	// Delete in batches to avoid long-running transactions
-	const batchSize = 10000
-	deletedTotal := int64(0)
-
-	for {
-		deleteQuery := fmt.Sprintf(`
-			DELETE FROM admiral.metrics
-			WHERE id IN (
-				SELECT id FROM admiral.metrics
-				WHERE timestamp < NOW() - INTERVAL '%d hours'
-				ORDER BY timestamp ASC
-				LIMIT %d
-			)
-		`, retentionSettings.RetentionHours, batchSize)
-
-		result, err := c.db.ExecContext(ctx, deleteQuery)
-		if err != nil {
-			return fmt.Errorf("failed to delete old metrics: %w", err)
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			break // No more rows to delete
-		}
-
-		deletedTotal += rowsAffected
-		logInfo(fmt.Sprintf("🗑️ Deleted batch: %d rows (progress: %d/%d)", rowsAffected, deletedTotal, totalRows))
-
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("cleanup cancelled: %w", ctx.Err())
-		default:
-			// Continue
-		}
-	}
+	logInfo(fmt.Sprintf("Retention policy: %s", plan))
 
-	logInfo(fmt.Sprintf("✅ Cleanup complete - deleted %d old metric records", deletedTotal))
-	return nil
+	return c.cleanPartitionedTableWithPlan(ctx, metricsTable, plan)
 }
 
 // getRetentionSettings reads retention policy from admiral.settings
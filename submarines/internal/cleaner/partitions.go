@@ -0,0 +1,185 @@
+package cleaner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+	"github.com/nodepulse/admiral/submarines/internal/partition"
+)
+
+// partitionDaysAhead is how many daily partitions are kept ready, starting today
+const partitionDaysAhead = 7
+
+// ensurePartitions creates the upcoming daily partitions of admiral.<table>
+// This runs even when retention is disabled or in dry run, otherwise new rows
+// would pile up in the default partition
+func (c *Cleaner) ensurePartitions(ctx context.Context, table string) error {
+	created, err := partition.Ensure(ctx, c.db, table, time.Now(), partitionDaysAhead)
+	if err != nil {
+		return fmt.Errorf("failed to create partitions of admiral.%s: %w", table, err)
+	}
+	if len(created) > 0 {
+		logInfo(fmt.Sprintf("📅 Created %d partitions of admiral.%s: %v", len(created), table, created))
+	}
+	return nil
+}
+
+// dropExpiredPartitions drops the daily partitions of admiral.<table> whose whole day is
+// older than retentionHours, then deletes expired rows from its default partition
+// (e.g. old rows replayed by an agent)
+func (c *Cleaner) dropExpiredPartitions(ctx context.Context, table string, retentionHours int) error {
+	cutoff := time.Now().Add(-time.Duration(retentionHours) * time.Hour)
+
+	partitions, err := partition.List(ctx, c.db, table)
+	if err != nil {
+		return err
+	}
+
+	expired := partition.Expired(partitions, cutoff)
+	if len(expired) == 0 {
+		logInfo(fmt.Sprintf("✓ No expired partitions of admiral.%s (retention: %dh)", table, retentionHours))
+	}
+
+	for _, p := range expired {
+		if c.cfg.DryRun {
+			logInfo(fmt.Sprintf("[DRY RUN] Would drop partition %s", p.Name))
+			continue
+		}
+		if err := partition.Drop(ctx, c.db, p); err != nil {
+			return err
+		}
+		logInfo(fmt.Sprintf("🗑️ Dropped partition %s (retention: %dh)", p.Name, retentionHours))
+	}
+
+	if c.cfg.DryRun {
+		return nil
+	}
+	result, err := c.db.ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM admiral.%s_default
+		WHERE timestamp < $1
+	`, table), cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to delete old rows from admiral.%s_default: %w", table, err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		logInfo(fmt.Sprintf("🗑️ Deleted %d old rows from admiral.%s_default", rowsAffected, table))
+	}
+
+	return nil
+}
+
+// Row deletes for servers kept shorter than the longest retention of a plan are bounded
+// per run: the cleaner runs every minute with a 30s budget, so a large backlog (e.g. right
+// after a short policy was added) is worked off over several runs instead of stalling the
+// other jobs
+const (
+	partitionDeleteBatchSize  = 10000
+	partitionDeleteMaxBatches = 10
+)
+
+// cleanPartitionedTableWithPlan applies a retention plan to a partitioned table with
+// server_id and timestamp columns: partitions are dropped once they are older than the
+// longest retention of the plan. Partitions are per day, not per retention class, so
+// servers kept for less than that (a shorter default or override) still need row deletes
+// inside the partitions that remain; those are limited to the days between both cutoffs
+// and to partitionDeleteMaxBatches per run (see deleteExpiredRows)
+func (c *Cleaner) cleanPartitionedTableWithPlan(ctx context.Context, table string, plan *retentionPlan) error {
+	longest := plan.longestHours()
+	if err := c.dropExpiredPartitions(ctx, table, longest); err != nil {
+		return err
+	}
+
+	for _, s := range plan.scopes() {
+		if s.RetentionHours >= longest {
+			continue
+		}
+		if err := c.deleteExpiredRows(ctx, table, s.RetentionHours, longest, s.Scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExpiredRows deletes rows within scope of admiral.<table> older than retentionHours
+// Only rows newer than longestHours are looked at (older partitions are dropped), so the
+// deletes are pruned to the partitions of those days; rows left after
+// partitionDeleteMaxBatches batches are deleted on the next run
+func (c *Cleaner) deleteExpiredRows(ctx context.Context, table string, retentionHours, longestHours int, scope rowScope) error {
+	label := scope.label("admiral." + table)
+
+	now := time.Now()
+	cutoff := now.Add(-time.Duration(retentionHours) * time.Hour)
+	floor := now.Add(-time.Duration(longestHours) * time.Hour).UTC().Truncate(partition.Day)
+
+	// Scope arguments come first ($1, ...), the time bounds follow them
+	args := append(append([]any{}, scope.args...), floor, cutoff)
+	floorParam, cutoffParam := len(scope.args)+1, len(scope.args)+2
+
+	if c.cfg.DryRun {
+		logInfo(fmt.Sprintf("[DRY RUN] Would delete rows from %s older than %d hours", label, retentionHours))
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+		DELETE FROM admiral.%s
+		WHERE (id, timestamp) IN (
+			SELECT id, timestamp FROM admiral.%s
+			WHERE timestamp >= $%d AND timestamp < $%d
+			%s
+			ORDER BY timestamp ASC
+			LIMIT %d
+		)
+	`, table, table, floorParam, cutoffParam, scope.where(), partitionDeleteBatchSize)
+
+	deletedTotal := int64(0)
+	for batch := 0; batch < partitionDeleteMaxBatches; batch++ {
+		result, err := c.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete old rows from %s: %w", label, err)
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		deletedTotal += rowsAffected
+		if rowsAffected < partitionDeleteBatchSize {
+			if deletedTotal == 0 {
+				logInfo(fmt.Sprintf("✓ No old rows in %s to clean up (retention: %dh)", label, retentionHours))
+			} else {
+				logInfo(fmt.Sprintf("✅ Cleanup complete - deleted %d old rows from %s", deletedTotal, label))
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cleanup cancelled: %w", ctx.Err())
+		default:
+		}
+	}
+
+	logInfo(fmt.Sprintf("⚠ Deleted %d old rows from %s (retention: %dh), continuing on the next run", deletedTotal, label, retentionHours))
+	return nil
+}
+
+// getHoursSetting reads a retention period in hours from admiral.settings
+// Returns 0 when the setting is missing, null or not a positive number
+func (c *Cleaner) getHoursSetting(ctx context.Context, key string) (int, error) {
+	var value models.JSONValue
+	err := c.db.QueryRowContext(ctx, `
+		SELECT value FROM admiral.settings WHERE key = $1
+	`, key).Scan(&value)
+	if err == sql.ErrNoRows || (err != nil && isMissingRelation(err)) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("query failed: %w", err)
+	}
+
+	hours, err := value.Int()
+	if err != nil || hours <= 0 {
+		return 0, nil
+	}
+	return hours, nil
+}
@@ -0,0 +1,57 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// processedMessageRetention is how long stream message IDs stay in the ledger
+// Messages are only redelivered while pending (at most a few retries before the DLQ),
+// so a day is far beyond any redelivery window
+const processedMessageRetention = 24 * time.Hour
+
+// CleanProcessedMessages trims admiral.processed_messages to its retention
+func (c *Cleaner) CleanProcessedMessages(ctx context.Context) error {
+	if c.cfg.DryRun {
+		logInfo("[DRY RUN] Skipping processed message ledger cleanup")
+		return nil
+	}
+
+	// Delete in batches to avoid long-running transactions
+	const batchSize = 10000
+	deletedTotal := int64(0)
+
+	for {
+		result, err := c.db.ExecContext(ctx, `
+			DELETE FROM admiral.processed_messages
+			WHERE (stream, message_id) IN (
+				SELECT stream, message_id FROM admiral.processed_messages
+				WHERE processed_at < NOW() - make_interval(secs => $1)
+				LIMIT $2
+			)
+		`, processedMessageRetention.Seconds(), batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to delete processed messages: %w", err)
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		deletedTotal += rowsAffected
+		if rowsAffected < batchSize {
+			break
+		}
+
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cleanup cancelled: %w", ctx.Err())
+		default:
+			// Continue
+		}
+	}
+
+	if deletedTotal > 0 {
+		logInfo(fmt.Sprintf("🗑️ Deleted %d processed message IDs older than %v", deletedTotal, processedMessageRetention))
+	}
+	return nil
+}
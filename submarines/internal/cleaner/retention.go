@@ -0,0 +1,202 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Data types a retention policy can target (admiral.retention_policies.data_type)
+const (
+	dataTypeAll              = "all"
+	dataTypeMetrics          = "metrics"
+	dataTypeProcessSnapshots = "process_snapshots"
+)
+
+// retentionPlan is the resolved retention of one data type across the fleet
+type retentionPlan struct {
+	Enabled      bool
+	DefaultHours int            // Servers without a policy
+	Overrides    map[string]int // server_id -> retention hours, for servers with a policy
+}
+
+// serverGroup is a set of overridden servers sharing the same retention
+type serverGroup struct {
+	RetentionHours int
+	ServerIDs      []string
+}
+
+// groups returns the overridden servers grouped by retention, shortest retention first
+func (p *retentionPlan) groups() []serverGroup {
+	byHours := make(map[int][]string)
+	for serverID, hours := range p.Overrides {
+		byHours[hours] = append(byHours[hours], serverID)
+	}
+
+	groups := make([]serverGroup, 0, len(byHours))
+	for hours, serverIDs := range byHours {
+		sort.Strings(serverIDs)
+		groups = append(groups, serverGroup{RetentionHours: hours, ServerIDs: serverIDs})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].RetentionHours < groups[j].RetentionHours })
+	return groups
+}
+
+// overriddenServers returns every server with a policy
+func (p *retentionPlan) overriddenServers() []string {
+	serverIDs := make([]string, 0, len(p.Overrides))
+	for serverID := range p.Overrides {
+		serverIDs = append(serverIDs, serverID)
+	}
+	sort.Strings(serverIDs)
+	return serverIDs
+}
+
+// longestHours returns the longest retention of any server
+func (p *retentionPlan) longestHours() int {
+	longest := p.DefaultHours
+	for _, hours := range p.Overrides {
+		longest = max(longest, hours)
+	}
+	return longest
+}
+
+// String summarizes the plan for logging
+func (p *retentionPlan) String() string {
+	s := fmt.Sprintf("%d hours", p.DefaultHours)
+	for _, g := range p.groups() {
+		s += fmt.Sprintf(", %dh for %d server(s)", g.RetentionHours, len(g.ServerIDs))
+	}
+	return s
+}
+
+// getRetentionPlan resolves the retention of a data type (metrics or process_snapshots):
+// the global retention_hours, overridden by <data type>_retention_hours, overridden per
+// server by admiral.retention_policies. Among policies the most specific wins
+// (server + data type, server + all, tag + data type, tag + all); when several tags of
+// a server match at the same level the longest retention wins, so no policy loses data
+func (c *Cleaner) getRetentionPlan(ctx context.Context, dataType string) (*retentionPlan, error) {
+	global, err := c.getRetentionSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &retentionPlan{
+		Enabled:      global.Enabled,
+		DefaultHours: global.RetentionHours,
+		Overrides:    make(map[string]int),
+	}
+
+	hours, err := c.getHoursSetting(ctx, dataType+"_retention_hours")
+	if err != nil {
+		return nil, err
+	}
+	if hours > 0 {
+		plan.DefaultHours = hours
+	}
+
+	// Tag policies are expanded to the servers currently carrying the tag
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT COALESCE(p.server_id, s.server_id), p.server_id IS NOT NULL, p.data_type, p.retention_hours
+		FROM admiral.retention_policies p
+		LEFT JOIN admiral.servers s
+			ON p.tag IS NOT NULL AND COALESCE(s.tags, '[]'::jsonb) @> jsonb_build_array(p.tag)
+		WHERE p.data_type IN ($1, $2)
+		  AND (p.server_id IS NOT NULL OR s.server_id IS NOT NULL)
+	`, dataTypeAll, dataType)
+	if err != nil {
+		if isMissingRelation(err) {
+			logInfo("admiral.retention_policies table not found, no retention overrides")
+			return plan, nil
+		}
+		return nil, fmt.Errorf("failed to query retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	// Specificity of the policy that set each override
+	specificity := make(map[string]int)
+
+	for rows.Next() {
+		var serverID, policyType string
+		var byServer bool
+		var hours int
+		if err := rows.Scan(&serverID, &byServer, &policyType, &hours); err != nil {
+			return nil, fmt.Errorf("failed to scan retention policy: %w", err)
+		}
+
+		rank := 0
+		if byServer {
+			rank += 2
+		}
+		if policyType == dataType {
+			rank++
+		}
+
+		current, seen := specificity[serverID]
+		switch {
+		case !seen || rank > current:
+			specificity[serverID] = rank
+			plan.Overrides[serverID] = hours
+		case rank == current && hours > plan.Overrides[serverID]:
+			plan.Overrides[serverID] = hours
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read retention policies: %w", err)
+	}
+
+	return plan, nil
+}
+
+// scopedRetention is the retention of some rows of a table
+type scopedRetention struct {
+	RetentionHours int
+	Scope          rowScope
+}
+
+// scopes splits the plan into row scopes: each group of overridden servers, then every other server
+func (p *retentionPlan) scopes() []scopedRetention {
+	if len(p.Overrides) == 0 {
+		return []scopedRetention{{RetentionHours: p.DefaultHours}}
+	}
+
+	scopes := []scopedRetention{}
+	for _, g := range p.groups() {
+		scopes = append(scopes, scopedRetention{
+			RetentionHours: g.RetentionHours,
+			Scope: rowScope{
+				condition:   "server_id = ANY($1)",
+				args:        []any{pq.Array(g.ServerIDs)},
+				description: fmt.Sprintf("%d overridden server(s)", len(g.ServerIDs)),
+			},
+		})
+	}
+	return append(scopes, scopedRetention{
+		RetentionHours: p.DefaultHours,
+		Scope: rowScope{
+			condition:   "NOT (server_id = ANY($1))",
+			args:        []any{pq.Array(p.overriddenServers())},
+			description: "servers without a retention policy",
+		},
+	})
+}
+
+// cleanTableWithPlan applies a retention plan to a table with id, server_id and timestamp
+// columns: each group of overridden servers with its own retention, every other server
+// with the default retention
+func (c *Cleaner) cleanTableWithPlan(ctx context.Context, table string, plan *retentionPlan) error {
+	for _, s := range plan.scopes() {
+		if err := c.cleanRows(ctx, table, s.RetentionHours, s.Scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isMissingRelation reports whether a query failed because a table doesn't exist yet
+func isMissingRelation(err error) bool {
+	return strings.Contains(err.Error(), "does not exist") && strings.Contains(err.Error(), "relation")
+}
@@ -0,0 +1,38 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+)
+
+// sshSessionRecordingsTable is the partitioned SSH session recordings table (in the admiral schema)
+const sshSessionRecordingsTable = "ssh_session_recordings"
+
+// CleanOldSSHSessionRecordings creates upcoming daily partitions of admiral.ssh_session_recordings
+// and drops partitions whose whole day is older than ssh_session_recording_retention_hours
+// Recordings are kept forever while the setting is null
+func (c *Cleaner) CleanOldSSHSessionRecordings(ctx context.Context) error {
+	logInfo("Starting SSH session recordings retention cleanup...")
+
+	if err := c.ensurePartitions(ctx, sshSessionRecordingsTable); err != nil {
+		return err
+	}
+
+	// Recordings follow the global retention_enabled switch
+	global, err := c.getRetentionSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read retention settings: %w", err)
+	}
+
+	retentionHours, err := c.getHoursSetting(ctx, "ssh_session_recording_retention_hours")
+	if err != nil {
+		return fmt.Errorf("failed to read SSH session recordings retention settings: %w", err)
+	}
+
+	if !global.Enabled || retentionHours == 0 {
+		logInfo("SSH session recordings retention cleanup is disabled, skipping...")
+		return nil
+	}
+
+	return c.dropExpiredPartitions(ctx, sshSessionRecordingsTable, retentionHours)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +20,26 @@ type Config struct {
 	ValkeyHost     string
 	ValkeyPort     string
 	ValkeyPassword string
+	ValkeyUsername string // ACL user, empty = default user
+
+	// Valkey topology: standalone, sentinel or cluster
+	// Sentinel and cluster take their seed nodes from ValkeyAddresses (host:port, comma-separated)
+	ValkeyMode             string
+	ValkeyAddresses        string
+	ValkeySentinelMaster   string
+	ValkeySentinelUsername string
+	ValkeySentinelPassword string
+
+	// Queue backend: valkey (streams) or embedded (bolt file at QueuePath)
+	QueueBackend string
+	QueuePath    string
+
+	// Valkey TLS
+	ValkeyTLS           bool
+	ValkeyTLSCAFile     string // PEM bundle, empty = system roots
+	ValkeyTLSCertFile   string // Client certificate (mutual TLS)
+	ValkeyTLSKeyFile    string
+	ValkeyTLSServerName string // Overrides the name verified against the server certificate
 
 	// Server
 	GinMode string
@@ -38,6 +59,20 @@ type Config struct {
 	// Cleaner-specific
 	DryRun           bool
 	LogLevel         string
+
+	// Ingest spool (on-disk buffer for messages that couldn't be published to Valkey)
+	IngestSpoolEnabled   bool
+	IngestSpoolDir       string
+	IngestSpoolMaxMB     int
+	IngestSpoolSegmentMB int
+	IngestSpoolFsync     string // always, interval or never
+
+	// Alerter-specific
+	AlertEvalInterval int // Seconds between alert rule evaluation cycles
+
+	// Tracing (OpenTelemetry)
+	OTLPEndpoint     string  // OTLP/HTTP collector URL (e.g. http://otel-collector:4318), empty disables export
+	TraceSampleRatio float64 // Fraction of new traces sampled (0-1), incoming sampled traces are always kept
 }
 
  
@@ -56,6 +91,22 @@ func Load() *Config {
 		ValkeyHost:     getEnv("VALKEY_HOST", "valkey"),
 		ValkeyPort:     getEnv("VALKEY_PORT", "6379"),
 		ValkeyPassword: getEnv("VALKEY_PASSWORD", ""),
+		ValkeyUsername: getEnv("VALKEY_USERNAME", ""),
+
+		ValkeyMode:             getEnv("VALKEY_MODE", "standalone"),
+		ValkeyAddresses:        getEnv("VALKEY_ADDRESSES", ""),
+		ValkeySentinelMaster:   getEnv("VALKEY_SENTINEL_MASTER", "mymaster"),
+		ValkeySentinelUsername: getEnv("VALKEY_SENTINEL_USERNAME", ""),
+		ValkeySentinelPassword: getEnv("VALKEY_SENTINEL_PASSWORD", ""),
+
+		QueueBackend: getEnv("QUEUE_BACKEND", "valkey"),
+		QueuePath:    getEnv("QUEUE_PATH", "/var/lib/submarines/queue/queue.db"),
+
+		ValkeyTLS:           getEnv("VALKEY_TLS", "false") == "true",
+		ValkeyTLSCAFile:     getEnv("VALKEY_TLS_CA_FILE", ""),
+		ValkeyTLSCertFile:   getEnv("VALKEY_TLS_CERT_FILE", ""),
+		ValkeyTLSKeyFile:    getEnv("VALKEY_TLS_KEY_FILE", ""),
+		ValkeyTLSServerName: getEnv("VALKEY_TLS_SERVER_NAME", ""),
 
 		// Server
 		GinMode: getEnv("GIN_MODE", "debug"),
@@ -75,6 +126,20 @@ func Load() *Config {
 		// Cleaner-specific
 		DryRun:           getEnv("DRY_RUN", "false") == "true",
 		LogLevel:         getEnv("LOG_LEVEL", "info"),
+
+		// Ingest spool
+		IngestSpoolEnabled:   getEnv("INGEST_SPOOL_ENABLED", "true") == "true",
+		IngestSpoolDir:       getEnv("INGEST_SPOOL_DIR", "/var/lib/submarines/spool"),
+		IngestSpoolMaxMB:     getEnvInt("INGEST_SPOOL_MAX_MB", 1024),
+		IngestSpoolSegmentMB: getEnvInt("INGEST_SPOOL_SEGMENT_MB", 64),
+		IngestSpoolFsync:     getEnv("INGEST_SPOOL_FSYNC", "interval"),
+
+		// Alerter-specific
+		AlertEvalInterval: getEnvInt("ALERT_EVAL_INTERVAL", 30),
+
+		// Tracing (standard OpenTelemetry variable names)
+		OTLPEndpoint:     getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TraceSampleRatio: getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1.0),
 	}
 }
 
@@ -89,6 +154,21 @@ func (c *Config) GetValkeyAddress() string {
 	return fmt.Sprintf("%s:%s", c.ValkeyHost, c.ValkeyPort)
 }
 
+// GetValkeyAddresses returns the Valkey seed nodes (sentinels or cluster nodes),
+// falling back to VALKEY_HOST:VALKEY_PORT
+func (c *Config) GetValkeyAddresses() []string {
+	addresses := []string{}
+	for _, addr := range strings.Split(c.ValkeyAddresses, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addresses = append(addresses, addr)
+		}
+	}
+	if len(addresses) == 0 {
+		addresses = append(addresses, c.GetValkeyAddress())
+	}
+	return addresses
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -106,6 +186,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // loadMasterKey loads the master encryption key from file
 // If required=true, exits on error. If required=false, returns empty string on error.
 func loadMasterKey() string {
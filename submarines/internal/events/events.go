@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// Sources of admiral.server_events rows
+const (
+	SourceAdmiral = "admiral" // Generated server-side (status monitor, digest)
+	SourceAgent   = "agent"   // Reported by the agent
+)
+
+// Event types agents may report
+const (
+	TypeReboot          = "reboot"
+	TypePackageInstall  = "package_install"
+	TypePackageUpgrade  = "package_upgrade"
+	TypePackageRemove   = "package_remove"
+	TypeOOMKill         = "oom_kill"
+	TypeKernelChange    = "kernel_change"
+	TypeKernelMessage   = "kernel_message" // e.g. hung task, I/O errors, MCE
+	TypeConfigChange    = "config_change"
+	TypeAgentRestart    = "agent_restart"
+	TypeClockAdjustment = "clock_adjustment"
+)
+
+// AgentTypes lists the event types accepted from agents
+var AgentTypes = []string{
+	TypeReboot, TypePackageInstall, TypePackageUpgrade, TypePackageRemove, TypeOOMKill,
+	TypeKernelChange, TypeKernelMessage, TypeConfigChange, TypeAgentRestart, TypeClockAdjustment,
+}
+
+// IsAgentType reports whether agents may report an event type
+func IsAgentType(eventType string) bool {
+	return slices.Contains(AgentTypes, eventType)
+}
+
+// Filter narrows a timeline query
+type Filter struct {
+	ServerID string   // Agent server_id
+	Types    []string // Any of these event types
+	Source   string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+	Offset   int
+}
+
+// List returns server events matching a filter, newest first, with the total number of matches
+func List(ctx context.Context, db *sql.DB, f Filter) ([]models.ServerEvent, int, error) {
+	conditions := []string{}
+	args := []any{}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if f.ServerID != "" {
+		add("server_id = $%d", f.ServerID)
+	}
+	if len(f.Types) > 0 {
+		placeholders := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			args = append(args, t)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, "event_type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.Source != "" {
+		add("source = $%d", f.Source)
+	}
+	if !f.Since.IsZero() {
+		add("occurred_at >= $%d", f.Since)
+	}
+	if !f.Until.IsZero() {
+		add("occurred_at < $%d", f.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM admiral.server_events "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count server events: %w", err)
+	}
+
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit, max(f.Offset, 0))
+	query := fmt.Sprintf(`
+		SELECT id, server_id, event_type, source, COALESCE(message, ''), COALESCE(data, '{}'::jsonb), occurred_at
+		FROM admiral.server_events
+		%s
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query server events: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.ServerEvent{}
+	for rows.Next() {
+		var e models.ServerEvent
+		var data []byte
+		if err := rows.Scan(&e.ID, &e.ServerID, &e.EventType, &e.Source, &e.Message, &data, &e.OccurredAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan server event: %w", err)
+		}
+		e.Data = data
+		result = append(result, e)
+	}
+
+	return result, total, rows.Err()
+}
@@ -0,0 +1,286 @@
+package fleet
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Metrics available to fleet queries (same definitions as alert rules)
+// Percentages are 0-100, load values are raw load averages
+var Metrics = []string{
+	"cpu_usage", "cpu_iowait", "memory_usage", "swap_usage", "disk_usage",
+	"load_1min", "load_5min", "load_15min",
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Filter scopes a fleet query
+type Filter struct {
+	Window time.Duration // Samples newer than now - Window
+	Tags   []string      // Servers must carry all tags (empty = whole fleet)
+}
+
+// ServerMetrics are a server's metric values over the query window
+// Gauges come from the latest snapshot; counters (CPU) use the delta between
+// the first and the latest snapshot in the window
+type ServerMetrics struct {
+	ServerID string              `json:"server_id"` // Agent server_id
+	Hostname string              `json:"hostname"`
+	Tags     []string            `json:"tags"`
+	FirstAt  time.Time           `json:"first_at"`
+	LatestAt time.Time           `json:"latest_at"`
+	Values   map[string]*float64 `json:"values"`
+}
+
+// Value returns a metric value, false when it couldn't be computed
+func (s *ServerMetrics) Value(metric string) (float64, bool) {
+	v := s.Values[metric]
+	if v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
+// scopedServers selects servers matching the tag filter ($2, JSON array)
+const scopedServers = `
+	scoped AS (
+		SELECT server_id, COALESCE(NULLIF(hostname, ''), name, server_id) AS hostname,
+		       COALESCE(tags, '[]'::jsonb) AS tags
+		FROM admiral.servers
+		WHERE COALESCE(tags, '[]'::jsonb) @> $2::jsonb
+	)
+`
+
+// snapshotColumns derives the metric values of one admiral.metrics row (alias m)
+const snapshotColumns = `
+	m.timestamp,
+	COALESCE(m.cpu_idle_seconds, 0) AS idle,
+	COALESCE(m.cpu_iowait_seconds, 0) AS iowait,
+	COALESCE(m.cpu_idle_seconds, 0) + COALESCE(m.cpu_iowait_seconds, 0) + COALESCE(m.cpu_system_seconds, 0)
+		+ COALESCE(m.cpu_user_seconds, 0) + COALESCE(m.cpu_steal_seconds, 0) AS cpu_total,
+	100.0 * (m.memory_total_bytes - m.memory_available_bytes) / NULLIF(m.memory_total_bytes, 0) AS memory_usage,
+	100.0 * (m.swap_total_bytes - m.swap_free_bytes) / NULLIF(m.swap_total_bytes, 0) AS swap_usage,
+	100.0 * (m.disk_total_bytes - m.disk_available_bytes) / NULLIF(m.disk_total_bytes, 0) AS disk_usage,
+	m.load_1min, m.load_5min, m.load_15min
+`
+
+// Servers computes every metric for each server with samples in the window
+func Servers(ctx context.Context, q queryer, f Filter) ([]ServerMetrics, error) {
+	tags, err := tagsParam(f.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the two snapshots bounding the window are read per server, each an
+	// index lookup on (server_id, timestamp DESC)
+	query := `
+		WITH ` + scopedServers + `
+		SELECT s.server_id, s.hostname, s.tags, f.timestamp, l.timestamp,
+		       CASE WHEN l.cpu_total > f.cpu_total AND l.idle >= f.idle
+		            THEN 100 * (1 - (l.idle - f.idle) / (l.cpu_total - f.cpu_total)) END,
+		       CASE WHEN l.cpu_total > f.cpu_total AND l.iowait >= f.iowait
+		            THEN 100 * (l.iowait - f.iowait) / (l.cpu_total - f.cpu_total) END,
+		       l.memory_usage, l.swap_usage, l.disk_usage,
+		       l.load_1min, l.load_5min, l.load_15min
+		FROM scoped s
+		CROSS JOIN LATERAL (
+			SELECT ` + snapshotColumns + `
+			FROM admiral.metrics m
+			WHERE m.server_id = s.server_id AND m.timestamp > NOW() - make_interval(secs => $1)
+			ORDER BY m.timestamp DESC
+			LIMIT 1
+		) l
+		CROSS JOIN LATERAL (
+			SELECT ` + snapshotColumns + `
+			FROM admiral.metrics m
+			WHERE m.server_id = s.server_id AND m.timestamp > NOW() - make_interval(secs => $1)
+			ORDER BY m.timestamp ASC
+			LIMIT 1
+		) f
+	`
+
+	rows, err := q.QueryContext(ctx, query, f.Window.Seconds(), tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fleet metrics: %w", err)
+	}
+	defer rows.Close()
+
+	servers := []ServerMetrics{}
+	for rows.Next() {
+		var s ServerMetrics
+		var rawTags []byte
+		values := make([]sql.NullFloat64, len(Metrics))
+		dest := []any{&s.ServerID, &s.Hostname, &rawTags, &s.FirstAt, &s.LatestAt}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan fleet metrics: %w", err)
+		}
+
+		s.Tags = []string{}
+		if err := json.Unmarshal(rawTags, &s.Tags); err != nil {
+			return nil, fmt.Errorf("invalid tags for server %s: %w", s.ServerID, err)
+		}
+
+		s.Values = make(map[string]*float64, len(Metrics))
+		for i, metric := range Metrics {
+			if values[i].Valid {
+				v := round2(values[i].Float64)
+				s.Values[metric] = &v
+			} else {
+				s.Values[metric] = nil
+			}
+		}
+		servers = append(servers, s)
+	}
+
+	return servers, rows.Err()
+}
+
+// Top returns the servers with the highest (or lowest) value of a metric
+func Top(ctx context.Context, q queryer, f Filter, metric string, limit int, ascending bool) ([]ServerMetrics, error) {
+	servers, err := Servers(ctx, q, f)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := servers[:0]
+	for _, s := range servers {
+		if _, ok := s.Value(metric); ok {
+			ranked = append(ranked, s)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, _ := ranked[i].Value(metric)
+		b, _ := ranked[j].Value(metric)
+		if ascending {
+			return a < b
+		}
+		return a > b
+	})
+
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, nil
+}
+
+// Matching returns the servers whose metric satisfies a condition (gt, gte, lt, lte), highest first
+func Matching(ctx context.Context, q queryer, f Filter, metric, condition string, threshold float64) ([]ServerMetrics, error) {
+	servers, err := Top(ctx, q, f, metric, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []ServerMetrics{}
+	for _, s := range servers {
+		v, _ := s.Value(metric)
+		if compare(condition, v, threshold) {
+			matched = append(matched, s)
+		}
+	}
+	return matched, nil
+}
+
+// Group is an aggregate of a metric over the servers sharing a tag
+type Group struct {
+	Tag     string  `json:"tag,omitempty"` // Empty for the whole fleet or untagged servers
+	Servers int     `json:"servers"`
+	Avg     float64 `json:"avg"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// Aggregate summarizes a metric per tag (a server counts towards each of its tags),
+// or over the whole fleet when byTag is false
+func Aggregate(ctx context.Context, q queryer, f Filter, metric string, byTag bool) ([]Group, error) {
+	servers, err := Servers(ctx, q, f)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*Group)
+	order := []string{}
+	add := func(tag string, v float64) {
+		g, ok := groups[tag]
+		if !ok {
+			g = &Group{Tag: tag, Min: v, Max: v}
+			groups[tag] = g
+			order = append(order, tag)
+		}
+		g.Servers++
+		g.Avg += v // Sum until finalized
+		g.Min = math.Min(g.Min, v)
+		g.Max = math.Max(g.Max, v)
+	}
+
+	for _, s := range servers {
+		v, ok := s.Value(metric)
+		if !ok {
+			continue
+		}
+		if !byTag || len(s.Tags) == 0 {
+			add("", v)
+			continue
+		}
+		for _, tag := range s.Tags {
+			add(tag, v)
+		}
+	}
+
+	slices.Sort(order)
+	result := make([]Group, 0, len(order))
+	for _, tag := range order {
+		g := groups[tag]
+		g.Avg = round2(g.Avg / float64(g.Servers))
+		result = append(result, *g)
+	}
+	return result, nil
+}
+
+// IsMetric reports whether a metric can be used in fleet queries
+func IsMetric(metric string) bool {
+	return slices.Contains(Metrics, metric)
+}
+
+func compare(condition string, value, threshold float64) bool {
+	switch condition {
+	case "gt":
+		return value > threshold
+	case "gte":
+		return value >= threshold
+	case "lt":
+		return value < threshold
+	case "lte":
+		return value <= threshold
+	default:
+		return false
+	}
+}
+
+// tagsParam encodes a tag filter as a JSON array for @>
+func tagsParam(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tag filter: %w", err)
+	}
+	return string(b), nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
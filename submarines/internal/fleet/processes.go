@@ -0,0 +1,99 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Process orderings for TopProcesses
+const (
+	ByMemory = "memory"
+	ByCPU    = "cpu"
+)
+
+// Process is a process group on one server at its latest scrape in the query window
+type Process struct {
+	ServerID    string    `json:"server_id"` // Agent server_id
+	Hostname    string    `json:"hostname"`
+	ProcessName string    `json:"process_name"`
+	NumProcs    int       `json:"num_procs"`    // Latest scrape
+	MemoryBytes int64     `json:"memory_bytes"` // Latest scrape (RSS)
+	CPUCores    *float64  `json:"cpu_cores"`    // CPU seconds per second between the first and latest scrape
+	LatestAt    time.Time `json:"latest_at"`
+}
+
+// TopProcesses returns the process groups using the most memory or CPU across the fleet
+func TopProcesses(ctx context.Context, q queryer, f Filter, by string, limit int) ([]Process, error) {
+	tags, err := tagsParam(f.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whitelisted ordering, never user input
+	orderBy := "memory_bytes DESC NULLS LAST"
+	if by == ByCPU {
+		orderBy = "cpu_cores DESC NULLS LAST"
+	}
+
+	// Only the first and latest scrape in the window are read per server: the
+	// bounding timestamps are index lookups, their rows share the timestamp
+	query := `
+		WITH ` + scopedServers + `,
+		bounds AS (
+			SELECT s.server_id, s.hostname, f.timestamp AS first_at, l.timestamp AS latest_at
+			FROM scoped s
+			CROSS JOIN LATERAL (
+				SELECT p.timestamp FROM admiral.process_snapshots p
+				WHERE p.server_id = s.server_id AND p.timestamp > NOW() - make_interval(secs => $1)
+				ORDER BY p.timestamp DESC
+				LIMIT 1
+			) l
+			CROSS JOIN LATERAL (
+				SELECT p.timestamp FROM admiral.process_snapshots p
+				WHERE p.server_id = s.server_id AND p.timestamp > NOW() - make_interval(secs => $1)
+				ORDER BY p.timestamp ASC
+				LIMIT 1
+			) f
+		),
+		ranked AS (
+			SELECT b.server_id, b.hostname, l.process_name, l.num_procs,
+			       COALESCE(l.memory_bytes, 0) AS memory_bytes,
+			       CASE WHEN b.latest_at > b.first_at AND l.cpu_seconds_total >= f.cpu_seconds_total
+			            THEN (l.cpu_seconds_total - f.cpu_seconds_total) / EXTRACT(EPOCH FROM (b.latest_at - b.first_at)) END AS cpu_cores,
+			       b.latest_at
+			FROM bounds b
+			JOIN admiral.process_snapshots l
+			  ON l.server_id = b.server_id AND l.timestamp = b.latest_at
+			LEFT JOIN admiral.process_snapshots f
+			  ON f.server_id = b.server_id AND f.timestamp = b.first_at AND f.process_name = l.process_name
+			WHERE l.num_procs > 0
+		)
+		SELECT server_id, hostname, process_name, num_procs, memory_bytes, cpu_cores, latest_at
+		FROM ranked
+		ORDER BY ` + orderBy + `
+		LIMIT $3
+	`
+
+	rows, err := q.QueryContext(ctx, query, f.Window.Seconds(), tags, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fleet processes: %w", err)
+	}
+	defer rows.Close()
+
+	processes := []Process{}
+	for rows.Next() {
+		var p Process
+		if err := rows.Scan(&p.ServerID, &p.Hostname, &p.ProcessName, &p.NumProcs,
+			&p.MemoryBytes, &p.CPUCores, &p.LatestAt); err != nil {
+			return nil, fmt.Errorf("failed to scan fleet process: %w", err)
+		}
+		if p.CPUCores != nil {
+			v := round2(*p.CPUCores)
+			p.CPUCores = &v
+		}
+		processes = append(processes, p)
+	}
+
+	return processes, rows.Err()
+}
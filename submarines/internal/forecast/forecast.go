@@ -0,0 +1,186 @@
+package forecast
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Resources whose exhaustion can be forecast
+const (
+	ResourceDisk   = "disk"   // disk_available_bytes of the root filesystem
+	ResourceMemory = "memory" // memory_available_bytes
+	ResourceSwap   = "swap"   // swap_free_bytes
+)
+
+// Resources lists all forecastable resources
+var Resources = []string{ResourceDisk, ResourceMemory, ResourceSwap}
+
+const (
+	// DefaultLookback is the trend window when none is configured
+	DefaultLookback = 24 * time.Hour
+
+	// MaxHours caps "hours until full" so it can be stored in admiral.alerts value columns
+	// (about 11 years, effectively "never")
+	MaxHours = 100000.0
+
+	// minSamples is how many samples a trend needs
+	minSamples = 10
+
+	// minSpan is how much time the samples of a trend must cover
+	minSpan = time.Hour
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Forecast is the linear trend of one resource on one server
+type Forecast struct {
+	ServerID          string     `json:"server_id"` // Agent server_id
+	Hostname          string     `json:"hostname,omitempty"`
+	Resource          string     `json:"resource"`
+	TotalBytes        int64      `json:"total_bytes"`
+	AvailableBytes    int64      `json:"available_bytes"`
+	SlopeBytesPerHour float64    `json:"slope_bytes_per_hour"` // Negative = filling up
+	R2                *float64   `json:"r2,omitempty"`         // Goodness of fit (0-1)
+	HoursUntilFull    *float64   `json:"hours_until_full"`     // nil = not filling up
+	PredictedFullAt   *time.Time `json:"predicted_full_at"`
+	Samples           int        `json:"samples"`
+	LatestAt          time.Time  `json:"latest_at"`
+}
+
+// Hours returns the hours until full, MaxHours when the resource isn't filling up
+func (f *Forecast) Hours() float64 {
+	if f.HoursUntilFull == nil {
+		return MaxHours
+	}
+	return *f.HoursUntilFull
+}
+
+// Compute fits a least-squares trend of available bytes over the lookback window
+// for every server (or a single agent server_id) and resource
+// Servers with too few samples or too short a history are skipped
+func Compute(ctx context.Context, q queryer, lookback time.Duration, serverID string) ([]Forecast, error) {
+	if lookback <= 0 {
+		lookback = DefaultLookback
+	}
+
+	// regr_* treat x = epoch seconds, y = available bytes
+	query := `
+		WITH trend AS (
+			SELECT server_id,
+			       COUNT(*) AS samples,
+			       MIN(timestamp) AS first_at,
+			       MAX(timestamp) AS latest_at,
+			       regr_slope(disk_available_bytes, EXTRACT(EPOCH FROM timestamp)) AS disk_slope,
+			       regr_r2(disk_available_bytes, EXTRACT(EPOCH FROM timestamp)) AS disk_r2,
+			       (array_agg(disk_available_bytes ORDER BY timestamp DESC) FILTER (WHERE disk_available_bytes IS NOT NULL))[1] AS disk_available,
+			       (array_agg(disk_total_bytes ORDER BY timestamp DESC) FILTER (WHERE disk_total_bytes IS NOT NULL))[1] AS disk_total,
+			       regr_slope(memory_available_bytes, EXTRACT(EPOCH FROM timestamp)) AS memory_slope,
+			       regr_r2(memory_available_bytes, EXTRACT(EPOCH FROM timestamp)) AS memory_r2,
+			       (array_agg(memory_available_bytes ORDER BY timestamp DESC) FILTER (WHERE memory_available_bytes IS NOT NULL))[1] AS memory_available,
+			       (array_agg(memory_total_bytes ORDER BY timestamp DESC) FILTER (WHERE memory_total_bytes IS NOT NULL))[1] AS memory_total,
+			       regr_slope(swap_free_bytes, EXTRACT(EPOCH FROM timestamp)) AS swap_slope,
+			       regr_r2(swap_free_bytes, EXTRACT(EPOCH FROM timestamp)) AS swap_r2,
+			       (array_agg(swap_free_bytes ORDER BY timestamp DESC) FILTER (WHERE swap_free_bytes IS NOT NULL))[1] AS swap_available,
+			       (array_agg(swap_total_bytes ORDER BY timestamp DESC) FILTER (WHERE swap_total_bytes IS NOT NULL))[1] AS swap_total
+			FROM admiral.metrics
+			WHERE timestamp > NOW() - make_interval(secs => $1)
+			  AND ($2 = '' OR server_id = $2)
+			GROUP BY server_id
+		)
+		SELECT t.server_id, COALESCE(NULLIF(s.hostname, ''), s.name, ''),
+		       t.samples, t.first_at, t.latest_at,
+		       t.disk_slope, t.disk_r2, t.disk_available, t.disk_total,
+		       t.memory_slope, t.memory_r2, t.memory_available, t.memory_total,
+		       t.swap_slope, t.swap_r2, t.swap_available, t.swap_total
+		FROM trend t
+		LEFT JOIN admiral.servers s ON s.server_id = t.server_id
+	`
+
+	rows, err := q.QueryContext(ctx, query, lookback.Seconds(), serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric trends: %w", err)
+	}
+	defer rows.Close()
+
+	forecasts := []Forecast{}
+	for rows.Next() {
+		var server, hostname string
+		var samples int
+		var firstAt, latestAt time.Time
+		var fits [3]struct {
+			slope, r2        sql.NullFloat64
+			available, total sql.NullInt64
+		}
+		if err := rows.Scan(&server, &hostname, &samples, &firstAt, &latestAt,
+			&fits[0].slope, &fits[0].r2, &fits[0].available, &fits[0].total,
+			&fits[1].slope, &fits[1].r2, &fits[1].available, &fits[1].total,
+			&fits[2].slope, &fits[2].r2, &fits[2].available, &fits[2].total,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan metric trend: %w", err)
+		}
+
+		if samples < minSamples || latestAt.Sub(firstAt) < minSpan {
+			continue
+		}
+
+		for i, resource := range Resources {
+			fit := fits[i]
+			if !fit.slope.Valid || !fit.available.Valid || !fit.total.Valid || fit.total.Int64 <= 0 {
+				continue // No data, or no swap configured
+			}
+
+			f := Forecast{
+				ServerID:          server,
+				Hostname:          hostname,
+				Resource:          resource,
+				TotalBytes:        fit.total.Int64,
+				AvailableBytes:    fit.available.Int64,
+				SlopeBytesPerHour: fit.slope.Float64 * 3600,
+				Samples:           samples,
+				LatestAt:          latestAt,
+			}
+			if fit.r2.Valid {
+				r2 := math.Round(fit.r2.Float64*1000) / 1000
+				f.R2 = &r2
+			}
+			f.project()
+			forecasts = append(forecasts, f)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Soonest exhaustion first
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		return forecasts[i].Hours() < forecasts[j].Hours()
+	})
+	return forecasts, nil
+}
+
+// project extrapolates the trend from the latest sample to zero available bytes
+func (f *Forecast) project() {
+	if f.SlopeBytesPerHour >= 0 {
+		return
+	}
+
+	hours := float64(f.AvailableBytes) / -f.SlopeBytesPerHour
+	if f.AvailableBytes <= 0 {
+		hours = 0
+	}
+	if hours >= MaxHours {
+		return
+	}
+
+	hours = math.Round(hours*100) / 100
+	fullAt := f.LatestAt.Add(time.Duration(hours * float64(time.Hour)))
+	f.HoursUntilFull = &hours
+	f.PredictedFullAt = &fullAt
+}
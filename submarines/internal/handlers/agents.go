@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nodepulse/admiral/submarines/internal/validation"
+)
+
+// requireKnownServer reads the server_id query parameter of an agent request and checks
+// it belongs to a registered server (with Valkey caching)
+// On failure the error response is written and false is returned
+// kind names what the agent sent, for log messages
+func requireKnownServer(c *gin.Context, validator *validation.ServerIDValidator, kind string) (string, bool) {
+	serverIDStr := c.Query("server_id")
+	if serverIDStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "server_id query parameter is required"})
+		return "", false
+	}
+
+	// Validate server_id is a valid UUID
+	serverID, err := uuid.Parse(serverIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid server_id format: %v", err),
+		})
+		return "", false
+	}
+
+	exists, err := validator.ValidateServerID(c.Request.Context(), serverID.String())
+	if err != nil {
+		log.Printf("ERROR: Server ID validation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server validation failed"})
+		return "", false
+	}
+
+	if !exists {
+		log.Printf("WARN: Rejected %s from unknown server_id: %s", kind, serverID.String())
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "unknown server_id",
+			"detail": "server not found in database",
+		})
+		return "", false
+	}
+
+	return serverID.String(), true
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nodepulse/admiral/submarines/internal/alerting"
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// AlertHandler exposes alert listing, acknowledgment and resolution
+type AlertHandler struct {
+	db *sql.DB
+}
+
+// NewAlertHandler creates a new alert handler instance
+func NewAlertHandler(db *sql.DB) *AlertHandler {
+	return &AlertHandler{
+		db: db,
+	}
+}
+
+// AlertActionRequest represents an acknowledge/unacknowledge/resolve request
+type AlertActionRequest struct {
+	UserID  *int64 `json:"user_id" binding:"required"` // admiral.users.id
+	Comment string `json:"comment"`
+}
+
+// ListAlerts lists alerts with optional filters
+// GET /internal/alerts?status=active,acknowledged&severity=critical&server_id=&rule_id=&alert_type=&since=&until=&limit=100&offset=0
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	filter := alerting.AlertFilter{
+		Severity:  c.Query("severity"),
+		AlertType: c.Query("alert_type"),
+	}
+
+	if status := c.Query("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	var err error
+	if filter.ServerID, err = parseUUIDParam(c.Query("server_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid server_id parameter"})
+		return
+	}
+	if filter.RuleID, err = parseUUIDParam(c.Query("rule_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule_id parameter"})
+		return
+	}
+	if filter.Since, err = parseTimeParam(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter (RFC 3339 expected)"})
+		return
+	}
+	if filter.Until, err = parseTimeParam(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until parameter (RFC 3339 expected)"})
+		return
+	}
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "100")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	if filter.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return
+	}
+
+	alerts, total, err := alerting.ListAlerts(c.Request.Context(), h.db, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": alerts,
+		"count":  len(alerts),
+		"total":  total,
+	})
+}
+
+// GetAlert returns an alert with its timeline
+// GET /internal/alerts/:id
+func (h *AlertHandler) GetAlert(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	alertID, ok := alertIDParam(c)
+	if !ok {
+		return
+	}
+
+	alert, err := alerting.GetAlert(ctx, h.db, alertID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	timeline, err := alerting.Timeline(ctx, h.db, alert.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alert":    alert,
+		"timeline": timeline,
+	})
+}
+
+// GetAlertStats returns MTTA/MTTR per rule
+// GET /internal/alerts/stats?days=30
+func (h *AlertHandler) GetAlertStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days parameter"})
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	stats, err := alerting.ResponseStats(c.Request.Context(), h.db, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since": since.UTC().Format(time.RFC3339),
+		"rules": stats,
+	})
+}
+
+// AcknowledgeAlert acknowledges an active alert
+// POST /internal/alerts/:id/acknowledge
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	h.applyAction(c, alerting.Acknowledge)
+}
+
+// UnacknowledgeAlert returns an acknowledged alert to active
+// POST /internal/alerts/:id/unacknowledge
+func (h *AlertHandler) UnacknowledgeAlert(c *gin.Context) {
+	h.applyAction(c, alerting.Unacknowledge)
+}
+
+// ResolveAlert manually resolves an active or acknowledged alert
+// POST /internal/alerts/:id/resolve
+func (h *AlertHandler) ResolveAlert(c *gin.Context) {
+	h.applyAction(c, alerting.Resolve)
+}
+
+type alertAction func(ctx context.Context, db *sql.DB, alertID string, userID *int64, comment string) (*models.Alert, *alerting.Event, error)
+
+func (h *AlertHandler) applyAction(c *gin.Context, action alertAction) {
+	alertID, ok := alertIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AlertActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The transition's notification event is written to the alert event outbox
+	alert, _, err := action(c.Request.Context(), h.db, alertID, req.UserID, strings.TrimSpace(req.Comment))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alert": alert})
+}
+
+func (h *AlertHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, alerting.ErrAlertNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, alerting.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// alertIDParam validates the :id path parameter (writes 400 on failure)
+func alertIDParam(c *gin.Context) (string, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alert id"})
+		return "", false
+	}
+	return id.String(), true
+}
+
+// parseUUIDParam normalizes an optional UUID query parameter
+func parseUUIDParam(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
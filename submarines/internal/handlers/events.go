@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nodepulse/admiral/submarines/internal/events"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+	"github.com/nodepulse/admiral/submarines/internal/validation"
+)
+
+const (
+	EventsStreamKey       = "nodepulse:events:stream"
+	MaxEventStreamBacklog = 20000 // Reject new events if stream has more than this many pending
+	MaxEventBatchSize     = 500   // Events accepted per request
+)
+
+// AgentEvent is a discrete host event reported by an agent
+// Agent sends a flat array of these: [AgentEvent, AgentEvent, ...]
+type AgentEvent struct {
+	ID        string          `json:"id"`        // Optional agent-generated ID, deduplicates re-sent events
+	Type      string          `json:"type"`      // reboot, package_upgrade, oom_kill, ... (see events.AgentTypes)
+	Timestamp time.Time       `json:"timestamp"` // When it happened (agent clock)
+	Message   string          `json:"message"`   // e.g. "openssl upgraded from 3.0.2 to 3.0.13"
+	Data      json.RawMessage `json:"data"`      // Type-specific JSON object, e.g. {"package": "openssl", "from": "3.0.2", "to": "3.0.13"}
+}
+
+// EventHandler accepts agent events and exposes the server event timeline
+type EventHandler struct {
+	db        *sql.DB
+	queue     queue.Queue
+	validator *validation.ServerIDValidator
+}
+
+// NewEventHandler creates a new event handler instance
+func NewEventHandler(db *sql.DB, q queue.Queue, validator *validation.ServerIDValidator) *EventHandler {
+	return &EventHandler{
+		db:        db,
+		queue:     q,
+		validator: validator,
+	}
+}
+
+// IngestEvents queues agent events for the digest workers
+// POST /events?server_id=<uuid>
+// Content-Type: application/json
+// Body: [AgentEvent, ...]
+func (h *EventHandler) IngestEvents(c *gin.Context) {
+	serverID, ok := requireKnownServer(c, h.validator, "events")
+	if !ok {
+		return
+	}
+
+	// Check stream backpressure BEFORE processing
+	streamLen, err := h.queue.XLen(c.Request.Context(), EventsStreamKey)
+	if err != nil {
+		log.Printf("ERROR: Failed to check events stream length: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check stream status"})
+		return
+	}
+
+	if streamLen > MaxEventStreamBacklog {
+		log.Printf("WARN: Events stream backlogged (%d pending), rejecting new events", streamLen)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "events stream is backlogged",
+			"pending": streamLen,
+			"retry":   "retry after a few seconds",
+		})
+		return
+	}
+
+	rawPayload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to read request body: %v", err),
+		})
+		return
+	}
+
+	var batch []AgentEvent
+	if err := json.Unmarshal(rawPayload, &batch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid event batch (JSON array of events expected): %v", err),
+		})
+		return
+	}
+	if len(batch) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event batch is empty"})
+		return
+	}
+	if len(batch) > MaxEventBatchSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("event batch has %d events (max %d)", len(batch), MaxEventBatchSize),
+		})
+		return
+	}
+	for i, event := range batch {
+		if !events.IsAgentType(event.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("event %d: unsupported type %q (supported: %s)", i, event.Type, strings.Join(events.AgentTypes, ", ")),
+			})
+			return
+		}
+		if data := bytes.TrimSpace(event.Data); len(data) > 0 && data[0] != '{' && string(data) != "null" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("event %d: data must be a JSON object", i),
+			})
+			return
+		}
+	}
+
+	fields := map[string]string{
+		"server_id": serverID,
+		"payload":   string(rawPayload),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	tracing.Inject(tracing.ExtractHeaders(c.Request.Context(), c.Request.Header), fields)
+
+	messageID, err := h.queue.XAdd(c.Request.Context(), EventsStreamKey, fields)
+	if err != nil {
+		log.Printf("ERROR: Failed to publish events to stream: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "queued",
+		"server_id":  serverID,
+		"events":     len(batch),
+		"message_id": messageID,
+	})
+}
+
+// ListEvents returns the event timeline of a server (or the whole fleet), newest first
+// Includes agent events as well as status changes and systemd unit transitions
+// GET /internal/events?server_id=&type=reboot,package_upgrade&source=agent&since=&until=&limit=100&offset=0
+func (h *EventHandler) ListEvents(c *gin.Context) {
+	filter := events.Filter{
+		ServerID: c.Query("server_id"),
+		Source:   c.Query("source"),
+	}
+
+	if types := c.Query("type"); types != "" {
+		filter.Types = strings.Split(types, ",")
+	}
+	if filter.Source != "" && filter.Source != events.SourceAgent && filter.Source != events.SourceAdmiral {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid source parameter (agent or admiral)"})
+		return
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter (RFC 3339 expected)"})
+		return
+	}
+	if filter.Until, err = parseTimeParam(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until parameter (RFC 3339 expected)"})
+		return
+	}
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "100")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+	if filter.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+		return
+	}
+
+	timeline, total, err := events.List(c.Request.Context(), h.db, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": timeline,
+		"count":  len(timeline),
+		"total":  total,
+	})
+}
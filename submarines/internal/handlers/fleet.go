@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nodepulse/admiral/submarines/internal/fleet"
+)
+
+// maxFleetWindow bounds how much history a fleet query may scan
+const maxFleetWindow = 7 * 24 * time.Hour
+
+// FleetHandler exposes fleet-wide top-N and aggregate queries
+type FleetHandler struct {
+	db *sql.DB
+}
+
+// NewFleetHandler creates a new fleet handler instance
+func NewFleetHandler(db *sql.DB) *FleetHandler {
+	return &FleetHandler{db: db}
+}
+
+// TopServers ranks servers by a metric
+// GET /internal/fleet/top?metric=cpu_usage&window=1h&limit=10&order=desc&tags=web,eu
+func (h *FleetHandler) TopServers(c *gin.Context) {
+	filter, ok := fleetFilter(c, time.Hour)
+	if !ok {
+		return
+	}
+	metric, ok := fleetMetric(c)
+	if !ok {
+		return
+	}
+	limit, ok := fleetLimit(c)
+	if !ok {
+		return
+	}
+
+	order := c.DefaultQuery("order", "desc")
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order parameter (asc or desc expected)"})
+		return
+	}
+
+	servers, err := fleet.Top(c.Request.Context(), h.db, filter, metric, limit, order == "asc")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metric":  metric,
+		"window":  filter.Window.String(),
+		"servers": servers,
+		"count":   len(servers),
+	})
+}
+
+// FilterServers lists servers whose metric matches a condition, highest first
+// GET /internal/fleet/servers?metric=memory_usage&condition=gt&threshold=90&window=5m&tags=
+func (h *FleetHandler) FilterServers(c *gin.Context) {
+	filter, ok := fleetFilter(c, 5*time.Minute)
+	if !ok {
+		return
+	}
+	metric, ok := fleetMetric(c)
+	if !ok {
+		return
+	}
+
+	condition := c.DefaultQuery("condition", "gt")
+	switch condition {
+	case "gt", "gte", "lt", "lte":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid condition parameter (gt, gte, lt or lte expected)"})
+		return
+	}
+
+	threshold, err := strconv.ParseFloat(c.Query("threshold"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold parameter is required"})
+		return
+	}
+
+	servers, err := fleet.Matching(c.Request.Context(), h.db, filter, metric, condition, threshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metric":    metric,
+		"condition": condition,
+		"threshold": threshold,
+		"window":    filter.Window.String(),
+		"servers":   servers,
+		"count":     len(servers),
+	})
+}
+
+// AggregateMetric returns avg/min/max of a metric per tag or over the whole fleet
+// GET /internal/fleet/aggregate?metric=load_1min&group_by=tag&window=1h&tags=
+func (h *FleetHandler) AggregateMetric(c *gin.Context) {
+	filter, ok := fleetFilter(c, time.Hour)
+	if !ok {
+		return
+	}
+	metric, ok := fleetMetric(c)
+	if !ok {
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", "tag")
+	if groupBy != "tag" && groupBy != "none" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_by parameter (tag or none expected)"})
+		return
+	}
+
+	groups, err := fleet.Aggregate(c.Request.Context(), h.db, filter, metric, groupBy == "tag")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metric":   metric,
+		"group_by": groupBy,
+		"window":   filter.Window.String(),
+		"groups":   groups,
+	})
+}
+
+// TopProcesses ranks process groups across the fleet
+// GET /internal/fleet/processes/top?by=memory&window=15m&limit=10&tags=
+func (h *FleetHandler) TopProcesses(c *gin.Context) {
+	filter, ok := fleetFilter(c, 15*time.Minute)
+	if !ok {
+		return
+	}
+	limit, ok := fleetLimit(c)
+	if !ok {
+		return
+	}
+
+	by := c.DefaultQuery("by", fleet.ByMemory)
+	if by != fleet.ByMemory && by != fleet.ByCPU {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid by parameter (memory or cpu expected)"})
+		return
+	}
+
+	processes, err := fleet.TopProcesses(c.Request.Context(), h.db, filter, by, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"by":        by,
+		"window":    filter.Window.String(),
+		"processes": processes,
+		"count":     len(processes),
+	})
+}
+
+// fleetFilter parses the window and tags parameters shared by fleet queries
+func fleetFilter(c *gin.Context, defaultWindow time.Duration) (fleet.Filter, bool) {
+	filter := fleet.Filter{Window: defaultWindow}
+
+	if value := c.Query("window"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 || d > maxFleetWindow {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window parameter (duration up to 168h expected, e.g. 1h)"})
+			return filter, false
+		}
+		filter.Window = d
+	}
+
+	if tags := c.Query("tags"); tags != "" {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+
+	return filter, true
+}
+
+func fleetMetric(c *gin.Context) (string, bool) {
+	metric := c.Query("metric")
+	if !fleet.IsMetric(metric) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid metric parameter (one of " + strings.Join(fleet.Metrics, ", ") + ")"})
+		return "", false
+	}
+	return metric, true
+}
+
+func fleetLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter (1-1000)"})
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nodepulse/admiral/submarines/internal/forecast"
+)
+
+// ForecastHandler exposes resource exhaustion forecasts
+type ForecastHandler struct {
+	db *sql.DB
+}
+
+// NewForecastHandler creates a new forecast handler instance
+func NewForecastHandler(db *sql.DB) *ForecastHandler {
+	return &ForecastHandler{db: db}
+}
+
+// ListForecasts returns predicted time until full per server and resource, soonest first
+// GET /internal/forecasts?server_id=&resource=disk&lookback=72h&within_hours=72
+func (h *ForecastHandler) ListForecasts(c *gin.Context) {
+	lookback := forecast.DefaultLookback
+	if value := c.Query("lookback"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lookback parameter (duration of at least 1h expected, e.g. 72h)"})
+			return
+		}
+		lookback = d
+	}
+
+	resource := c.Query("resource")
+	if resource != "" && !slices.Contains(forecast.Resources, resource) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource parameter (disk, memory or swap expected)"})
+		return
+	}
+
+	within := 0.0
+	if value := c.Query("within_hours"); value != "" {
+		var err error
+		if within, err = strconv.ParseFloat(value, 64); err != nil || within <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid within_hours parameter"})
+			return
+		}
+	}
+
+	forecasts, err := forecast.Compute(c.Request.Context(), h.db, lookback, c.Query("server_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filtered := []forecast.Forecast{}
+	for _, f := range forecasts {
+		if resource != "" && f.Resource != resource {
+			continue
+		}
+		if within > 0 && (f.HoursUntilFull == nil || *f.HoursUntilFull > within) {
+			continue
+		}
+		filtered = append(filtered, f)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lookback":  lookback.String(),
+		"forecasts": filtered,
+		"count":     len(filtered),
+	})
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nodepulse/admiral/submarines/internal/logs"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+	"github.com/nodepulse/admiral/submarines/internal/validation"
+)
+
+const (
+	LogsStreamKey       = "nodepulse:logs:stream"
+	MaxLogStreamBacklog = 20000 // Reject new log batches if stream has more than this many pending
+	MaxLogBatchLines    = 5000  // Lines accepted per request
+)
+
+// LogLine is a single journald/syslog line sent by agents
+// Agent sends a flat array of these: [LogLine, LogLine, ...]
+type LogLine struct {
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`   // journald (default) or syslog
+	Severity  *int      `json:"severity"` // Syslog priority 0-7 (journald PRIORITY)
+	Unit      string    `json:"unit"`     // systemd unit or syslog identifier
+	Hostname  string    `json:"hostname"`
+	PID       *int      `json:"pid"`
+	Message   string    `json:"message"`
+}
+
+// LogHandler accepts log batches from agents and searches stored logs
+type LogHandler struct {
+	db        *sql.DB
+	queue     queue.Queue
+	validator *validation.ServerIDValidator
+}
+
+// NewLogHandler creates a new log handler instance
+func NewLogHandler(db *sql.DB, q queue.Queue, validator *validation.ServerIDValidator) *LogHandler {
+	return &LogHandler{
+		db:        db,
+		queue:     q,
+		validator: validator,
+	}
+}
+
+// IngestLogs queues a batch of log lines for the digest workers
+// POST /logs?server_id=<uuid>
+// Content-Type: application/json
+// Body: [LogLine, ...]
+func (h *LogHandler) IngestLogs(c *gin.Context) {
+	serverID, ok := requireKnownServer(c, h.validator, "logs")
+	if !ok {
+		return
+	}
+
+	// Check stream backpressure BEFORE processing
+	streamLen, err := h.queue.XLen(c.Request.Context(), LogsStreamKey)
+	if err != nil {
+		log.Printf("ERROR: Failed to check logs stream length: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check stream status"})
+		return
+	}
+
+	if streamLen > MaxLogStreamBacklog {
+		log.Printf("WARN: Logs stream backlogged (%d pending), rejecting new logs", streamLen)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "logs stream is backlogged",
+			"pending": streamLen,
+			"retry":   "retry after a few seconds",
+		})
+		return
+	}
+
+	rawPayload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to read request body: %v", err),
+		})
+		return
+	}
+
+	// Unlike metrics, batches are checked here: a malformed batch would only
+	// be caught by digest after exhausting its retries
+	var lines []LogLine
+	if err := json.Unmarshal(rawPayload, &lines); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid log batch (JSON array of lines expected): %v", err),
+		})
+		return
+	}
+	if len(lines) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "log batch is empty"})
+		return
+	}
+	if len(lines) > MaxLogBatchLines {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("log batch has %d lines (max %d)", len(lines), MaxLogBatchLines),
+		})
+		return
+	}
+
+	fields := map[string]string{
+		"server_id": serverID,
+		"payload":   string(rawPayload),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	tracing.Inject(tracing.ExtractHeaders(c.Request.Context(), c.Request.Header), fields)
+
+	messageID, err := h.queue.XAdd(c.Request.Context(), LogsStreamKey, fields)
+	if err != nil {
+		log.Printf("ERROR: Failed to publish logs to stream: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "queued",
+		"server_id":  serverID,
+		"lines":      len(lines),
+		"message_id": messageID,
+	})
+}
+
+// SearchLogs searches stored log lines, newest first
+// GET /internal/logs?server_id=&unit=&source=&severity=warning&q=&since=&until=&limit=100
+// severity returns lines at that level or more severe; since defaults to 24 hours ago
+func (h *LogHandler) SearchLogs(c *gin.Context) {
+	filter := logs.Filter{
+		ServerID: c.Query("server_id"),
+		Unit:     c.Query("unit"),
+		Source:   c.Query("source"),
+		Query:    c.Query("q"),
+	}
+
+	if severity := c.Query("severity"); severity != "" {
+		level, err := logs.ParseSeverity(severity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.MaxSeverity = &level
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter (RFC 3339 expected)"})
+		return
+	}
+	if filter.Until, err = parseTimeParam(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until parameter (RFC 3339 expected)"})
+		return
+	}
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "100")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+
+	entries, err := logs.Search(c.Request.Context(), h.db, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":  entries,
+		"count": len(entries),
+	})
+}
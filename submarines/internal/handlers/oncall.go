@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nodepulse/admiral/submarines/internal/oncall"
+)
+
+// OnCallHandler exposes on-call schedule lookups
+type OnCallHandler struct {
+	db *sql.DB
+}
+
+// NewOnCallHandler creates a new on-call handler instance
+func NewOnCallHandler(db *sql.DB) *OnCallHandler {
+	return &OnCallHandler{db: db}
+}
+
+// GetCurrentOnCall returns who is on call for a schedule
+// GET /internal/oncall/schedules/:id/current?at=<RFC 3339>
+func (h *OnCallHandler) GetCurrentOnCall(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return
+	}
+
+	at, err := parseTimeParam(c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid at parameter (RFC 3339 expected)"})
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	shift, err := oncall.Current(c.Request.Context(), h.db, id.String(), at)
+	switch {
+	case errors.Is(err, oncall.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, oncall.ErrNobodyOnCall):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, shift)
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,13 +11,18 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/nodepulse/admiral/submarines/internal/database"
-	"github.com/nodepulse/admiral/submarines/internal/valkey"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+	"github.com/nodepulse/admiral/submarines/internal/spool"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
 	"github.com/nodepulse/admiral/submarines/internal/validation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	MetricsStreamKey = "nodepulse:metrics:stream"
-	MaxStreamBacklog = 50000 // Reject new metrics if stream has more than this many pending
+	MetricsDLQKey    = "nodepulse:metrics:dlq" // Poison messages moved aside by digest
+	MaxStreamBacklog = 50000                   // Reject new metrics if stream has more than this many pending
 )
 
 // MetricSnapshot represents a parsed snapshot of all essential metrics
@@ -80,6 +87,46 @@ type MetricSnapshot struct {
 
 	// System Uptime
 	UptimeSeconds int64 `json:"uptime_seconds"`
+
+	// Per-device breakdowns (optional, older agents only send the totals above)
+	Filesystems       []FilesystemSnapshot       `json:"filesystems,omitempty"`
+	NetworkInterfaces []NetworkInterfaceSnapshot `json:"network_interfaces,omitempty"`
+	BlockDevices      []BlockDeviceSnapshot      `json:"block_devices,omitempty"`
+}
+
+// FilesystemSnapshot is the usage of one mounted filesystem (bytes and inodes)
+type FilesystemSnapshot struct {
+	Mountpoint     string `json:"mountpoint"` // e.g. /var/lib/postgresql
+	Device         string `json:"device"`     // e.g. /dev/nvme1n1p1
+	FSType         string `json:"fstype"`     // e.g. ext4
+	TotalBytes     int64  `json:"total_bytes"`
+	FreeBytes      int64  `json:"free_bytes"`
+	AvailableBytes int64  `json:"available_bytes"`
+	FilesTotal     int64  `json:"files_total"`
+	FilesFree      int64  `json:"files_free"`
+}
+
+// NetworkInterfaceSnapshot holds the counters of one network interface
+type NetworkInterfaceSnapshot struct {
+	Interface            string `json:"interface"` // e.g. eth0
+	ReceiveBytesTotal    int64  `json:"receive_bytes_total"`
+	TransmitBytesTotal   int64  `json:"transmit_bytes_total"`
+	ReceivePacketsTotal  int64  `json:"receive_packets_total"`
+	TransmitPacketsTotal int64  `json:"transmit_packets_total"`
+	ReceiveErrsTotal     int64  `json:"receive_errs_total"`
+	TransmitErrsTotal    int64  `json:"transmit_errs_total"`
+	ReceiveDropTotal     int64  `json:"receive_drop_total"`
+	TransmitDropTotal    int64  `json:"transmit_drop_total"`
+}
+
+// BlockDeviceSnapshot holds the I/O counters of one block device
+type BlockDeviceSnapshot struct {
+	Device               string  `json:"device"` // e.g. nvme0n1
+	ReadsCompletedTotal  int64   `json:"reads_completed_total"`
+	WritesCompletedTotal int64   `json:"writes_completed_total"`
+	ReadBytesTotal       int64   `json:"read_bytes_total"`
+	WrittenBytesTotal    int64   `json:"written_bytes_total"`
+	IOTimeSecondsTotal   float64 `json:"io_time_seconds_total"`
 }
 
 // ProcessSnapshot represents a single process group metric snapshot from process_exporter
@@ -94,15 +141,19 @@ type ProcessSnapshot struct {
 
 type PrometheusHandler struct {
 	db        *database.DB
-	valkey    *valkey.Client
+	queue     queue.Queue
 	validator *validation.ServerIDValidator
+	spool     *spool.Spool // nil when spooling is disabled
 }
 
-func NewPrometheusHandler(db *database.DB, valkeyClient *valkey.Client, validator *validation.ServerIDValidator) *PrometheusHandler {
+// NewPrometheusHandler creates the metrics ingest handler
+// With a spool, payloads that can't be published to the queue are buffered on disk instead of rejected
+func NewPrometheusHandler(db *database.DB, q queue.Queue, validator *validation.ServerIDValidator, sp *spool.Spool) *PrometheusHandler {
 	return &PrometheusHandler{
 		db:        db,
-		valkey:    valkeyClient,
+		queue:     q,
 		validator: validator,
+		spool:     sp,
 	}
 }
 
@@ -124,7 +175,17 @@ type MetricSnapshotPayload struct {
 // 1. Validates server_id and checks stream backlog
 // 2. Pushes raw payload to Valkey Stream (NO parsing)
 // 3. Digest workers handle parsing and database writes
+//
+// A traceparent header from the agent is continued; the trace context is carried
+// to digest in the stream message fields
+//
+// When the queue is unavailable the payload goes to the on-disk spool (if enabled) and is
+// published later by ReplaySpooled; while the spool holds messages, new ones queue behind them
 func (h *PrometheusHandler) IngestPrometheusMetrics(c *gin.Context) {
+	ctx, span := tracing.Tracer().Start(tracing.ExtractHeaders(c.Request.Context(), c.Request.Header),
+		"IngestPrometheusMetrics", trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
 	// Get server_id from query parameter
 	serverIDStr := c.Query("server_id")
 	if serverIDStr == "" {
@@ -159,8 +220,10 @@ func (h *PrometheusHandler) IngestPrometheusMetrics(c *gin.Context) {
 	}
 
 	// Check stream backpressure BEFORE processing
-	streamLen, err := h.valkey.StreamLength(MetricsStreamKey)
-	if err != nil {
+	// An unreachable queue isn't backpressure: with a spool the payload is buffered below
+	streamLen, err := h.queue.XLen(ctx, MetricsStreamKey)
+	queueDown := err != nil
+	if queueDown && h.spool == nil {
 		log.Printf("ERROR: Failed to check stream length: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check stream status"})
 		return
@@ -185,20 +248,40 @@ func (h *PrometheusHandler) IngestPrometheusMetrics(c *gin.Context) {
 		return
 	}
 
-	// Push to stream as-is with server_id and timestamp
-	messageID, err := h.valkey.PublishToStream(MetricsStreamKey, map[string]string{
+	// Push to stream as-is with server_id, timestamp and trace context
+	fields := map[string]string{
 		"server_id": serverID.String(),
 		"payload":   string(rawPayload),
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
+	}
+	tracing.Inject(ctx, fields)
+
+	span.SetAttributes(
+		attribute.String("server_id", serverID.String()),
+		attribute.Int("payload_bytes", len(rawPayload)),
+	)
+
+	if queueDown || (h.spool != nil && h.spool.Depth() > 0) {
+		h.spoolPayload(ctx, c, span, serverID.String(), fields)
+		return
+	}
+
+	messageID, err := h.queue.XAdd(ctx, MetricsStreamKey, fields)
+	if err != nil && h.spool != nil {
+		log.Printf("WARN: Failed to publish to stream, spooling: %v (trace_id=%s)", err, tracing.TraceID(ctx))
+		h.spoolPayload(ctx, c, span, serverID.String(), fields)
+		return
+	}
 	if err != nil {
-		log.Printf("ERROR: Failed to publish to stream: %v", err)
+		tracing.End(span, err)
+		log.Printf("ERROR: Failed to publish to stream: %v (trace_id=%s)", err, tracing.TraceID(ctx))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue metrics"})
 		return
 	}
+	span.SetAttributes(attribute.String("messaging.message.id", messageID))
 
-	log.Printf("INFO: Queued payload from server_id=%s (size=%d bytes, message_id=%s)",
-		serverID.String(), len(rawPayload), messageID)
+	log.Printf("INFO: Queued payload from server_id=%s (size=%d bytes, message_id=%s, trace_id=%s)",
+		serverID.String(), len(rawPayload), messageID, tracing.TraceID(ctx))
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":     "queued",
@@ -207,15 +290,62 @@ func (h *PrometheusHandler) IngestPrometheusMetrics(c *gin.Context) {
 	})
 }
 
+// spoolPayload buffers a stream message on disk for ReplaySpooled
+// The agent gets a success response: the spool now owns the payload
+func (h *PrometheusHandler) spoolPayload(ctx context.Context, c *gin.Context, span trace.Span, serverID string, fields map[string]string) {
+	if err := h.spool.Append(MetricsStreamKey, fields); err != nil {
+		tracing.End(span, err)
+		if errors.Is(err, spool.ErrFull) {
+			log.Printf("WARN: Spool is full, rejecting metrics from server_id=%s (trace_id=%s)", serverID, tracing.TraceID(ctx))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "metrics queue unavailable and spool is full",
+				"retry": "retry after a few seconds",
+			})
+			return
+		}
+		log.Printf("ERROR: Failed to spool metrics: %v (trace_id=%s)", err, tracing.TraceID(ctx))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue metrics"})
+		return
+	}
+	span.SetAttributes(attribute.Bool("spooled", true))
+
+	log.Printf("INFO: Spooled payload from server_id=%s (spool depth=%d, trace_id=%s)",
+		serverID, h.spool.Depth(), tracing.TraceID(ctx))
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "spooled",
+		"server_id": serverID,
+	})
+}
+
+// ReplaySpooled publishes one spooled message to its stream (used by spool.Run)
+// Backpressure applies as for live traffic: replay pauses while the stream is backlogged
+func (h *PrometheusHandler) ReplaySpooled(ctx context.Context, rec spool.Record) error {
+	streamLen, err := h.queue.XLen(ctx, rec.Stream)
+	if err != nil {
+		return err
+	}
+	if streamLen > MaxStreamBacklog {
+		return fmt.Errorf("stream %s is backlogged (%d pending)", rec.Stream, streamLen)
+	}
+
+	_, err = h.queue.XAdd(ctx, rec.Stream, rec.Fields)
+	return err
+}
+
 // Health check endpoint for Prometheus metrics ingestion
 func (h *PrometheusHandler) HealthCheck(c *gin.Context) {
 	// Check Valkey stream health
-	streamLen, err := h.valkey.StreamLength(MetricsStreamKey)
+	streamLen, err := h.queue.XLen(c.Request.Context(), MetricsStreamKey)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
+		body := gin.H{
 			"status": "unhealthy",
-			"error":  "valkey stream unavailable",
-		})
+			"error":  "metrics stream unavailable",
+		}
+		if h.spool != nil {
+			body["spool"] = h.spool.Stats()
+		}
+		c.JSON(http.StatusServiceUnavailable, body)
 		return
 	}
 
@@ -224,10 +354,20 @@ func (h *PrometheusHandler) HealthCheck(c *gin.Context) {
 		status = "degraded"
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"status":         status,
 		"stream_pending": streamLen,
 		"max_backlog":    MaxStreamBacklog,
 		"format":         "prometheus",
-	})
+	}
+	if h.spool != nil {
+		stats := h.spool.Stats()
+		if stats.Records > 0 {
+			status = "degraded" // Still replaying buffered payloads
+			body["status"] = status
+		}
+		body["spool"] = stats
+	}
+
+	c.JSON(http.StatusOK, body)
 }
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nodepulse/admiral/submarines/internal/alerting"
+	"github.com/nodepulse/admiral/submarines/internal/models"
+)
+
+// SilenceHandler exposes alert silences and maintenance windows
+type SilenceHandler struct {
+	db *sql.DB
+}
+
+// NewSilenceHandler creates a new silence handler instance
+func NewSilenceHandler(db *sql.DB) *SilenceHandler {
+	return &SilenceHandler{db: db}
+}
+
+// SilenceMatchers select the alerts a silence or maintenance window applies to
+// All set matchers must match; none set matches every alert
+type SilenceMatchers struct {
+	ServerID  string `json:"server_id"` // admiral.servers.id or agent server_id
+	Tag       string `json:"tag"`
+	AlertType string `json:"alert_type"` // Rule metric_type
+	RuleName  string `json:"rule_name"`
+}
+
+// CreateSilenceRequest represents a new one-off silence
+// Either ends_at or duration_minutes is required; starts_at defaults to now
+type CreateSilenceRequest struct {
+	SilenceMatchers
+	Action          string     `json:"action"` // suppress (default), downgrade
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Comment         string     `json:"comment"`
+	UserID          *int64     `json:"user_id"` // admiral.users.id
+}
+
+// CreateMaintenanceWindowRequest represents a new recurring maintenance window
+type CreateMaintenanceWindowRequest struct {
+	SilenceMatchers
+	Name       string `json:"name" binding:"required"`
+	Action     string `json:"action"`                        // suppress (default), downgrade
+	DaysOfWeek []int  `json:"days_of_week"`                  // 0 = Sunday, empty = every day
+	StartTime  string `json:"start_time" binding:"required"` // HH:MM or HH:MM:SS
+	EndTime    string `json:"end_time" binding:"required"`   // <= start_time = ends the next day
+	Timezone   string `json:"timezone"`                      // IANA name, default UTC
+	Enabled    *bool  `json:"enabled"`                       // Default true
+	Comment    string `json:"comment"`
+	UserID     *int64 `json:"user_id"` // admiral.users.id
+}
+
+// UpdateMaintenanceWindowRequest enables or disables a maintenance window
+type UpdateMaintenanceWindowRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// ListSilences lists silences that haven't ended (all=true includes expired ones)
+// GET /internal/silences?all=false
+func (h *SilenceHandler) ListSilences(c *gin.Context) {
+	silences, err := alerting.ListSilences(c.Request.Context(), h.db, c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"silences": silences,
+		"count":    len(silences),
+	})
+}
+
+// CreateSilence creates a one-off silence
+// POST /internal/silences
+func (h *SilenceHandler) CreateSilence(c *gin.Context) {
+	var req CreateSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action, ok := silenceAction(c, req.Action)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+
+	var endsAt time.Time
+	switch {
+	case req.EndsAt != nil:
+		endsAt = *req.EndsAt
+	case req.DurationMinutes > 0:
+		endsAt = startsAt.Add(time.Duration(req.DurationMinutes) * time.Minute)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at or duration_minutes is required"})
+		return
+	}
+	if !endsAt.After(startsAt) || !endsAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at and in the future"})
+		return
+	}
+
+	silence, err := alerting.CreateSilence(c.Request.Context(), h.db, &models.AlertSilence{
+		ServerID:  optionalString(req.ServerID),
+		Tag:       optionalString(req.Tag),
+		AlertType: optionalString(req.AlertType),
+		RuleName:  optionalString(req.RuleName),
+		Action:    action,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Comment:   optionalString(req.Comment),
+		CreatedBy: req.UserID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"silence": silence})
+}
+
+// ExpireSilence ends a silence now (a silence that hasn't started is removed)
+// DELETE /internal/silences/:id
+func (h *SilenceHandler) ExpireSilence(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid silence id"})
+		return
+	}
+
+	err = alerting.ExpireSilence(c.Request.Context(), h.db, id.String())
+	switch {
+	case errors.Is(err, alerting.ErrSilenceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"expired": id.String()})
+	}
+}
+
+// ListMaintenanceWindows lists all maintenance windows
+// GET /internal/maintenance-windows
+func (h *SilenceHandler) ListMaintenanceWindows(c *gin.Context) {
+	windows, err := alerting.ListMaintenanceWindows(c.Request.Context(), h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"maintenance_windows": windows,
+		"count":               len(windows),
+	})
+}
+
+// CreateMaintenanceWindow creates a recurring maintenance window
+// POST /internal/maintenance-windows
+func (h *SilenceHandler) CreateMaintenanceWindow(c *gin.Context) {
+	var req CreateMaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action, ok := silenceAction(c, req.Action)
+	if !ok {
+		return
+	}
+
+	startTime, err := parseClockTime(req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_time (HH:MM expected)"})
+		return
+	}
+	endTime, err := parseClockTime(req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_time (HH:MM expected)"})
+		return
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown timezone"})
+		return
+	}
+
+	days := []int{}
+	for _, day := range req.DaysOfWeek {
+		if day < 0 || day > 6 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days_of_week must be between 0 (Sunday) and 6 (Saturday)"})
+			return
+		}
+		days = append(days, day)
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	window, err := alerting.CreateMaintenanceWindow(c.Request.Context(), h.db, &models.MaintenanceWindow{
+		Name:       strings.TrimSpace(req.Name),
+		ServerID:   optionalString(req.ServerID),
+		Tag:        optionalString(req.Tag),
+		AlertType:  optionalString(req.AlertType),
+		RuleName:   optionalString(req.RuleName),
+		Action:     action,
+		DaysOfWeek: days,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Timezone:   timezone,
+		Enabled:    enabled,
+		Comment:    optionalString(req.Comment),
+		CreatedBy:  req.UserID,
+	})
+	switch {
+	case errors.Is(err, alerting.ErrMaintenanceWindowExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusCreated, gin.H{"maintenance_window": window})
+	}
+}
+
+// UpdateMaintenanceWindow enables or disables a maintenance window
+// PATCH /internal/maintenance-windows/:id
+func (h *SilenceHandler) UpdateMaintenanceWindow(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance window id"})
+		return
+	}
+
+	var req UpdateMaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := alerting.SetMaintenanceWindowEnabled(c.Request.Context(), h.db, id.String(), *req.Enabled)
+	switch {
+	case errors.Is(err, alerting.ErrMaintenanceWindowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"maintenance_window": window})
+	}
+}
+
+// DeleteMaintenanceWindow removes a maintenance window
+// DELETE /internal/maintenance-windows/:id
+func (h *SilenceHandler) DeleteMaintenanceWindow(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance window id"})
+		return
+	}
+
+	err = alerting.DeleteMaintenanceWindow(c.Request.Context(), h.db, id.String())
+	switch {
+	case errors.Is(err, alerting.ErrMaintenanceWindowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"deleted": id.String()})
+	}
+}
+
+// silenceAction validates a silence action, defaulting to suppress (writes 400 on failure)
+func silenceAction(c *gin.Context, action string) (string, bool) {
+	switch action {
+	case "":
+		return "suppress", true
+	case "suppress", "downgrade":
+		return action, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action (suppress or downgrade expected)"})
+		return "", false
+	}
+}
+
+// parseClockTime normalizes HH:MM or HH:MM:SS to HH:MM:SS
+func parseClockTime(value string) (string, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("15:04:05"), nil
+		}
+	}
+	return "", errors.New("invalid time of day")
+}
+
+// optionalString maps an empty string to NULL
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
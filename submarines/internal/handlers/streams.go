@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nodepulse/admiral/submarines/internal/valkey"
+)
+
+// DeploymentsStreamKey is published by Flagship and consumed by the deployer
+const DeploymentsStreamKey = "nodepulse:deployments:stream"
+
+// ObservedStreams are reported by GET /internal/streams
+var ObservedStreams = []string{MetricsStreamKey, MetricsDLQKey, DeploymentsStreamKey}
+
+// StreamHandler exposes stream and consumer group state
+type StreamHandler struct {
+	valkey func() (*valkey.Client, error)
+}
+
+// NewStreamHandler creates a new stream handler instance
+// valkeyClient returns the client, or an error while Valkey isn't connected
+// (ingest started in spool-only mode) or isn't used (embedded queue backend)
+func NewStreamHandler(valkeyClient func() (*valkey.Client, error)) *StreamHandler {
+	return &StreamHandler{valkey: valkeyClient}
+}
+
+// ListStreams reports length, oldest/newest entry age and per-group lag and pending
+// counts of each observed stream, down to the idle time of every consumer
+// A growing lag means digest is falling behind; a consumer with pending messages
+// and a growing idle time is stuck
+// GET /internal/streams
+func (h *StreamHandler) ListStreams(c *gin.Context) {
+	client, err := h.valkey()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	streams := make([]*valkey.StreamInfo, 0, len(ObservedStreams))
+	for _, stream := range ObservedStreams {
+		info, err := client.StreamInfo(c.Request.Context(), stream)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		streams = append(streams, info)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"streams": streams})
+}
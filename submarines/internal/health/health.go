@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/nodepulse/admiral/submarines/internal/database"
-	"github.com/nodepulse/admiral/submarines/internal/valkey"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
 )
 
 // Response represents the health check response
@@ -24,7 +24,7 @@ type Check struct {
 }
 
 // Handler creates an HTTP handler for health checks
-func Handler(db *database.DB, valkeyClient *valkey.Client, version string) http.HandlerFunc {
+func Handler(db *database.DB, q queue.Queue, service, version string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
@@ -33,7 +33,7 @@ func Handler(db *database.DB, valkeyClient *valkey.Client, version string) http.
 			Status: "healthy",
 			Checks: make(map[string]Check),
 			Metadata: map[string]string{
-				"service": "digest-worker",
+				"service": service,
 				"version": version,
 			},
 		}
@@ -45,10 +45,10 @@ func Handler(db *database.DB, valkeyClient *valkey.Client, version string) http.
 			response.Status = "unhealthy"
 		}
 
-		// Check Valkey
-		valkeyCheck := checkValkey(ctx, valkeyClient)
-		response.Checks["valkey"] = valkeyCheck
-		if valkeyCheck.Status != "pass" {
+		// Check the queue backend (Valkey or embedded)
+		queueCheck := checkQueue(ctx, q)
+		response.Checks["queue"] = queueCheck
+		if queueCheck.Status != "pass" {
 			response.Status = "unhealthy"
 		}
 
@@ -76,8 +76,8 @@ func checkDatabase(ctx context.Context, db *database.DB) Check {
 	}
 }
 
-func checkValkey(ctx context.Context, valkeyClient *valkey.Client) Check {
-	if err := valkeyClient.Ping(ctx); err != nil {
+func checkQueue(ctx context.Context, q queue.Queue) Check {
+	if err := q.Ping(ctx); err != nil {
 		return Check{
 			Status:  "fail",
 			Message: err.Error(),
@@ -0,0 +1,164 @@
+package logs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of log lines
+const (
+	SourceJournald = "journald"
+	SourceSyslog   = "syslog"
+)
+
+// severityNames maps syslog priority names (and common aliases) to their level
+var severityNames = map[string]int{
+	"emerg":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"error":   3,
+	"warning": 4,
+	"warn":    4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+// severityLabels are the canonical names of syslog priorities 0-7
+var severityLabels = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// ParseSeverity accepts a syslog priority as a number (0-7) or a name (err, warning, ...)
+func ParseSeverity(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if level, ok := severityNames[value]; ok {
+		return level, nil
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 0 || level > 7 {
+		return 0, fmt.Errorf("invalid severity %q (0-7 or emerg, alert, crit, err, warning, notice, info, debug)", value)
+	}
+	return level, nil
+}
+
+// SeverityLabel returns the name of a syslog priority
+func SeverityLabel(level int) string {
+	if level < 0 || level >= len(severityLabels) {
+		return ""
+	}
+	return severityLabels[level]
+}
+
+// Entry is a stored log line
+type Entry struct {
+	ID            int64     `json:"id"`
+	ServerID      string    `json:"server_id"` // Agent server_id
+	Timestamp     time.Time `json:"timestamp"`
+	Source        string    `json:"source"`
+	Severity      *int      `json:"severity,omitempty"`
+	SeverityLabel string    `json:"severity_label,omitempty"`
+	Unit          string    `json:"unit,omitempty"`
+	Hostname      string    `json:"hostname,omitempty"`
+	PID           *int      `json:"pid,omitempty"`
+	Message       string    `json:"message"`
+}
+
+// Filter narrows a log search
+type Filter struct {
+	ServerID    string
+	Unit        string
+	Source      string
+	MaxSeverity *int   // Only lines at least this severe (lower or equal priority)
+	Query       string // Case-insensitive substring of the message
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+// DefaultSearchWindow bounds searches without a since parameter, so partitions can be pruned
+const DefaultSearchWindow = 24 * time.Hour
+
+// Search returns log lines matching a filter, newest first
+func Search(ctx context.Context, db *sql.DB, f Filter) ([]Entry, error) {
+	conditions := []string{}
+	args := []any{}
+	add := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	since := f.Since
+	if since.IsZero() {
+		since = time.Now().Add(-DefaultSearchWindow)
+	}
+	add("timestamp >= $%d", since)
+	if !f.Until.IsZero() {
+		add("timestamp < $%d", f.Until)
+	}
+	if f.ServerID != "" {
+		add("server_id = $%d", f.ServerID)
+	}
+	if f.Unit != "" {
+		add("unit = $%d", f.Unit)
+	}
+	if f.Source != "" {
+		add("source = $%d", f.Source)
+	}
+	if f.MaxSeverity != nil {
+		add("severity <= $%d", *f.MaxSeverity)
+	}
+	if f.Query != "" {
+		add(`message ILIKE $%d ESCAPE '\'`, "%"+escapeLike(f.Query)+"%")
+	}
+
+	limit := f.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT id, server_id, timestamp, source, severity, COALESCE(unit, ''), COALESCE(hostname, ''), pid, message
+		FROM admiral.logs
+		WHERE %s
+		ORDER BY timestamp DESC, id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		var severity, pid sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.ServerID, &e.Timestamp, &e.Source, &severity,
+			&e.Unit, &e.Hostname, &pid, &e.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan log entry: %w", err)
+		}
+		if severity.Valid {
+			level := int(severity.Int64)
+			e.Severity = &level
+			e.SeverityLabel = SeverityLabel(level)
+		}
+		if pid.Valid {
+			p := int(pid.Int64)
+			e.PID = &p
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// escapeLike escapes LIKE wildcards so the query matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Alert status values (admiral.alerts.status)
+const (
+	AlertStatusActive       = "active"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// AlertRule represents a row in admiral.alert_rules
+type AlertRule struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Description     *string  `json:"description,omitempty"`
+	MetricType      string   `json:"metric_type"`      // cpu_usage, memory_usage, disk_usage, ...
+	Condition       string   `json:"condition"`        // gt, lt, eq, gte, lte, anomaly
+	Threshold       float64  `json:"threshold"`        // Standard deviations for anomaly rules
+	DurationSeconds int      `json:"duration_seconds"` // Condition must persist for this long
+	Severity        string   `json:"severity"`         // info, warning, critical
+	Enabled         bool     `json:"enabled"`
+	ServerIDs       []string `json:"server_ids"`  // Empty = all servers
+	ServerTags      []string `json:"server_tags"` // Empty = all servers
+
+	// Notification grouping and flap detection
+	GroupBy              []string `json:"group_by"` // tag, server, metadata.<key> (empty = one group per rule)
+	GroupWaitSeconds     int      `json:"group_wait_seconds"`
+	GroupIntervalSeconds int      `json:"group_interval_seconds"`
+	FlapWindowSeconds    int      `json:"flap_window_seconds"`
+	FlapThreshold        int      `json:"flap_threshold"` // 0 = flap detection disabled
+
+	// Trend window of *_hours_until_full rules
+	ForecastLookbackSeconds int `json:"forecast_lookback_seconds"`
+
+	// Process group (admiral.process_snapshots.process_name) of process_* rules
+	ProcessName *string `json:"process_name,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Alert represents a row in admiral.alerts
+type Alert struct {
+	ID              string          `json:"id"`
+	ServerID        string          `json:"server_id"` // admiral.servers.id (UUID)
+	RuleID          *string         `json:"rule_id,omitempty"`
+	AlertType       string          `json:"alert_type"`
+	Severity        string          `json:"severity"`
+	Message         string          `json:"message"`
+	ThresholdValue  *float64        `json:"threshold_value,omitempty"`
+	CurrentValue    *float64        `json:"current_value,omitempty"`
+	Metadata        json.RawMessage `json:"metadata,omitempty"`
+	Status          string          `json:"status"`
+	AcknowledgedAt  *time.Time      `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy  *int64          `json:"acknowledged_by,omitempty"` // admiral.users.id
+	ResolvedAt      *time.Time      `json:"resolved_at,omitempty"`
+	ResolvedBy      *int64          `json:"resolved_by,omitempty"` // NULL = resolved by the alerter
+	LastEvaluatedAt *time.Time      `json:"last_evaluated_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// AlertTimelineEntry represents a row in admiral.alert_timeline
+type AlertTimelineEntry struct {
+	ID         int64     `json:"id"`
+	AlertID    string    `json:"alert_id"`
+	EventType  string    `json:"event_type"` // opened, acknowledged, unacknowledged, resolved
+	FromStatus *string   `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	UserID     *int64    `json:"user_id,omitempty"` // NULL = alerter
+	Comment    *string   `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AlertResponseStats summarizes response times for alerts created by one rule
+type AlertResponseStats struct {
+	RuleID       *string  `json:"rule_id,omitempty"` // NULL = alerts not created by a rule
+	RuleName     string   `json:"rule_name"`
+	Alerts       int      `json:"alerts"`
+	Acknowledged int      `json:"acknowledged"`
+	Resolved     int      `json:"resolved"`
+	MTTASeconds  *float64 `json:"mtta_seconds,omitempty"` // Mean time to first acknowledgment
+	MTTRSeconds  *float64 `json:"mttr_seconds,omitempty"` // Mean time to resolution
+}
+
+// AlertSilence represents a row in admiral.alert_silences
+type AlertSilence struct {
+	ID        string    `json:"id"`
+	ServerID  *string   `json:"server_id,omitempty"` // admiral.servers.id or agent server_id
+	Tag       *string   `json:"tag,omitempty"`
+	AlertType *string   `json:"alert_type,omitempty"` // Rule metric_type
+	RuleName  *string   `json:"rule_name,omitempty"`
+	Action    string    `json:"action"` // suppress, downgrade
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Comment   *string   `json:"comment,omitempty"`
+	CreatedBy *int64    `json:"created_by,omitempty"` // admiral.users.id
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaintenanceWindow represents a row in admiral.maintenance_windows
+type MaintenanceWindow struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	ServerID   *string   `json:"server_id,omitempty"`
+	Tag        *string   `json:"tag,omitempty"`
+	AlertType  *string   `json:"alert_type,omitempty"`
+	RuleName   *string   `json:"rule_name,omitempty"`
+	Action     string    `json:"action"`       // suppress, downgrade
+	DaysOfWeek []int     `json:"days_of_week"` // 0 = Sunday, empty = every day
+	StartTime  string    `json:"start_time"`   // HH:MM:SS in Timezone
+	EndTime    string    `json:"end_time"`     // <= StartTime = ends the next day
+	Timezone   string    `json:"timezone"`     // IANA name
+	Enabled    bool      `json:"enabled"`
+	Comment    *string   `json:"comment,omitempty"`
+	CreatedBy  *int64    `json:"created_by,omitempty"` // admiral.users.id
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,6 +24,26 @@ type Server struct {
 	UpdatedAt      time.Time              `json:"updated_at"`
 }
 
+// Server status values managed by the digest status monitor
+// Servers an admin set to inactive/error are left untouched
+const (
+	ServerStatusActive  = "active" // Default for newly registered servers
+	ServerStatusOnline  = "online"
+	ServerStatusStale   = "stale"
+	ServerStatusOffline = "offline"
+)
+
+// ServerEvent represents a row in admiral.server_events
+type ServerEvent struct {
+	ID         int64           `json:"id"`
+	ServerID   string          `json:"server_id"`
+	EventType  string          `json:"event_type"`
+	Source     string          `json:"source"`
+	Message    string          `json:"message,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
 type SystemInfo struct {
 	Hostname      string `json:"hostname"`
 	Kernel        string `json:"kernel"`
@@ -31,6 +52,8 @@ type SystemInfo struct {
 	DistroVersion string `json:"distro_version"`
 	Architecture  string `json:"architecture"`
 	CPUCores      int    `json:"cpu_cores"`
+	IPv4          string `json:"ipv4,omitempty"`
+	IPv6          string `json:"ipv6,omitempty"`
 }
 
 type CPUMetrics struct {
@@ -87,4 +110,15 @@ type MetricReport struct {
 	IPv6       *string           `json:"ipv6,omitempty"`
 }
 
- 
\ No newline at end of file
+ 
+// SystemdUnit is the state of one systemd unit from the systemd payload section
+// Agent sends a flat array of these: [SystemdUnit, SystemdUnit, ...]
+type SystemdUnit struct {
+	Timestamp      time.Time  `json:"timestamp"`
+	Name           string     `json:"name"`         // Unit name (e.g. nginx.service)
+	LoadState      string     `json:"load_state"`   // loaded, not-found, masked, ...
+	ActiveState    string     `json:"active_state"` // active, inactive, failed, ...
+	SubState       string     `json:"sub_state"`    // running, exited, dead, auto-restart, ...
+	Restarts       int        `json:"restarts"`     // NRestarts
+	StateChangedAt *time.Time `json:"state_changed_at,omitempty"`
+}
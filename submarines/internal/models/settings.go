@@ -78,3 +78,10 @@ type RetentionSettings struct {
 	RetentionHours int  `json:"retention_hours"`
 	Enabled        bool `json:"enabled"`
 }
+
+// ServerStatusSettings contains parsed server status thresholds
+// Thresholds are expressed in missed reporting intervals (servers.report_interval_seconds)
+type ServerStatusSettings struct {
+	StaleAfterIntervals   int `json:"stale_after_intervals"`
+	OfflineAfterIntervals int `json:"offline_after_intervals"`
+}
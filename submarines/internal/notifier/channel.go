@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/nodepulse/admiral/submarines/internal/alerting"
+)
+
+// Channel types (admiral.notification_channels.type)
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelSlack   = "slack"
+	ChannelDiscord = "discord"
+)
+
+// severityRank orders alert severities for min_severity filtering
+var severityRank = map[string]int{
+	"info":     0,
+	"warning":  1,
+	"critical": 2,
+}
+
+// Channel represents a row in admiral.notification_channels
+type Channel struct {
+	ID              string
+	Name            string
+	Type            string
+	Config          json.RawMessage
+	SubjectTemplate string
+	BodyTemplate    string
+	MinSeverity     string
+	Events          []string
+}
+
+// accepts reports whether the channel should receive an event
+func (c *Channel) accepts(event *alerting.Event) bool {
+	if len(c.Events) > 0 && !slices.Contains(c.Events, event.Type) {
+		return false
+	}
+	return severityRank[event.Severity] >= severityRank[c.MinSeverity]
+}
+
+// loadChannels reads all enabled channels from admiral.notification_channels
+func loadChannels(ctx context.Context, db *sql.DB) ([]Channel, error) {
+	query := `
+		SELECT id, name, type, config,
+		       COALESCE(subject_template, ''), COALESCE(body_template, ''),
+		       min_severity, events
+		FROM admiral.notification_channels
+		WHERE enabled = true
+		ORDER BY name
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	channels := []Channel{}
+	for rows.Next() {
+		var ch Channel
+		var config, events []byte
+		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &config,
+			&ch.SubjectTemplate, &ch.BodyTemplate, &ch.MinSeverity, &events); err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+
+		ch.Config = json.RawMessage(config)
+		if err := json.Unmarshal(events, &ch.Events); err != nil {
+			return nil, fmt.Errorf("invalid events for channel %q: %w", ch.Name, err)
+		}
+		channels = append(channels, ch)
+	}
+
+	return channels, rows.Err()
+}
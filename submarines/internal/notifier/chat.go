@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Discord rejects message content longer than 2000 characters
+const discordMaxContent = 2000
+
+type chatConfig struct {
+	URL string `json:"url"`
+}
+
+// sendChat posts a message to a Slack or Discord incoming webhook
+func (n *Notifier) sendChat(ctx context.Context, ch *Channel, msg *Message) (int, error) {
+	var cfg chatConfig
+	if err := json.Unmarshal(ch.Config, &cfg); err != nil {
+		return 0, fmt.Errorf("invalid %s config: %w", ch.Type, err)
+	}
+	if cfg.URL == "" {
+		return 0, fmt.Errorf("%s webhook url is not configured", ch.Type)
+	}
+
+	text := msg.Body
+	if msg.Subject != "" {
+		text = msg.Subject + "\n" + msg.Body
+	}
+
+	var payload map[string]string
+	switch ch.Type {
+	case ChannelSlack:
+		payload = map[string]string{"text": text}
+	case ChannelDiscord:
+		if runes := []rune(text); len(runes) > discordMaxContent {
+			text = string(runes[:discordMaxContent-1]) + "…"
+		}
+		payload = map[string]string{"content": text}
+	default:
+		return 0, fmt.Errorf("unsupported chat channel type: %s", ch.Type)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s payload: %w", ch.Type, err)
+	}
+
+	return n.postJSON(ctx, cfg.URL, body, nil)
+}
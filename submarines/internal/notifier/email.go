@@ -0,0 +1,150 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP transport security modes
+const (
+	smtpTLSStartTLS = "starttls" // Upgrade with STARTTLS (required)
+	smtpTLSImplicit = "tls"      // TLS from the first byte (usually port 465)
+	smtpTLSNone     = "none"     // Plain text (local relays, test sinks)
+)
+
+const smtpDialTimeout = 10 * time.Second
+
+type emailConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	TLS      string   `json:"tls"` // starttls, tls, none (empty = STARTTLS when offered)
+}
+
+// sendEmail delivers a plain text message over SMTP
+// Returns the SMTP reply code of the failing command, or 250 on success
+func (n *Notifier) sendEmail(ctx context.Context, ch *Channel, msg *Message) (int, error) {
+	var cfg emailConfig
+	if err := json.Unmarshal(ch.Config, &cfg); err != nil {
+		return 0, fmt.Errorf("invalid email config: %w", err)
+	}
+	if cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return 0, fmt.Errorf("email channel requires host, from and to")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.TLS == smtpTLSImplicit {
+			cfg.Port = 465
+		}
+	}
+
+	err := deliverMail(ctx, &cfg, buildMail(&cfg, msg))
+	if err != nil {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) {
+			return protoErr.Code, err
+		}
+		return 0, err
+	}
+	return 250, nil
+}
+
+func deliverMail(ctx context.Context, cfg *emailConfig, data []byte) error {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS == smtpTLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	// Bound the whole SMTP conversation by the context deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if cfg.TLS != smtpTLSImplicit && cfg.TLS != smtpTLSNone {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("starttls failed: %w", err)
+			}
+		} else if cfg.TLS == smtpTLSStartTLS {
+			return fmt.Errorf("server %s does not support STARTTLS", addr)
+		}
+	}
+
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(cfg.From); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("RCPT TO %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// buildMail assembles an RFC 5322 message with CRLF line endings
+func buildMail(cfg *emailConfig, msg *Message) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + cfg.From + "\r\n")
+	sb.WriteString("To: " + strings.Join(cfg.To, ", ") + "\r\n")
+	sb.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	sb.WriteString("X-NodePulse-Group: " + mime.QEncoding.Encode("utf-8", msg.Notification.GroupKey) + "\r\n")
+	sb.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	sb.WriteString("\r\n")
+
+	return []byte(sb.String())
+}
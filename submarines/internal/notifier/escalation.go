@@ -0,0 +1,157 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/alerting"
+	"github.com/nodepulse/admiral/submarines/internal/oncall"
+)
+
+// Escalate pages every escalation tier that is due
+// Pages bypass notification grouping and channel event filters: the tier chose its targets
+// A tier advances once its page reached a target, otherwise it is paged again
+// Returns the number of successful pages
+func (n *Notifier) Escalate(ctx context.Context) (int, error) {
+	pages, err := alerting.ClaimEscalations(ctx, n.db)
+	if err != nil {
+		return 0, err
+	}
+	if len(pages) == 0 {
+		return 0, nil
+	}
+
+	// Claimed tiers that are never paged are re-armed after the claim timeout
+	channels, err := loadChannels(ctx, n.db)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, page := range pages {
+		if ctx.Err() != nil {
+			break
+		}
+		reached := n.page(ctx, channels, &page)
+		sent += reached
+
+		settleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), attemptTimeout)
+		if reached > 0 {
+			err = alerting.CompletePage(settleCtx, n.db, &page, reached)
+		} else {
+			log.Printf("[WARN] Escalation of alert %s (tier %d) reached no target, retrying", page.Event.AlertID, page.Tier)
+			err = alerting.RetryPage(settleCtx, n.db, &page)
+		}
+		cancel()
+		if err != nil {
+			log.Printf("[WARN] Failed to settle escalation of alert %s (tier %d): %v", page.Event.AlertID, page.Tier, err)
+		}
+	}
+	return sent, nil
+}
+
+// page delivers one escalation tier to all of its targets
+func (n *Notifier) page(ctx context.Context, channels []Channel, page *alerting.Page) int {
+	notification := newNotification("escalation:"+page.Event.AlertID, nil, []alerting.Event{*page.Event})
+
+	sent := 0
+	for _, target := range page.Targets {
+		ch, recipient, err := n.resolveTarget(ctx, channels, page, target)
+		if err != nil {
+			log.Printf("[WARN] Escalation of alert %s (tier %d): %v", page.Event.AlertID, page.Tier, err)
+			continue
+		}
+
+		if err := n.deliver(ctx, ch, notification); err != nil {
+			log.Printf("[WARN] Escalation of alert %s (tier %d) to %s failed: %v",
+				page.Event.AlertID, page.Tier, recipient, err)
+			continue
+		}
+		log.Printf("[INFO] Paged %s for alert %s (tier %d)", recipient, page.Event.AlertID, page.Tier)
+		sent++
+	}
+	return sent
+}
+
+// resolveTarget returns the channel delivering a page to a target and a description of the recipient
+func (n *Notifier) resolveTarget(ctx context.Context, channels []Channel, page *alerting.Page, target alerting.EscalationTarget) (*Channel, string, error) {
+	switch target.Type {
+	case alerting.TargetChannel:
+		var id string
+		if err := json.Unmarshal(target.ID, &id); err != nil {
+			return nil, "", fmt.Errorf("invalid channel target %s", target.ID)
+		}
+		for i := range channels {
+			if channels[i].ID == id {
+				return &channels[i], fmt.Sprintf("channel %q", channels[i].Name), nil
+			}
+		}
+		return nil, "", fmt.Errorf("channel %s not found or disabled", id)
+
+	case alerting.TargetUser:
+		var id int64
+		if err := json.Unmarshal(target.ID, &id); err != nil {
+			return nil, "", fmt.Errorf("invalid user target %s", target.ID)
+		}
+		user, err := oncall.UserByID(ctx, n.db, id)
+		if err != nil {
+			return nil, "", err
+		}
+		return emailTo(channels, page.EmailChannelID, user)
+
+	case alerting.TargetSchedule:
+		var id string
+		if err := json.Unmarshal(target.ID, &id); err != nil {
+			return nil, "", fmt.Errorf("invalid schedule target %s", target.ID)
+		}
+		shift, err := oncall.Current(ctx, n.db, id, time.Now())
+		if err != nil {
+			return nil, "", fmt.Errorf("schedule %s: %w", id, err)
+		}
+		return emailTo(channels, page.EmailChannelID, &shift.User)
+
+	default:
+		return nil, "", fmt.Errorf("unsupported escalation target type: %s", target.Type)
+	}
+}
+
+// emailTo copies an email channel with its recipients replaced by a single user
+func emailTo(channels []Channel, channelID string, user *oncall.User) (*Channel, string, error) {
+	recipient := "user " + strconv.FormatInt(user.ID, 10) + " <" + user.Email + ">"
+	if user.Email == "" {
+		return nil, "", fmt.Errorf("%s has no email address", recipient)
+	}
+
+	var transport *Channel
+	for i := range channels {
+		ch := &channels[i]
+		if ch.Type != ChannelEmail {
+			continue
+		}
+		if channelID == "" || ch.ID == channelID {
+			transport = ch
+			break
+		}
+	}
+	if transport == nil {
+		return nil, "", fmt.Errorf("no enabled email channel to page %s", recipient)
+	}
+
+	var cfg map[string]any
+	if err := json.Unmarshal(transport.Config, &cfg); err != nil {
+		return nil, "", fmt.Errorf("invalid email config in channel %q: %w", transport.Name, err)
+	}
+	cfg["to"] = []string{user.Email}
+	config, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal email config: %w", err)
+	}
+
+	ch := *transport
+	ch.Config = config
+	return &ch, recipient, nil
+}
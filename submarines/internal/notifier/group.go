@@ -0,0 +1,449 @@
+package notifier
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nodepulse/admiral/submarines/internal/alerting"
+)
+
+const (
+	// maxGroupsPerFlush bounds how many due groups one flush claims
+	maxGroupsPerFlush = 50
+
+	// maxFlushAttempts bounds how often a group with failed deliveries is retried
+	maxFlushAttempts = 5
+
+	// redeliveryDelay is the wait before a group with failed deliveries is flushed again
+	redeliveryDelay = time.Minute
+
+	// claimTimeout releases events of a flush that never settled (e.g. crashed notifier)
+	// Must exceed the longest delivery of a group (retries across all channels)
+	claimTimeout = 10 * time.Minute
+)
+
+// severityOrder lists severities from most to least severe
+var severityOrder = []string{"critical", "warning", "info"}
+
+// Notification is a group of alert events delivered as one message
+// The embedded event is the most recent one, so single-alert templates keep working
+type Notification struct {
+	*alerting.Event
+
+	GroupKey string
+	Labels   map[string]string
+	Events   []alerting.Event // Latest event per alert, oldest first
+	Severity string           // Highest severity in the group
+	Firing   int              // Events that are not resolutions
+	Resolved int
+}
+
+// newNotification builds a notification from a group's events (oldest first)
+func newNotification(groupKey string, labels map[string]string, events []alerting.Event) *Notification {
+	n := &Notification{
+		GroupKey: groupKey,
+		Labels:   labels,
+		Events:   events,
+		Event:    &events[len(events)-1],
+		Severity: severityOrder[len(severityOrder)-1],
+	}
+
+	for _, e := range events {
+		if e.Type == alerting.EventResolved {
+			n.Resolved++
+		} else {
+			n.Firing++
+		}
+		if rank, ok := severityRank[e.Severity]; ok && rank > severityRank[n.Severity] {
+			n.Severity = e.Severity
+		}
+	}
+	return n
+}
+
+// AlertIDs returns the alerts covered by the notification
+func (n *Notification) AlertIDs() []string {
+	ids := make([]string, len(n.Events))
+	for i, e := range n.Events {
+		ids[i] = e.AlertID
+	}
+	return ids
+}
+
+// enqueue stores an event until its group is flushed
+// Events without a group (e.g. user actions) get their own group and no wait
+func (n *Notifier) enqueue(ctx context.Context, event *alerting.Event) error {
+	key := event.GroupKey
+	if key == "" {
+		key = "alert:" + event.AlertID
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert event: %w", err)
+	}
+	labels, err := json.Marshal(event.GroupLabels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal group labels: %w", err)
+	}
+
+	var ruleID any
+	if event.RuleID != "" {
+		ruleID = event.RuleID
+	}
+
+	tx, err := n.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO admiral.notification_queue (group_key, alert_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+	`, key, event.AlertID, event.Type, payload)
+	if err != nil {
+		return fmt.Errorf("failed to queue alert event: %w", err)
+	}
+
+	// A new group waits group_wait; a group notified recently waits until
+	// group_interval has passed since its last flush
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO admiral.notification_groups (
+			group_key, rule_id, labels, group_wait_seconds, group_interval_seconds, next_flush_at
+		) VALUES ($1, $2, COALESCE(NULLIF($3, 'null'), '{}')::jsonb, $4, $5, NOW() + make_interval(secs => $4))
+		ON CONFLICT (group_key) DO UPDATE SET
+			labels = EXCLUDED.labels,
+			group_wait_seconds = EXCLUDED.group_wait_seconds,
+			group_interval_seconds = EXCLUDED.group_interval_seconds,
+			next_flush_at = COALESCE(
+				admiral.notification_groups.next_flush_at,
+				GREATEST(
+					NOW() + make_interval(secs => EXCLUDED.group_wait_seconds),
+					COALESCE(admiral.notification_groups.last_flushed_at + make_interval(secs => EXCLUDED.group_interval_seconds), NOW())
+				)
+			)
+	`, key, ruleID, string(labels), event.GroupWait, event.GroupInterval)
+	if err != nil {
+		return fmt.Errorf("failed to schedule notification group %s: %w", key, err)
+	}
+
+	return tx.Commit()
+}
+
+// Flush delivers every group whose wait/interval elapsed
+// Groups are claimed atomically, so several alerter instances can flush concurrently.
+// Queued events are kept until every channel accepted them: a failed group is
+// re-queued and retried, channels that already received an event are skipped
+// Returns the number of notifications sent
+func (n *Notifier) Flush(ctx context.Context) (int, error) {
+	if err := releaseExpiredClaims(ctx, n.db); err != nil {
+		return 0, err
+	}
+
+	groups, err := n.claimDueGroups(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(groups) == 0 {
+		return 0, nil
+	}
+
+	channels, err := loadChannels(ctx, n.db)
+	if err != nil {
+		n.releaseAll(groups)
+		return 0, err
+	}
+
+	sent := 0
+	for i := range groups {
+		g := &groups[i]
+		if ctx.Err() != nil {
+			// Not attempted, hand the remaining groups back
+			n.releaseAll(groups[i:])
+			break
+		}
+		delivered, failed := n.notify(ctx, channels, g)
+		sent += delivered
+		// Settle even when shutting down mid-flush, otherwise the group waits for claimTimeout
+		settleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), attemptTimeout)
+		err := n.settle(settleCtx, g, failed)
+		cancel()
+		if err != nil {
+			log.Printf("[WARN] Failed to settle notification group %s: %v", g.key, err)
+		}
+	}
+	return sent, nil
+}
+
+// claimedGroup is a notification group whose queued events are claimed by this flush
+type claimedGroup struct {
+	key      string
+	labels   map[string]string
+	events   []queuedEvent // Latest event per alert, oldest first
+	ids      []int64       // Every claimed queue row, including superseded events
+	attempts int           // Highest previous attempt count of the group's events
+}
+
+// queuedEvent is the latest queued event of one alert
+type queuedEvent struct {
+	id        int64
+	event     alerting.Event
+	delivered map[string]bool // Channel IDs that already received the event
+}
+
+// claimDueGroups takes due groups and marks their queued events in-flight in one transaction
+func (n *Notifier) claimDueGroups(ctx context.Context) ([]claimedGroup, error) {
+	tx, err := n.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	rows, err := tx.QueryContext(ctx, `
+		WITH due AS (
+			SELECT group_key
+			FROM admiral.notification_groups
+			WHERE next_flush_at <= NOW()
+			ORDER BY next_flush_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE admiral.notification_groups g
+		SET next_flush_at = NULL, last_flushed_at = NOW()
+		FROM due
+		WHERE g.group_key = due.group_key
+		RETURNING g.group_key, g.labels
+	`, maxGroupsPerFlush)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notification groups: %w", err)
+	}
+
+	groups := []claimedGroup{}
+	for rows.Next() {
+		var g claimedGroup
+		var labels []byte
+		if err := rows.Scan(&g.key, &labels); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan notification group: %w", err)
+		}
+		if err := json.Unmarshal(labels, &g.labels); err != nil {
+			log.Printf("[WARN] Ignoring invalid labels of notification group %s: %v", g.key, err)
+		}
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	claimed := groups[:0]
+	for _, g := range groups {
+		if err := claimQueued(ctx, tx, &g); err != nil {
+			return nil, err
+		}
+		if len(g.events) == 0 {
+			continue
+		}
+		claimed = append(claimed, g)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return claimed, nil
+}
+
+// claimQueued marks a group's waiting events in-flight, keeping only the latest event per alert
+func claimQueued(ctx context.Context, tx *sql.Tx, g *claimedGroup) error {
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE admiral.notification_queue
+		SET claimed_at = NOW()
+		WHERE group_key = $1 AND claimed_at IS NULL
+		RETURNING id, payload, attempts, delivered_channels
+	`, g.key)
+	if err != nil {
+		return fmt.Errorf("failed to claim queued events for %s: %w", g.key, err)
+	}
+	defer rows.Close()
+
+	items := []queuedEvent{}
+	for rows.Next() {
+		var q queuedEvent
+		var payload, delivered []byte
+		var attempts int
+		if err := rows.Scan(&q.id, &payload, &attempts, &delivered); err != nil {
+			return fmt.Errorf("failed to scan queued event: %w", err)
+		}
+		// Invalid rows are claimed too, so they are removed with the group
+		g.ids = append(g.ids, q.id)
+		if err := json.Unmarshal(payload, &q.event); err != nil {
+			log.Printf("[WARN] Dropping invalid queued alert event %d: %v", q.id, err)
+			continue
+		}
+		var channelIDs []string
+		if err := json.Unmarshal(delivered, &channelIDs); err != nil {
+			log.Printf("[WARN] Ignoring invalid delivered channels of queued alert event %d: %v", q.id, err)
+		}
+		q.delivered = make(map[string]bool, len(channelIDs))
+		for _, id := range channelIDs {
+			q.delivered[id] = true
+		}
+		g.attempts = max(g.attempts, attempts)
+		items = append(items, q)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// UPDATE ... RETURNING has no order
+	slices.SortFunc(items, func(a, b queuedEvent) int {
+		return cmp.Compare(a.id, b.id)
+	})
+
+	// Deduplicate: an alert that changed state several times is reported once, as its latest state
+	seen := make(map[string]bool, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		if seen[items[i].event.AlertID] {
+			continue
+		}
+		seen[items[i].event.AlertID] = true
+		g.events = append(g.events, items[i])
+	}
+	slices.Reverse(g.events)
+
+	return nil
+}
+
+// settle removes a group's claimed events once delivered, or re-queues them for a retry
+// Superseded events are removed either way, they were reported through the latest event
+func (n *Notifier) settle(ctx context.Context, g *claimedGroup, failed bool) error {
+	if !failed || g.attempts+1 >= maxFlushAttempts {
+		if failed {
+			log.Printf("[ERROR] Giving up on notification group %s after %d attempts", g.key, g.attempts+1)
+		}
+		if _, err := n.db.ExecContext(ctx, `
+			DELETE FROM admiral.notification_queue WHERE id = ANY($1)
+		`, pq.Array(g.ids)); err != nil {
+			return fmt.Errorf("failed to remove delivered events: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := n.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	kept := make(map[int64]bool, len(g.events))
+	for _, q := range g.events {
+		kept[q.id] = true
+		delivered, err := json.Marshal(slices.Sorted(maps.Keys(q.delivered)))
+		if err != nil {
+			return fmt.Errorf("failed to marshal delivered channels: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE admiral.notification_queue
+			SET claimed_at = NULL, attempts = attempts + 1, delivered_channels = $2
+			WHERE id = $1
+		`, q.id, delivered); err != nil {
+			return fmt.Errorf("failed to re-queue event %d: %w", q.id, err)
+		}
+	}
+
+	superseded := []int64{}
+	for _, id := range g.ids {
+		if !kept[id] {
+			superseded = append(superseded, id)
+		}
+	}
+	if len(superseded) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM admiral.notification_queue WHERE id = ANY($1)
+		`, pq.Array(superseded)); err != nil {
+			return fmt.Errorf("failed to remove superseded events: %w", err)
+		}
+	}
+
+	if err := rescheduleGroup(ctx, tx, g.key, redeliveryDelay); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("[WARN] Notification group %s re-queued (attempt %d of %d)", g.key, g.attempts+1, maxFlushAttempts)
+	return nil
+}
+
+// releaseAll hands groups that were claimed but not attempted back to the queue
+func (n *Notifier) releaseAll(groups []claimedGroup) {
+	// The flush context may be cancelled already
+	ctx, cancel := context.WithTimeout(context.Background(), attemptTimeout)
+	defer cancel()
+
+	for _, g := range groups {
+		err := func() error {
+			tx, err := n.db.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback() // Safe to call even after commit
+
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE admiral.notification_queue SET claimed_at = NULL WHERE id = ANY($1)
+			`, pq.Array(g.ids)); err != nil {
+				return err
+			}
+			if err := rescheduleGroup(ctx, tx, g.key, 0); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}()
+		if err != nil {
+			// The claims expire after claimTimeout
+			log.Printf("[WARN] Failed to release notification group %s: %v", g.key, err)
+		}
+	}
+}
+
+// releaseExpiredClaims re-queues events whose flush never settled (e.g. the notifier crashed)
+func releaseExpiredClaims(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		WITH released AS (
+			UPDATE admiral.notification_queue
+			SET claimed_at = NULL, attempts = attempts + 1
+			WHERE claimed_at < NOW() - make_interval(secs => $1)
+			RETURNING group_key
+		)
+		UPDATE admiral.notification_groups g
+		SET next_flush_at = COALESCE(g.next_flush_at, NOW())
+		WHERE g.group_key IN (SELECT group_key FROM released)
+	`, claimTimeout.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to release expired notification claims: %w", err)
+	}
+	return nil
+}
+
+// rescheduleGroup makes a group due again after delay (unless it is already scheduled)
+func rescheduleGroup(ctx context.Context, tx *sql.Tx, groupKey string, delay time.Duration) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE admiral.notification_groups
+		SET next_flush_at = COALESCE(next_flush_at, NOW() + make_interval(secs => $2))
+		WHERE group_key = $1
+	`, groupKey, delay.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to reschedule notification group %s: %w", groupKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package notifier
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/alerting"
+	"github.com/nodepulse/admiral/submarines/internal/queue"
+	"github.com/nodepulse/admiral/submarines/internal/retry"
+)
+
+const (
+	// ConsumerGroup is the consumer group reading alerting.EventsStreamKey
+	ConsumerGroup = "submarines-notifier"
+
+	batchSize      = 20
+	attemptTimeout = 15 * time.Second
+)
+
+// deliveryRetry controls per-channel retries (2s, 4s between attempts)
+// Every attempt is recorded in admiral.notification_deliveries
+var deliveryRetry = retry.Config{
+	MaxAttempts:  3,
+	InitialDelay: 2 * time.Second,
+	MaxDelay:     30 * time.Second,
+	Multiplier:   2.0,
+}
+
+// Notifier delivers alert events to notification channels
+type Notifier struct {
+	db         *sql.DB
+	queue      queue.Queue
+	consumer   string
+	httpClient *http.Client
+}
+
+// New creates a notifier reading the alert events stream as the given consumer
+func New(db *sql.DB, q queue.Queue, consumer string) *Notifier {
+	return &Notifier{
+		db:       db,
+		queue:    q,
+		consumer: consumer,
+		httpClient: &http.Client{
+			Timeout: attemptTimeout,
+		},
+	}
+}
+
+// Init creates the consumer group (idempotent)
+func (n *Notifier) Init(ctx context.Context) error {
+	return n.queue.XGroupCreate(ctx, alerting.EventsStreamKey, ConsumerGroup, "0")
+}
+
+// ProcessBatch reads one batch of alert events and queues them in their notification group
+// Pending messages (delivered but not ACKed, e.g. after a crash) are retried first
+// Delivery happens in Flush once the group's wait/interval elapsed
+// Returns the number of events handled
+func (n *Notifier) ProcessBatch(ctx context.Context) (int, error) {
+	messages, err := n.queue.XReadGroup(ctx, ConsumerGroup, n.consumer, alerting.EventsStreamKey, "0", batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		messages, err = n.queue.XReadGroup(ctx, ConsumerGroup, n.consumer, alerting.EventsStreamKey, ">", batchSize)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	handled := 0
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			break
+		}
+
+		event, err := alerting.ParseEvent(msg)
+		if err != nil {
+			// Malformed events can never be delivered, drop them
+			log.Printf("[WARN] Dropping invalid alert event %s: %v", msg.ID, err)
+		} else if err := n.enqueue(ctx, event); err != nil {
+			// Leave the message pending, it is re-read on the next batch
+			log.Printf("[WARN] Failed to queue alert event %s: %v", msg.ID, err)
+			continue
+		}
+
+		if err := n.queue.XAck(ctx, alerting.EventsStreamKey, ConsumerGroup, msg.ID); err != nil {
+			log.Printf("[WARN] Failed to ACK alert event %s: %v", msg.ID, err)
+			continue
+		}
+		if err := n.queue.XDel(ctx, alerting.EventsStreamKey, msg.ID); err != nil {
+			log.Printf("[WARN] Failed to delete alert event %s: %v", msg.ID, err)
+		}
+		handled++
+	}
+
+	return handled, nil
+}
+
+// notify delivers a group to every channel accepting at least one of its events
+// Events are marked delivered per channel, so a retry only goes to the channels that failed
+// Returns the number of successful deliveries and whether any delivery failed
+func (n *Notifier) notify(ctx context.Context, channels []Channel, g *claimedGroup) (int, bool) {
+	sent, failed := 0, false
+	for i := range channels {
+		ch := &channels[i]
+
+		pending := []*queuedEvent{}
+		events := []alerting.Event{}
+		for j := range g.events {
+			q := &g.events[j]
+			if !q.delivered[ch.ID] && ch.accepts(&q.event) {
+				pending = append(pending, q)
+				events = append(events, q.event)
+			}
+		}
+		if len(events) == 0 {
+			continue
+		}
+
+		notification := newNotification(g.key, g.labels, events)
+		if err := n.deliver(ctx, ch, notification); err != nil {
+			log.Printf("[WARN] Notification for group %s (%d alerts) to channel %q failed: %v",
+				g.key, len(events), ch.Name, err)
+			failed = true
+			continue
+		}
+		for _, q := range pending {
+			q.delivered[ch.ID] = true
+		}
+		log.Printf("[INFO] Notified channel %q: group %s (%d firing, %d resolved)",
+			ch.Name, g.key, notification.Firing, notification.Resolved)
+		sent++
+	}
+	return sent, failed
+}
+
+// deliver sends a notification to a single channel with retries
+func (n *Notifier) deliver(ctx context.Context, ch *Channel, notification *Notification) error {
+	msg, err := render(ch, notification)
+	if err != nil {
+		// Template errors won't fix themselves, record a single failed attempt
+		n.recordDelivery(ctx, ch, notification, 1, 0, 0, err)
+		return err
+	}
+
+	attempt := 0
+	operation := fmt.Sprintf("Deliver group %s to %s channel %q", notification.GroupKey, ch.Type, ch.Name)
+	return retry.WithExponentialBackoff(ctx, deliveryRetry, operation, func() error {
+		attempt++
+
+		attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+		defer cancel()
+
+		start := time.Now()
+		code, err := n.send(attemptCtx, ch, msg)
+		n.recordDelivery(ctx, ch, notification, attempt, code, time.Since(start), err)
+		return err
+	})
+}
+
+// send dispatches a rendered message by channel type
+func (n *Notifier) send(ctx context.Context, ch *Channel, msg *Message) (int, error) {
+	switch ch.Type {
+	case ChannelWebhook:
+		return n.sendWebhook(ctx, ch, msg)
+	case ChannelEmail:
+		return n.sendEmail(ctx, ch, msg)
+	case ChannelSlack, ChannelDiscord:
+		return n.sendChat(ctx, ch, msg)
+	default:
+		return 0, fmt.Errorf("unsupported channel type: %s", ch.Type)
+	}
+}
+
+// recordDelivery logs one delivery attempt to admiral.notification_deliveries
+func (n *Notifier) recordDelivery(ctx context.Context, ch *Channel, notification *Notification, attempt, code int, duration time.Duration, sendErr error) {
+	status := "success"
+	var errMsg sql.NullString
+	if sendErr != nil {
+		status = "failed"
+		errMsg = sql.NullString{String: sendErr.Error(), Valid: true}
+	}
+
+	var responseCode sql.NullInt64
+	if code != 0 {
+		responseCode = sql.NullInt64{Int64: int64(code), Valid: true}
+	}
+
+	// alert_id is only set when the notification covers a single alert
+	var alertID any
+	if len(notification.Events) == 1 {
+		alertID = notification.AlertID
+	}
+	alertIDs, err := json.Marshal(notification.AlertIDs())
+	if err != nil {
+		log.Printf("[WARN] Failed to marshal alert IDs for group %s: %v", notification.GroupKey, err)
+		return
+	}
+
+	query := `
+		INSERT INTO admiral.notification_deliveries (
+			channel_id, alert_id, group_key, alert_ids, event_type, attempt, status,
+			response_code, error, duration_ms
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err = n.db.ExecContext(ctx, query,
+		ch.ID, alertID, notification.GroupKey, alertIDs, notification.Type, attempt, status,
+		responseCode, errMsg, duration.Milliseconds(),
+	)
+	if err != nil {
+		log.Printf("[WARN] Failed to record notification delivery for group %s: %v", notification.GroupKey, err)
+	}
+}
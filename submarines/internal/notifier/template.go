@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Default templates (used when a channel doesn't define its own)
+// Template data is Notification: group fields plus the most recent alerting.Event
+const (
+	defaultSubjectTemplate = `[{{ upper .Severity }}] {{ if gt (len .Events) 1 }}{{ if .Firing }}FIRING:{{ .Firing }}{{ end }}{{ if and .Firing .Resolved }} {{ end }}{{ if .Resolved }}RESOLVED:{{ .Resolved }}{{ end }} {{ or .RuleName .AlertType }}{{ range $k, $v := .Labels }} {{ $k }}={{ $v }}{{ end }}{{ else }}{{ if eq .Type "resolved" }}RESOLVED{{ else if eq .Type "acknowledged" }}ACKNOWLEDGED{{ else if eq .Type "escalated" }}ESCALATED{{ else }}FIRING{{ end }}: {{ or .RuleName .AlertType }} on {{ or .Hostname .ServerID }}{{ end }}`
+
+	defaultBodyTemplate = `{{ if gt (len .Events) 1 }}{{ len .Events }} alerts for {{ or .RuleName .AlertType }}{{ range $k, $v := .Labels }} {{ $k }}={{ $v }}{{ end }}
+{{ range .Events }}
+- [{{ .Type }}] {{ .Message }}{{ if .Reason }} ({{ .Reason }}){{ end }}{{ end }}
+{{ else }}{{ .Message }}
+Status: {{ .Type }}{{ if .Reason }} ({{ .Reason }}){{ end }}
+Server: {{ or .Hostname .ServerID }}
+Severity: {{ .Severity }}{{ if .CurrentValue }}
+Current value: {{ printf "%.2f" (value .CurrentValue) }}{{ end }}{{ if .Threshold }}
+Threshold: {{ printf "%.2f" (value .Threshold) }}{{ end }}
+Time: {{ .OccurredAt.UTC.Format "2006-01-02 15:04:05 MST" }}
+Alert ID: {{ .AlertID }}{{ end }}`
+)
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// value dereferences optional numbers (Threshold, CurrentValue)
+	"value": func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	},
+}
+
+// Message is a rendered notification
+type Message struct {
+	Subject      string
+	Body         string
+	Notification *Notification
+}
+
+// render applies a channel's templates to a notification
+func render(ch *Channel, notification *Notification) (*Message, error) {
+	subjectTmpl := ch.SubjectTemplate
+	if subjectTmpl == "" {
+		subjectTmpl = defaultSubjectTemplate
+	}
+	bodyTmpl := ch.BodyTemplate
+	if bodyTmpl == "" {
+		bodyTmpl = defaultBodyTemplate
+	}
+
+	subject, err := execute("subject", subjectTmpl, notification)
+	if err != nil {
+		return nil, err
+	}
+	body, err := execute("body", bodyTmpl, notification)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject:      strings.TrimSpace(subject),
+		Body:         body,
+		Notification: notification,
+	}, nil
+}
+
+func execute(name, text string, data any) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return sb.String(), nil
+}
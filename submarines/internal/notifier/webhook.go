@@ -0,0 +1,114 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook request headers
+// The signature is HMAC-SHA256(secret, "<timestamp>.<body>") so receivers can
+// reject replayed requests by checking the timestamp
+const (
+	headerEvent     = "X-NodePulse-Event"
+	headerTimestamp = "X-NodePulse-Timestamp"
+	headerSignature = "X-NodePulse-Signature"
+)
+
+type webhookConfig struct {
+	URL     string            `json:"url"`
+	Secret  string            `json:"secret"`
+	Headers map[string]string `json:"headers"`
+}
+
+// webhookPayload is the JSON body POSTed to generic webhooks
+type webhookPayload struct {
+	Subject  string            `json:"subject"`
+	Text     string            `json:"text"`
+	GroupKey string            `json:"group_key"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Event    any               `json:"event"`  // Most recent event
+	Events   any               `json:"events"` // All events in the group
+}
+
+// sendWebhook POSTs a signed JSON payload to a generic webhook
+func (n *Notifier) sendWebhook(ctx context.Context, ch *Channel, msg *Message) (int, error) {
+	var cfg webhookConfig
+	if err := json.Unmarshal(ch.Config, &cfg); err != nil {
+		return 0, fmt.Errorf("invalid webhook config: %w", err)
+	}
+	if cfg.URL == "" {
+		return 0, fmt.Errorf("webhook url is not configured")
+	}
+
+	notification := msg.Notification
+	body, err := json.Marshal(webhookPayload{
+		Subject:  msg.Subject,
+		Text:     msg.Body,
+		GroupKey: notification.GroupKey,
+		Labels:   notification.Labels,
+		Event:    notification.Event,
+		Events:   notification.Events,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	headers := map[string]string{
+		headerEvent: notification.Type,
+	}
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	if cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[headerTimestamp] = timestamp
+		headers[headerSignature] = "sha256=" + sign(cfg.Secret, timestamp, body)
+	}
+
+	return n.postJSON(ctx, cfg.URL, body, headers)
+}
+
+// sign computes the hex HMAC-SHA256 of "<timestamp>.<body>"
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON sends a JSON body and treats any non-2xx response as a failure
+func (n *Notifier) postJSON(ctx context.Context, url string, body []byte, headers map[string]string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NodePulse-Admiral/1.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
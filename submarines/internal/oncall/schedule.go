@@ -0,0 +1,130 @@
+package oncall
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrScheduleNotFound is returned when a schedule doesn't exist or is disabled
+	ErrScheduleNotFound = errors.New("on-call schedule not found")
+
+	// ErrNobodyOnCall is returned when a schedule has no participants
+	ErrNobodyOnCall = errors.New("nobody is on call")
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// User is the on-call user (subset of admiral.users)
+type User struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Shift describes who is on call and until when
+type Shift struct {
+	ScheduleID string    `json:"schedule_id"`
+	User       User      `json:"user"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Override   bool      `json:"override"` // true when an override replaces the rotation
+}
+
+// Current returns the shift of a schedule at the given time
+// Overrides take precedence over the rotation
+func Current(ctx context.Context, q queryer, scheduleID string, at time.Time) (*Shift, error) {
+	var participantsRaw []byte
+	var rotationHours int
+	var rotationStart time.Time
+	err := q.QueryRowContext(ctx, `
+		SELECT participants, rotation_hours, rotation_start
+		FROM admiral.oncall_schedules
+		WHERE id = $1 AND enabled = true
+	`, scheduleID).Scan(&participantsRaw, &rotationHours, &rotationStart)
+	if err == sql.ErrNoRows {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load on-call schedule %s: %w", scheduleID, err)
+	}
+
+	shift := &Shift{ScheduleID: scheduleID}
+
+	err = q.QueryRowContext(ctx, `
+		SELECT user_id, starts_at, ends_at
+		FROM admiral.oncall_overrides
+		WHERE schedule_id = $1 AND starts_at <= $2 AND ends_at > $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, scheduleID, at).Scan(&shift.User.ID, &shift.Start, &shift.End)
+	switch {
+	case err == nil:
+		shift.Override = true
+	case err == sql.ErrNoRows:
+		var participants []int64
+		if err := json.Unmarshal(participantsRaw, &participants); err != nil {
+			return nil, fmt.Errorf("invalid participants in schedule %s: %w", scheduleID, err)
+		}
+		userID, start, end, ok := rotation(participants, time.Duration(rotationHours)*time.Hour, rotationStart, at)
+		if !ok {
+			return nil, ErrNobodyOnCall
+		}
+		shift.User.ID, shift.Start, shift.End = userID, start, end
+	default:
+		return nil, fmt.Errorf("failed to load on-call overrides for %s: %w", scheduleID, err)
+	}
+
+	err = q.QueryRowContext(ctx, `SELECT name, email FROM admiral.users WHERE id = $1`, shift.User.ID).
+		Scan(&shift.User.Name, &shift.User.Email)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: user %d in schedule %s no longer exists", ErrNobodyOnCall, shift.User.ID, scheduleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load on-call user %d: %w", shift.User.ID, err)
+	}
+
+	return shift, nil
+}
+
+// rotation picks the participant on call at the given time
+// Participant i is on call during [start + (n*len + i) * length, ... + length) for every n;
+// times before start belong to the rotation running backwards from it
+func rotation(participants []int64, length time.Duration, start, at time.Time) (int64, time.Time, time.Time, bool) {
+	if len(participants) == 0 || length <= 0 {
+		return 0, time.Time{}, time.Time{}, false
+	}
+
+	slot := int64(at.Sub(start) / length)
+	if at.Before(start) && at.Sub(start)%length != 0 {
+		slot-- // Floor division for negative offsets
+	}
+
+	idx := slot % int64(len(participants))
+	if idx < 0 {
+		idx += int64(len(participants))
+	}
+
+	shiftStart := start.Add(time.Duration(slot) * length)
+	return participants[idx], shiftStart, shiftStart.Add(length), true
+}
+
+// UserByID loads a user that can be paged directly
+func UserByID(ctx context.Context, q queryer, userID int64) (*User, error) {
+	u := &User{ID: userID}
+	err := q.QueryRowContext(ctx, `SELECT name, email FROM admiral.users WHERE id = $1`, userID).Scan(&u.Name, &u.Email)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user %d: %w", userID, err)
+	}
+	return u, nil
+}
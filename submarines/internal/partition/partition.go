@@ -0,0 +1,177 @@
+package partition
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Day is the range of one partition (a UTC day)
+// Partitions of admiral.<table> are named <table>_pYYYYMMDD
+const Day = 24 * time.Hour
+
+// dayLayout is the date suffix of partition names
+const dayLayout = "20060102"
+
+// Partition is a daily partition of a table
+type Partition struct {
+	Name string    // Without schema, e.g. logs_p20251216
+	From time.Time // Inclusive
+	To   time.Time // Exclusive
+}
+
+// Name returns the partition of table (without schema) holding a timestamp
+func Name(table string, t time.Time) string {
+	return table + "_p" + t.UTC().Format(dayLayout)
+}
+
+// Ensure creates the missing daily partitions of admiral.<table> for the given number
+// of days starting with the day of from
+// Returns the names of the partitions created
+func Ensure(ctx context.Context, db *sql.DB, table string, from time.Time, days int) ([]string, error) {
+	existing, err := List(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		have[p.Name] = true
+	}
+
+	created := []string{}
+	start := from.UTC().Truncate(Day)
+	for i := 0; i < days; i++ {
+		dayStart := start.Add(time.Duration(i) * Day)
+		p := Partition{Name: Name(table, dayStart), From: dayStart, To: dayStart.Add(Day)}
+		if have[p.Name] {
+			continue
+		}
+
+		if err := create(ctx, db, table, p); err != nil {
+			return created, err
+		}
+		created = append(created, p.Name)
+	}
+
+	return created, nil
+}
+
+// create adds a daily partition to admiral.<table>
+// Postgres refuses to create a partition while the default partition holds rows of its
+// range (e.g. a row stamped ahead of time), so those rows are moved into the new partition:
+// the default partition is detached, the partition created, the rows re-inserted through
+// the parent and the default partition attached again, all in one transaction
+func create(ctx context.Context, db *sql.DB, table string, p Partition) error {
+	createQuery := fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS admiral.%s PARTITION OF admiral.%s FOR VALUES FROM ('%s') TO ('%s')`,
+		p.Name, table, p.From.Format(time.RFC3339), p.To.Format(time.RFC3339))
+
+	var stranded bool
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM admiral.%s_default WHERE timestamp >= $1 AND timestamp < $2)
+	`, table), p.From, p.To).Scan(&stranded)
+	if err != nil {
+		return fmt.Errorf("failed to check admiral.%s_default for rows of %s: %w", table, p.Name, err)
+	}
+
+	if !stranded {
+		if _, err := db.ExecContext(ctx, createQuery); err != nil {
+			return fmt.Errorf("failed to create partition admiral.%s: %w", p.Name, err)
+		}
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	steps := []string{
+		fmt.Sprintf(`ALTER TABLE admiral.%s DETACH PARTITION admiral.%s_default`, table, table),
+		createQuery,
+		fmt.Sprintf(`
+			WITH moved AS (
+				DELETE FROM admiral.%s_default
+				WHERE timestamp >= '%s' AND timestamp < '%s'
+				RETURNING *
+			)
+			INSERT INTO admiral.%s SELECT * FROM moved
+		`, table, p.From.Format(time.RFC3339), p.To.Format(time.RFC3339), table),
+		fmt.Sprintf(`ALTER TABLE admiral.%s ATTACH PARTITION admiral.%s_default DEFAULT`, table, table),
+	}
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, step); err != nil {
+			return fmt.Errorf("failed to move rows of admiral.%s_default into partition admiral.%s: %w", table, p.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit partition admiral.%s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// List returns the daily partitions of admiral.<table>, oldest first
+// The default partition and partitions not following the naming scheme are left out
+func List(ctx context.Context, db *sql.DB, table string) ([]Partition, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		JOIN pg_namespace n ON n.oid = p.relnamespace
+		WHERE n.nspname = 'admiral' AND p.relname = $1
+	`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of admiral.%s: %w", table, err)
+	}
+	defer rows.Close()
+
+	prefix := table + "_p"
+	partitions := []Partition{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan partition: %w", err)
+		}
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		day, err := time.ParseInLocation(dayLayout, strings.TrimPrefix(name, prefix), time.UTC)
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, Partition{Name: name, From: day, To: day.Add(Day)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].From.Before(partitions[j].From)
+	})
+	return partitions, nil
+}
+
+// Expired returns the partitions whose whole day is before cutoff
+func Expired(partitions []Partition, cutoff time.Time) []Partition {
+	expired := []Partition{}
+	for _, p := range partitions {
+		if !p.To.After(cutoff) {
+			expired = append(expired, p)
+		}
+	}
+	return expired
+}
+
+// Drop removes a partition with all of its rows
+func Drop(ctx context.Context, db *sql.DB, p Partition) error {
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS admiral."+p.Name); err != nil {
+		return fmt.Errorf("failed to drop partition admiral.%s: %w", p.Name, err)
+	}
+	return nil
+}
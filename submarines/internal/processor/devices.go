@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/nodepulse/admiral/submarines/internal/handlers"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// insertDeviceSnapshots upserts the per-mountpoint, per-interface and per-block-device
+// arrays of a node_exporter snapshot within a transaction
+// Redelivered stream messages overwrite the existing rows (unique per server, timestamp and device)
+func insertDeviceSnapshots(ctx context.Context, tx *sql.Tx, serverID string, snapshot *handlers.MetricSnapshot) (err error) {
+	ctx, span := startSpan(ctx, "insertDeviceSnapshots", attribute.String("db.system", "postgresql"))
+	defer func() { tracing.End(span, err) }()
+
+	filesystems := [][]any{}
+	seen := make(map[string]int)
+	for _, fs := range snapshot.Filesystems {
+		row := []any{serverID, snapshot.Timestamp, fs.Mountpoint, nullIfEmpty(fs.Device), nullIfEmpty(fs.FSType),
+			fs.TotalBytes, fs.FreeBytes, fs.AvailableBytes, fs.FilesTotal, fs.FilesFree}
+		filesystems = appendUnique(filesystems, seen, fs.Mountpoint, row)
+	}
+	if err := upsertDeviceRows(ctx, tx, "admiral.filesystem_snapshots", "mountpoint",
+		[]string{"device", "fstype", "total_bytes", "free_bytes", "available_bytes", "files_total", "files_free"},
+		filesystems); err != nil {
+		return err
+	}
+
+	interfaces := [][]any{}
+	seen = make(map[string]int)
+	for _, nic := range snapshot.NetworkInterfaces {
+		row := []any{serverID, snapshot.Timestamp, nic.Interface,
+			nic.ReceiveBytesTotal, nic.TransmitBytesTotal, nic.ReceivePacketsTotal, nic.TransmitPacketsTotal,
+			nic.ReceiveErrsTotal, nic.TransmitErrsTotal, nic.ReceiveDropTotal, nic.TransmitDropTotal}
+		interfaces = appendUnique(interfaces, seen, nic.Interface, row)
+	}
+	if err := upsertDeviceRows(ctx, tx, "admiral.network_interface_snapshots", "interface",
+		[]string{"receive_bytes_total", "transmit_bytes_total", "receive_packets_total", "transmit_packets_total",
+			"receive_errs_total", "transmit_errs_total", "receive_drop_total", "transmit_drop_total"},
+		interfaces); err != nil {
+		return err
+	}
+
+	devices := [][]any{}
+	seen = make(map[string]int)
+	for _, dev := range snapshot.BlockDevices {
+		row := []any{serverID, snapshot.Timestamp, dev.Device,
+			dev.ReadsCompletedTotal, dev.WritesCompletedTotal, dev.ReadBytesTotal, dev.WrittenBytesTotal,
+			dev.IOTimeSecondsTotal}
+		devices = appendUnique(devices, seen, dev.Device, row)
+	}
+	return upsertDeviceRows(ctx, tx, "admiral.block_device_snapshots", "device",
+		[]string{"reads_completed_total", "writes_completed_total", "read_bytes_total", "written_bytes_total",
+			"io_time_seconds_total"},
+		devices)
+}
+
+// appendUnique adds a row keyed by device name, replacing an earlier row with the same name
+// ON CONFLICT DO UPDATE cannot touch the same row twice in one statement
+// Rows with an empty name are dropped
+func appendUnique(rows [][]any, seen map[string]int, name string, row []any) [][]any {
+	if name == "" {
+		log.Printf("[WARN] Dropped device snapshot without a name")
+		return rows
+	}
+	if i, ok := seen[name]; ok {
+		rows[i] = row
+		return rows
+	}
+	seen[name] = len(rows)
+	return append(rows, row)
+}
+
+// upsertDeviceRows bulk upserts rows of (server_id, timestamp, <key>, <columns>...) into a device table
+func upsertDeviceRows(ctx context.Context, tx *sql.Tx, table, key string, columns []string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	allColumns := append([]string{"server_id", "timestamp", key}, columns...)
+	width := len(allColumns)
+
+	values := make([]string, 0, len(rows))
+	args := make([]any, 0, len(rows)*width)
+	for i, row := range rows {
+		params := make([]string, width)
+		for j := range params {
+			params[j] = fmt.Sprintf("$%d", i*width+j+1)
+		}
+		values = append(values, "("+strings.Join(params, ", ")+")")
+		args = append(args, row...)
+	}
+
+	updates := make([]string, len(columns))
+	for i, column := range columns {
+		updates[i] = column + " = EXCLUDED." + column
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (%s) VALUES %s
+		ON CONFLICT (server_id, timestamp, %s) DO UPDATE SET %s
+	`, table, strings.Join(allColumns, ", "), strings.Join(values, ", "), key, strings.Join(updates, ", "))
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to batch insert %d rows into %s: %w", len(rows), table, err)
+	}
+	return nil
+}
+
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/database"
+	"github.com/nodepulse/admiral/submarines/internal/events"
+	"github.com/nodepulse/admiral/submarines/internal/handlers"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxEventClockSkew is how far in the future an agent event may be stamped before
+// its occurred_at is replaced by the time it was processed (see maxLogClockSkew)
+const maxEventClockSkew = time.Hour
+
+// ProcessEventsWithTransaction stores agent events from nodepulse:events:stream in
+// admiral.server_events within a database transaction, skipping messages already
+// in the processed-message ledger and events whose agent ID was already stored
+func ProcessEventsWithTransaction(ctx context.Context, db *database.DB, stream, messageID, serverID string, payloadJSON string) (err error) {
+	ctx, span := startSpan(ctx, "ProcessEventsWithTransaction", attribute.String("server_id", serverID))
+	defer func() { tracing.End(span, err) }()
+
+	var batch []handlers.AgentEvent
+	if err := json.Unmarshal([]byte(payloadJSON), &batch); err != nil {
+		return fmt.Errorf("invalid events payload: %w", err)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	first, err := markProcessed(ctx, tx, stream, messageID)
+	if err != nil {
+		return err
+	}
+	if !first {
+		log.Printf("[INFO] Skipping already processed message %s from %s (server %s)", messageID, stream, serverID)
+		return nil
+	}
+
+	now := time.Now()
+	for i := range batch {
+		event := &batch[i]
+		if !events.IsAgentType(event.Type) {
+			log.Printf("[WARN] Dropped agent event with unsupported type %q from server %s", event.Type, serverID)
+			continue
+		}
+		if err := insertAgentEvent(ctx, tx, serverID, event, now); err != nil {
+			return err
+		}
+	}
+
+	return commit(ctx, tx)
+}
+
+// insertAgentEvent appends an agent event to admiral.server_events
+// A missing or far-future timestamp (agent clock skew) is replaced by now
+func insertAgentEvent(ctx context.Context, tx *sql.Tx, serverID string, event *handlers.AgentEvent, now time.Time) error {
+	occurredAt := event.Timestamp
+	if occurredAt.IsZero() || occurredAt.After(now.Add(maxEventClockSkew)) {
+		occurredAt = now
+	}
+
+	data := []byte("{}")
+	if trimmed := bytes.TrimSpace(event.Data); len(trimmed) > 0 && trimmed[0] == '{' {
+		data = trimmed
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO admiral.server_events (server_id, event_type, source, message, data, occurred_at, agent_event_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (server_id, agent_event_id) WHERE agent_event_id IS NOT NULL DO NOTHING
+	`, serverID, event.Type, events.SourceAgent, nullIfEmpty(event.Message), data, occurredAt, nullIfEmpty(event.ID))
+	if err != nil {
+		return fmt.Errorf("failed to record %s event: %w", event.Type, err)
+	}
+	return nil
+}
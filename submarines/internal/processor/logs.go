@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nodepulse/admiral/submarines/internal/database"
+	"github.com/nodepulse/admiral/submarines/internal/handlers"
+	"github.com/nodepulse/admiral/submarines/internal/logs"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// maxLogMessageBytes truncates very long lines (stack traces, dumped payloads)
+	maxLogMessageBytes = 8192
+
+	// maxLogClockSkew is how far in the future a line may be stamped before
+	// its timestamp is replaced by the time it was received
+	maxLogClockSkew = time.Hour
+
+	// logInsertChunk bounds the rows per INSERT (8 parameters each, limit is 65535)
+	logInsertChunk = 1000
+)
+
+// ProcessLogsWithTransaction writes a batch of log lines from nodepulse:logs:stream
+// within a database transaction, skipping messages already in the processed-message ledger
+func ProcessLogsWithTransaction(ctx context.Context, db *database.DB, stream, messageID, serverID string, payloadJSON string) (err error) {
+	ctx, span := startSpan(ctx, "ProcessLogsWithTransaction", attribute.String("server_id", serverID))
+	defer func() { tracing.End(span, err) }()
+
+	var lines []handlers.LogLine
+	if err := json.Unmarshal([]byte(payloadJSON), &lines); err != nil {
+		return fmt.Errorf("invalid log payload: %w", err)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	first, err := markProcessed(ctx, tx, stream, messageID)
+	if err != nil {
+		return err
+	}
+	if !first {
+		log.Printf("[INFO] Skipping already processed message %s from %s (server %s)", messageID, stream, serverID)
+		return nil
+	}
+
+	now := time.Now()
+	for start := 0; start < len(lines); start += logInsertChunk {
+		end := min(start+logInsertChunk, len(lines))
+		if err := insertLogLines(ctx, tx, serverID, lines[start:end], now); err != nil {
+			return err
+		}
+	}
+
+	return commit(ctx, tx)
+}
+
+// insertLogLines bulk inserts log lines, normalizing timestamps, severities and message length
+func insertLogLines(ctx context.Context, tx *sql.Tx, serverID string, lines []handlers.LogLine, now time.Time) (err error) {
+	ctx, span := startSpan(ctx, "insertLogLines", attribute.String("db.system", "postgresql"))
+	defer func() { tracing.End(span, err) }()
+
+	values := []string{}
+	args := []any{}
+
+	for _, line := range lines {
+		if strings.TrimSpace(line.Message) == "" {
+			continue
+		}
+
+		timestamp := line.Timestamp
+		if timestamp.IsZero() || timestamp.After(now.Add(maxLogClockSkew)) {
+			timestamp = now
+		}
+
+		source := line.Source
+		if source != logs.SourceSyslog {
+			source = logs.SourceJournald
+		}
+
+		var severity any
+		if line.Severity != nil && *line.Severity >= 0 && *line.Severity <= 7 {
+			severity = *line.Severity
+		}
+		var pid any
+		if line.PID != nil {
+			pid = *line.PID
+		}
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+		args = append(args, serverID, timestamp, source, severity,
+			nullIfEmpty(line.Unit), nullIfEmpty(line.Hostname), pid, truncateMessage(line.Message))
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO admiral.logs (server_id, timestamp, source, severity, unit, hostname, pid, message)
+		VALUES ` + strings.Join(values, ", ")
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert %d log lines: %w", len(values), err)
+	}
+	return nil
+}
+
+// truncateMessage cuts a message to maxLogMessageBytes without splitting a UTF-8 sequence
+// Postgres rejects NUL bytes and invalid UTF-8 in text, so those are stripped or replaced
+func truncateMessage(message string) string {
+	message = strings.ToValidUTF8(strings.ReplaceAll(message, "\x00", ""), "\uFFFD")
+	if len(message) <= maxLogMessageBytes {
+		return message
+	}
+	cut := maxLogMessageBytes
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + "…"
+}
@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+)
+
+// inventoryField pairs an admiral.servers column with the value reported by the agent
+type inventoryField struct {
+	column   string
+	current  sql.NullString
+	reported string
+}
+
+// processSystemInfo updates admiral.servers inventory columns from the optional
+// system_info payload section and records every change in admiral.server_inventory_changes
+// Empty values in the payload are ignored so a partial report never wipes existing data
+func processSystemInfo(ctx context.Context, tx *sql.Tx, serverID string, rawData json.RawMessage) (err error) {
+	ctx, span := startSpan(ctx, "processSystemInfo")
+	defer func() { tracing.End(span, err) }()
+
+	var info models.SystemInfo
+	if err := json.Unmarshal(rawData, &info); err != nil {
+		return fmt.Errorf("failed to parse system_info data: %w", err)
+	}
+
+	// Lock the server row so concurrent digest workers don't record the same change twice
+	query := `
+		SELECT hostname, kernel, kernel_version, distro, distro_version,
+		       architecture, cpu_cores::text, ipv4, ipv6
+		FROM admiral.servers
+		WHERE server_id = $1
+		FOR UPDATE
+	`
+
+	fields := []*inventoryField{
+		{column: "hostname", reported: info.Hostname},
+		{column: "kernel", reported: info.Kernel},
+		{column: "kernel_version", reported: info.KernelVersion},
+		{column: "distro", reported: info.Distro},
+		{column: "distro_version", reported: info.DistroVersion},
+		{column: "architecture", reported: info.Architecture},
+		{column: "cpu_cores"},
+		{column: "ipv4", reported: info.IPv4},
+		{column: "ipv6", reported: info.IPv6},
+	}
+	if info.CPUCores > 0 {
+		fields[6].reported = strconv.Itoa(info.CPUCores)
+	}
+
+	err = tx.QueryRowContext(ctx, query, serverID).Scan(
+		&fields[0].current, &fields[1].current, &fields[2].current,
+		&fields[3].current, &fields[4].current, &fields[5].current,
+		&fields[6].current, &fields[7].current, &fields[8].current,
+	)
+	if err == sql.ErrNoRows {
+		log.Printf("[WARN] Server %s not found in database, system_info not applied", serverID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read current inventory: %w", err)
+	}
+
+	// Collect changed columns
+	setClauses := []string{}
+	args := []any{}
+	changed := []*inventoryField{}
+
+	for _, field := range fields {
+		reported := strings.TrimSpace(field.reported)
+		if reported == "" {
+			continue
+		}
+		if field.current.Valid && field.current.String == reported {
+			continue
+		}
+
+		args = append(args, reported)
+		if field.column == "cpu_cores" {
+			setClauses = append(setClauses, fmt.Sprintf("%s = $%d::integer", field.column, len(args)))
+		} else {
+			setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field.column, len(args)))
+		}
+		field.reported = reported
+		changed = append(changed, field)
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	args = append(args, serverID)
+	updateQuery := fmt.Sprintf(`
+		UPDATE admiral.servers
+		SET %s, updated_at = NOW()
+		WHERE server_id = $%d
+	`, strings.Join(setClauses, ", "), len(args))
+
+	if _, err := tx.ExecContext(ctx, updateQuery, args...); err != nil {
+		return fmt.Errorf("failed to update server inventory: %w", err)
+	}
+
+	// Record history (one row per changed column)
+	historyQuery := `
+		INSERT INTO admiral.server_inventory_changes (server_id, field, old_value, new_value)
+		VALUES ($1, $2, $3, $4)
+	`
+	for _, field := range changed {
+		if _, err := tx.ExecContext(ctx, historyQuery, serverID, field.column, field.current, field.reported); err != nil {
+			return fmt.Errorf("failed to record %s change: %w", field.column, err)
+		}
+		log.Printf("[INFO] Server %s inventory change: %s %q -> %q",
+			serverID, field.column, field.current.String, field.reported)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+)
+
+// admiral.server_events event types recorded for systemd units
+const (
+	EventTypeServiceStateChange = "service_state_change"
+	EventTypeServiceRestart     = "service_restart"
+)
+
+// serviceState is a row of admiral.service_states
+type serviceState struct {
+	activeState string
+	subState    sql.NullString
+	restarts    int
+	reportedAt  time.Time
+}
+
+// processSystemd upserts admiral.service_states from the systemd payload section
+// and records state changes and restarts of known units in admiral.server_events
+// Units missing from a report are left untouched (agents may only report a subset)
+func processSystemd(ctx context.Context, tx *sql.Tx, serverID string, rawData json.RawMessage) (err error) {
+	ctx, span := startSpan(ctx, "processSystemd")
+	defer func() { tracing.End(span, err) }()
+
+	var units []models.SystemdUnit
+	if err := json.Unmarshal(rawData, &units); err != nil {
+		return fmt.Errorf("failed to parse systemd data: %w", err)
+	}
+
+	units = latestUnits(units)
+	if len(units) == 0 {
+		return nil
+	}
+
+	current, err := loadServiceStates(ctx, tx, serverID)
+	if err != nil {
+		return err
+	}
+
+	for i := range units {
+		unit := &units[i]
+		prev, known := current[unit.Name]
+		if known && unit.Timestamp.Before(prev.reportedAt) {
+			continue // Older than what we already have
+		}
+
+		if err := upsertServiceState(ctx, tx, serverID, unit); err != nil {
+			return err
+		}
+		if known {
+			if err := recordServiceEvents(ctx, tx, serverID, prev, unit); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// latestUnits keeps the newest report of each unit, dropping entries without a name or state
+func latestUnits(units []models.SystemdUnit) []models.SystemdUnit {
+	index := make(map[string]int, len(units))
+	result := make([]models.SystemdUnit, 0, len(units))
+
+	for _, unit := range units {
+		if unit.Name == "" || unit.ActiveState == "" {
+			continue
+		}
+		if i, ok := index[unit.Name]; ok {
+			if !unit.Timestamp.Before(result[i].Timestamp) {
+				result[i] = unit
+			}
+			continue
+		}
+		index[unit.Name] = len(result)
+		result = append(result, unit)
+	}
+
+	if dropped := len(units) - len(result); dropped > 0 {
+		log.Printf("[WARN] Dropped %d duplicate or incomplete systemd units from payload", dropped)
+	}
+
+	return result
+}
+
+// loadServiceStates locks and reads the known units of a server
+// The lock keeps concurrent digest workers from recording the same transition twice
+func loadServiceStates(ctx context.Context, tx *sql.Tx, serverID string) (map[string]*serviceState, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT unit_name, active_state, sub_state, restarts, reported_at
+		FROM admiral.service_states
+		WHERE server_id = $1
+		FOR UPDATE
+	`, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service states: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]*serviceState)
+	for rows.Next() {
+		var name string
+		s := &serviceState{}
+		if err := rows.Scan(&name, &s.activeState, &s.subState, &s.restarts, &s.reportedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan service state: %w", err)
+		}
+		states[name] = s
+	}
+
+	return states, rows.Err()
+}
+
+// upsertServiceState stores the latest state of a unit
+func upsertServiceState(ctx context.Context, tx *sql.Tx, serverID string, unit *models.SystemdUnit) error {
+	var stateChangedAt any
+	if unit.StateChangedAt != nil {
+		stateChangedAt = *unit.StateChangedAt
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO admiral.service_states (
+			server_id, unit_name, load_state, active_state, sub_state, restarts, state_changed_at, reported_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (server_id, unit_name) DO UPDATE SET
+			load_state = EXCLUDED.load_state,
+			active_state = EXCLUDED.active_state,
+			sub_state = EXCLUDED.sub_state,
+			restarts = EXCLUDED.restarts,
+			state_changed_at = EXCLUDED.state_changed_at,
+			reported_at = EXCLUDED.reported_at
+	`, serverID, unit.Name, nullIfEmpty(unit.LoadState), unit.ActiveState, nullIfEmpty(unit.SubState),
+		unit.Restarts, stateChangedAt, unit.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to upsert state of unit %s: %w", unit.Name, err)
+	}
+	return nil
+}
+
+// recordServiceEvents compares a unit with its previous state and records
+// a service_state_change event, or a service_restart event when only the restart counter grew
+// (a crash-looping unit can be caught in the same state between two reports)
+func recordServiceEvents(ctx context.Context, tx *sql.Tx, serverID string, prev *serviceState, unit *models.SystemdUnit) error {
+	from := describeState(prev.activeState, prev.subState.String)
+	to := describeState(unit.ActiveState, unit.SubState)
+
+	occurredAt := unit.Timestamp
+	if unit.StateChangedAt != nil && !unit.StateChangedAt.IsZero() {
+		occurredAt = *unit.StateChangedAt
+	}
+
+	data := map[string]any{
+		"unit":              unit.Name,
+		"from_active_state": prev.activeState,
+		"from_sub_state":    prev.subState.String,
+		"to_active_state":   unit.ActiveState,
+		"to_sub_state":      unit.SubState,
+		"restarts":          unit.Restarts,
+		"previous_restarts": prev.restarts,
+	}
+
+	switch {
+	case from != to:
+		message := fmt.Sprintf("Unit %s changed from %s to %s", unit.Name, from, to)
+		if err := insertServerEvent(ctx, tx, serverID, EventTypeServiceStateChange, message, data, occurredAt); err != nil {
+			return err
+		}
+		log.Printf("[INFO] Server %s unit %s: %s -> %s", serverID, unit.Name, from, to)
+
+	case unit.Restarts > prev.restarts:
+		message := fmt.Sprintf("Unit %s restarted %d time(s) (%s)", unit.Name, unit.Restarts-prev.restarts, to)
+		if err := insertServerEvent(ctx, tx, serverID, EventTypeServiceRestart, message, data, unit.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// describeState formats a unit state as "active (running)"
+func describeState(activeState, subState string) string {
+	if subState == "" || strings.EqualFold(subState, activeState) {
+		return activeState
+	}
+	return activeState + " (" + subState + ")"
+}
+
+// insertServerEvent appends a row to admiral.server_events
+func insertServerEvent(ctx context.Context, tx *sql.Tx, serverID, eventType, message string, data map[string]any, occurredAt time.Time) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO admiral.server_events (server_id, event_type, source, message, data, occurred_at)
+		VALUES ($1, $2, 'admiral', $3, $4, $5)
+	`, serverID, eventType, message, payload, occurredAt)
+	if err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+	return nil
+}
@@ -7,14 +7,28 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/nodepulse/admiral/submarines/internal/database"
 	"github.com/nodepulse/admiral/submarines/internal/handlers"
+	"github.com/nodepulse/admiral/submarines/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxSnapshotClockSkew is how far in the future a metric or process snapshot may be
+// stamped before its timestamp is replaced by the time it was processed (see
+// maxLogClockSkew); rows further ahead would land in the default partition
+const maxSnapshotClockSkew = time.Hour
+
 // ProcessMessageWithTransaction processes a message within a database transaction
 // This ensures atomicity - either all data is saved, or none of it is (rollback)
-func ProcessMessageWithTransaction(ctx context.Context, db *database.DB, serverID string, payloadJSON string) error {
+// The stream message ID is recorded in the same transaction, so a message that was already
+// committed (e.g. redelivered after a crash before XACK) is skipped and nil is returned
+func ProcessMessageWithTransaction(ctx context.Context, db *database.DB, stream, messageID, serverID string, payloadJSON string) (err error) {
+	ctx, span := startSpan(ctx, "ProcessMessageWithTransaction", attribute.String("server_id", serverID))
+	defer func() { tracing.End(span, err) }()
+
 	// Start transaction
 	tx, err := db.DB.BeginTx(ctx, nil)
 	if err != nil {
@@ -22,11 +36,22 @@ func ProcessMessageWithTransaction(ctx context.Context, db *database.DB, serverI
 	}
 	defer tx.Rollback() // Safe to call even after commit
 
-	// Parse grouped payload: { "node_exporter": [...], "process_exporter": [...] }
+	// Claim the message in the ledger first - covers every exporter below
+	first, err := markProcessed(ctx, tx, stream, messageID)
+	if err != nil {
+		return err
+	}
+	if !first {
+		log.Printf("[INFO] Skipping already processed message %s from %s (server %s)", messageID, stream, serverID)
+		return nil
+	}
+
+	// Parse grouped payload: { "node_exporter": [...], "process_exporter": [...], "system_info": {...}, "systemd": [...] }
 	var groupedPayload map[string]json.RawMessage
 	if err := json.Unmarshal([]byte(payloadJSON), &groupedPayload); err != nil {
 		return fmt.Errorf("invalid JSON payload: %w", err)
 	}
+	span.SetAttributes(attribute.Int("payload_bytes", len(payloadJSON)))
 
 	// Process each exporter type within the transaction
 	// If ANY exporter fails, the entire transaction rolls back
@@ -44,6 +69,16 @@ func ProcessMessageWithTransaction(ctx context.Context, db *database.DB, serverI
 				return fmt.Errorf("failed to process process_exporter: %w", err)
 			}
 
+		case "system_info":
+			if err := processSystemInfo(ctx, tx, serverID, rawData); err != nil {
+				return fmt.Errorf("failed to process system_info: %w", err)
+			}
+
+		case "systemd":
+			if err := processSystemd(ctx, tx, serverID, rawData); err != nil {
+				return fmt.Errorf("failed to process systemd: %w", err)
+			}
+
 		default:
 			log.Printf("[WARN] Unknown exporter type: %s", exporterName)
 		}
@@ -55,32 +90,78 @@ func ProcessMessageWithTransaction(ctx context.Context, db *database.DB, serverI
 	}
 
 	// Commit transaction - only if everything succeeded
+	return commit(ctx, tx)
+}
+
+// startSpan starts a child span of the message trace (see tracing.Extract in digest)
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// commit commits a message transaction in its own span, so commit latency is visible
+func commit(ctx context.Context, tx *sql.Tx) (err error) {
+	_, span := startSpan(ctx, "COMMIT", attribute.String("db.system", "postgresql"))
+	defer func() { tracing.End(span, err) }()
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
-
 	return nil
 }
 
+// markProcessed records a stream message in admiral.processed_messages
+// Returns false when the message was already committed
+// A concurrent transaction holding the same message blocks here until it commits or rolls back
+func markProcessed(ctx context.Context, tx *sql.Tx, stream, messageID string) (first bool, err error) {
+	ctx, span := startSpan(ctx, "markProcessed", attribute.String("db.system", "postgresql"))
+	defer func() { tracing.End(span, err) }()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO admiral.processed_messages (stream, message_id)
+		VALUES ($1, $2)
+		ON CONFLICT (stream, message_id) DO NOTHING
+	`, stream, messageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed message %s: %w", messageID, err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed message %s: %w", messageID, err)
+	}
+	return inserted == 1, nil
+}
+
 // processNodeExporter handles node_exporter metrics within a transaction
-func processNodeExporter(ctx context.Context, tx *sql.Tx, serverID string, rawData json.RawMessage) error {
+func processNodeExporter(ctx context.Context, tx *sql.Tx, serverID string, rawData json.RawMessage) (err error) {
+	ctx, span := startSpan(ctx, "processNodeExporter")
+	defer func() { tracing.End(span, err) }()
+
 	var snapshots []handlers.MetricSnapshot
 	if err := json.Unmarshal(rawData, &snapshots); err != nil {
 		return fmt.Errorf("failed to parse node_exporter data: %w", err)
 	}
 
 	// Insert each snapshot
+	now := time.Now()
 	for _, snapshot := range snapshots {
+		snapshot.Timestamp = clampTimestamp(snapshot.Timestamp, now)
 		if err := insertMetricSnapshot(ctx, tx, serverID, &snapshot); err != nil {
 			return fmt.Errorf("failed to insert node_exporter snapshot: %w", err)
 		}
+		if err := insertDeviceSnapshots(ctx, tx, serverID, &snapshot); err != nil {
+			return fmt.Errorf("failed to insert node_exporter device snapshots: %w", err)
+		}
 	}
 
 	return nil
 }
 
 // processProcessExporter handles process_exporter metrics within a transaction
-func processProcessExporter(ctx context.Context, tx *sql.Tx, serverID string, rawData json.RawMessage) error {
+func processProcessExporter(ctx context.Context, tx *sql.Tx, serverID string, rawData json.RawMessage) (err error) {
+	ctx, span := startSpan(ctx, "processProcessExporter")
+	defer func() { tracing.End(span, err) }()
+
 	var processSnapshots []handlers.ProcessSnapshot
 	if err := json.Unmarshal(rawData, &processSnapshots); err != nil {
 		return fmt.Errorf("failed to parse process_exporter data: %w", err)
@@ -94,8 +175,13 @@ func processProcessExporter(ctx context.Context, tx *sql.Tx, serverID string, ra
 	return insertProcessSnapshotsBatch(ctx, tx, serverID, processSnapshots)
 }
 
-// insertMetricSnapshot inserts a single node_exporter snapshot within a transaction
-func insertMetricSnapshot(ctx context.Context, tx *sql.Tx, serverID string, snapshot *handlers.MetricSnapshot) error {
+// insertMetricSnapshot upserts a single node_exporter snapshot within a transaction
+// Redelivered stream messages hit the (server_id, timestamp) constraint and overwrite
+// the existing row instead of creating a duplicate
+func insertMetricSnapshot(ctx context.Context, tx *sql.Tx, serverID string, snapshot *handlers.MetricSnapshot) (err error) {
+	ctx, span := startSpan(ctx, "insertMetricSnapshot", attribute.String("db.system", "postgresql"))
+	defer func() { tracing.End(span, err) }()
+
 	query := `
 		INSERT INTO admiral.metrics (
 			server_id,
@@ -145,9 +231,49 @@ func insertMetricSnapshot(ctx context.Context, tx *sql.Tx, serverID string, snap
 			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
 			$31, $32, $33, $34, $35, $36, $37, $38, $39, $40, $41
 		)
+		ON CONFLICT (server_id, timestamp) DO UPDATE SET
+			cpu_idle_seconds = EXCLUDED.cpu_idle_seconds,
+			cpu_iowait_seconds = EXCLUDED.cpu_iowait_seconds,
+			cpu_system_seconds = EXCLUDED.cpu_system_seconds,
+			cpu_user_seconds = EXCLUDED.cpu_user_seconds,
+			cpu_steal_seconds = EXCLUDED.cpu_steal_seconds,
+			cpu_cores = EXCLUDED.cpu_cores,
+			memory_total_bytes = EXCLUDED.memory_total_bytes,
+			memory_available_bytes = EXCLUDED.memory_available_bytes,
+			memory_free_bytes = EXCLUDED.memory_free_bytes,
+			memory_cached_bytes = EXCLUDED.memory_cached_bytes,
+			memory_buffers_bytes = EXCLUDED.memory_buffers_bytes,
+			memory_active_bytes = EXCLUDED.memory_active_bytes,
+			memory_inactive_bytes = EXCLUDED.memory_inactive_bytes,
+			swap_total_bytes = EXCLUDED.swap_total_bytes,
+			swap_free_bytes = EXCLUDED.swap_free_bytes,
+			swap_cached_bytes = EXCLUDED.swap_cached_bytes,
+			disk_total_bytes = EXCLUDED.disk_total_bytes,
+			disk_free_bytes = EXCLUDED.disk_free_bytes,
+			disk_available_bytes = EXCLUDED.disk_available_bytes,
+			disk_reads_completed_total = EXCLUDED.disk_reads_completed_total,
+			disk_writes_completed_total = EXCLUDED.disk_writes_completed_total,
+			disk_read_bytes_total = EXCLUDED.disk_read_bytes_total,
+			disk_written_bytes_total = EXCLUDED.disk_written_bytes_total,
+			disk_io_time_seconds_total = EXCLUDED.disk_io_time_seconds_total,
+			network_receive_bytes_total = EXCLUDED.network_receive_bytes_total,
+			network_transmit_bytes_total = EXCLUDED.network_transmit_bytes_total,
+			network_receive_packets_total = EXCLUDED.network_receive_packets_total,
+			network_transmit_packets_total = EXCLUDED.network_transmit_packets_total,
+			network_receive_errs_total = EXCLUDED.network_receive_errs_total,
+			network_transmit_errs_total = EXCLUDED.network_transmit_errs_total,
+			network_receive_drop_total = EXCLUDED.network_receive_drop_total,
+			network_transmit_drop_total = EXCLUDED.network_transmit_drop_total,
+			load_1min = EXCLUDED.load_1min,
+			load_5min = EXCLUDED.load_5min,
+			load_15min = EXCLUDED.load_15min,
+			processes_running = EXCLUDED.processes_running,
+			processes_blocked = EXCLUDED.processes_blocked,
+			processes_total = EXCLUDED.processes_total,
+			uptime_seconds = EXCLUDED.uptime_seconds
 	`
 
-	_, err := tx.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		serverID,
 		snapshot.Timestamp,
 		snapshot.CPUIdleSeconds,
@@ -194,8 +320,21 @@ func insertMetricSnapshot(ctx context.Context, tx *sql.Tx, serverID string, snap
 	return err
 }
 
-// insertProcessSnapshotsBatch performs bulk insert of process snapshots within a transaction
-func insertProcessSnapshotsBatch(ctx context.Context, tx *sql.Tx, serverID string, snapshots []handlers.ProcessSnapshot) error {
+// insertProcessSnapshotsBatch performs bulk upsert of process snapshots within a transaction
+// Redelivered stream messages hit the (server_id, timestamp, process_name) constraint
+// and overwrite the existing rows instead of creating duplicates
+func insertProcessSnapshotsBatch(ctx context.Context, tx *sql.Tx, serverID string, snapshots []handlers.ProcessSnapshot) (err error) {
+	ctx, span := startSpan(ctx, "insertProcessSnapshotsBatch", attribute.String("db.system", "postgresql"))
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now()
+	for i := range snapshots {
+		snapshots[i].Timestamp = clampTimestamp(snapshots[i].Timestamp, now)
+	}
+
+	// ON CONFLICT DO UPDATE cannot touch the same row twice in one statement,
+	// so collapse duplicates within the payload first (clamped snapshots included)
+	snapshots = dedupeProcessSnapshots(snapshots)
 	if len(snapshots) == 0 {
 		return nil
 	}
@@ -235,9 +374,16 @@ func insertProcessSnapshotsBatch(ctx context.Context, tx *sql.Tx, serverID strin
 
 	// Concatenate VALUES clauses
 	query += strings.Join(values, ", ")
+	query += `
+		ON CONFLICT (server_id, timestamp, process_name) DO UPDATE SET
+			num_procs = EXCLUDED.num_procs,
+			cpu_seconds_total = EXCLUDED.cpu_seconds_total,
+			memory_bytes = EXCLUDED.memory_bytes
+	`
 
 	// Execute bulk insert
-	_, err := tx.ExecContext(ctx, query, args...)
+	span.SetAttributes(attribute.Int("rows", len(snapshots)))
+	_, err = tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to batch insert %d process snapshots: %w", len(snapshots), err)
 	}
@@ -245,8 +391,51 @@ func insertProcessSnapshotsBatch(ctx context.Context, tx *sql.Tx, serverID strin
 	return nil
 }
 
+// clampTimestamp replaces a missing timestamp, or one more than maxSnapshotClockSkew
+// ahead of now (agent clock skew), with now
+func clampTimestamp(timestamp, now time.Time) time.Time {
+	if timestamp.IsZero() || timestamp.After(now.Add(maxSnapshotClockSkew)) {
+		return now
+	}
+	return timestamp
+}
+
+// dedupeProcessSnapshots keeps the last snapshot for each (timestamp, name) pair
+// while preserving the original order of first appearance. Timestamps are
+// truncated to Postgres' microsecond precision first, otherwise two samples
+// that differ below 1µs would collide in the same ON CONFLICT statement.
+func dedupeProcessSnapshots(snapshots []handlers.ProcessSnapshot) []handlers.ProcessSnapshot {
+	type key struct {
+		timestamp int64
+		name      string
+	}
+
+	index := make(map[key]int, len(snapshots))
+	result := make([]handlers.ProcessSnapshot, 0, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		snapshot.Timestamp = snapshot.Timestamp.Truncate(time.Microsecond)
+		k := key{timestamp: snapshot.Timestamp.UnixMicro(), name: snapshot.Name}
+		if i, ok := index[k]; ok {
+			result[i] = snapshot
+			continue
+		}
+		index[k] = len(result)
+		result = append(result, snapshot)
+	}
+
+	if dropped := len(snapshots) - len(result); dropped > 0 {
+		log.Printf("[WARN] Dropped %d duplicate process snapshots from payload", dropped)
+	}
+
+	return result
+}
+
 // updateServerLastSeen updates the server's last_seen_at timestamp within a transaction
-func updateServerLastSeen(ctx context.Context, tx *sql.Tx, serverID string) error {
+func updateServerLastSeen(ctx context.Context, tx *sql.Tx, serverID string) (err error) {
+	ctx, span := startSpan(ctx, "updateServerLastSeen", attribute.String("db.system", "postgresql"))
+	defer func() { tracing.End(span, err) }()
+
 	query := `
 		UPDATE admiral.servers
 		SET last_seen_at = NOW(), updated_at = NOW()
@@ -0,0 +1,670 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// readBlock is how long XReadGroup waits for new messages, as the Valkey backend's BLOCK
+	readBlock = 5 * time.Second
+
+	// readPoll is how often a waiting XReadGroup checks for new messages
+	readPoll = 100 * time.Millisecond
+
+	// lockTimeout bounds the wait for another process holding the database
+	lockTimeout = 10 * time.Second
+)
+
+var (
+	keyEntries = []byte("entries") // id -> JSON fields
+	keyGroups  = []byte("groups")  // group -> {last, pending}
+	keyPending = []byte("pending") // id -> JSON pendingEntry
+	keyLast    = []byte("last")    // Last generated (stream) or delivered (group) ID
+	keyLength  = []byte("length")
+)
+
+// errNoStream is returned for group operations on a stream that doesn't exist (like Valkey's NOGROUP)
+var errNoStream = errors.New("no such stream or consumer group")
+
+// pendingEntry is a delivered but unacknowledged message of a group
+type pendingEntry struct {
+	Consumer    string `json:"consumer"`
+	Deliveries  int64  `json:"deliveries"`
+	DeliveredAt int64  `json:"delivered_at"` // Unix milliseconds
+}
+
+// Embedded is a Queue stored in a bolt database file
+// Every stream is a bucket of entries keyed by their ID, with its consumer groups'
+// positions and pending entries lists alongside, so semantics match Valkey streams
+//
+// The file is opened per operation rather than held open: bolt's file lock then lets
+// several services on one host (e.g. ingest and digest sharing a volume) use the same
+// queue, one operation at a time. Waiting reads poll with read-only opens, which share
+// the lock, and only take the exclusive lock once there is something to deliver
+type Embedded struct {
+	path string
+}
+
+// OpenEmbedded creates (or opens) a queue database file
+func OpenEmbedded(path string) (*Embedded, error) {
+	if path == "" {
+		return nil, fmt.Errorf("embedded queue path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	e := &Embedded{path: path}
+	if err := e.Ping(context.Background()); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Opened embedded queue %s", path)
+	return e, nil
+}
+
+// XAdd appends a message, IDs are <unix ms>-<sequence> as in Valkey
+func (e *Embedded) XAdd(ctx context.Context, stream string, values map[string]string) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	var id string
+	err = e.update(ctx, func(tx *bolt.Tx) error {
+		sb, err := createStream(tx, stream)
+		if err != nil {
+			return err
+		}
+
+		ms, seq := uint64(time.Now().UnixMilli()), uint64(0)
+		if last := sb.Get(keyLast); last != nil {
+			lastMs, lastSeq := decodeID(last)
+			if ms <= lastMs {
+				ms, seq = lastMs, lastSeq+1
+			}
+		}
+		key := encodeID(ms, seq)
+
+		if err := sb.Bucket(keyEntries).Put(key, data); err != nil {
+			return err
+		}
+		if err := sb.Put(keyLast, key); err != nil {
+			return err
+		}
+		if err := addLength(sb, 1); err != nil {
+			return err
+		}
+		id = formatID(key)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to add to stream %s: %w", stream, err)
+	}
+	return id, nil
+}
+
+// XLen returns the number of entries in a stream (0 if it doesn't exist)
+func (e *Embedded) XLen(ctx context.Context, stream string) (int64, error) {
+	var n int64
+	err := e.view(ctx, func(tx *bolt.Tx) error {
+		if sb := tx.Bucket(streamKey(stream)); sb != nil {
+			n = length(sb)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream length: %w", err)
+	}
+	return n, nil
+}
+
+// XGroupCreate creates a consumer group, doing nothing if it already exists
+func (e *Embedded) XGroupCreate(ctx context.Context, stream, group string, startID string) error {
+	created := false
+	err := e.update(ctx, func(tx *bolt.Tx) error {
+		sb, err := createStream(tx, stream)
+		if err != nil {
+			return err
+		}
+		groups := sb.Bucket(keyGroups)
+		if groups.Bucket([]byte(group)) != nil {
+			return nil
+		}
+
+		gb, err := groups.CreateBucket([]byte(group))
+		if err != nil {
+			return err
+		}
+		if _, err := gb.CreateBucket(keyPending); err != nil {
+			return err
+		}
+
+		start := encodeID(0, 0)
+		switch startID {
+		case "$":
+			if last := sb.Get(keyLast); last != nil {
+				start = last
+			}
+		case "0", "0-0":
+		default:
+			if start, err = parseID(startID); err != nil {
+				return err
+			}
+		}
+		created = true
+		return gb.Put(keyLast, start)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	if created {
+		log.Printf("Created consumer group %s for stream %s", group, stream)
+	} else {
+		log.Printf("Consumer group %s already exists for stream %s", group, stream)
+	}
+	return nil
+}
+
+// XReadGroup reads new (">") or the consumer's pending ("0") messages
+// New reads wait up to readBlock for messages to arrive
+func (e *Embedded) XReadGroup(ctx context.Context, group, consumer, stream, id string, count int64) ([]Message, error) {
+	if id != ">" {
+		return e.readPending(ctx, group, consumer, stream, count)
+	}
+
+	deadline := time.Now().Add(readBlock)
+	for {
+		ready, err := e.hasNew(ctx, group, stream)
+		if err != nil {
+			return nil, err
+		}
+		if ready {
+			messages, err := e.readNew(ctx, group, consumer, stream, count)
+			if err != nil || len(messages) > 0 {
+				return messages, err
+			}
+		}
+		if time.Now().After(deadline) {
+			return []Message{}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return []Message{}, nil
+		case <-time.After(readPoll):
+		}
+	}
+}
+
+// hasNew reports whether entries were added after the group's last delivered ID
+func (e *Embedded) hasNew(ctx context.Context, group, stream string) (bool, error) {
+	ready := false
+	err := e.view(ctx, func(tx *bolt.Tx) error {
+		sb, gb, err := groupBucket(tx, stream, group)
+		if err != nil {
+			return err
+		}
+		ready = bytes.Compare(sb.Get(keyLast), gb.Get(keyLast)) > 0
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read from stream %s: %w", stream, err)
+	}
+	return ready, nil
+}
+
+// readNew delivers entries after the group's last delivered ID to a consumer
+func (e *Embedded) readNew(ctx context.Context, group, consumer, stream string, count int64) ([]Message, error) {
+	messages := []Message{}
+	err := e.update(ctx, func(tx *bolt.Tx) error {
+		sb, gb, err := groupBucket(tx, stream, group)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		pending := gb.Bucket(keyPending)
+		last := gb.Get(keyLast)
+
+		c := sb.Bucket(keyEntries).Cursor()
+		var lastRead []byte
+		for k, v := c.Seek(last); k != nil && int64(len(messages)) < count; k, v = c.Next() {
+			if bytes.Equal(k, last) {
+				continue
+			}
+			msg, err := decodeMessage(k, v)
+			if err != nil {
+				return err
+			}
+			if err := putPending(pending, k, pendingEntry{Consumer: consumer, Deliveries: 1, DeliveredAt: now}); err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+			lastRead = append([]byte(nil), k...)
+		}
+
+		if lastRead == nil {
+			return nil
+		}
+		return gb.Put(keyLast, lastRead)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stream %s: %w", stream, err)
+	}
+	return messages, nil
+}
+
+// readPending redelivers a consumer's pending messages, oldest first
+// Messages deleted from the stream meanwhile are returned with nil fields, as Valkey does
+func (e *Embedded) readPending(ctx context.Context, group, consumer, stream string, count int64) ([]Message, error) {
+	messages := []Message{}
+	err := e.update(ctx, func(tx *bolt.Tx) error {
+		sb, gb, err := groupBucket(tx, stream, group)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		entries := sb.Bucket(keyEntries)
+		pending := gb.Bucket(keyPending)
+
+		c := pending.Cursor()
+		for k, v := c.First(); k != nil && int64(len(messages)) < count; k, v = c.Next() {
+			var p pendingEntry
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			if p.Consumer != consumer {
+				continue
+			}
+
+			msg := Message{ID: formatID(k)}
+			if data := entries.Get(k); data != nil {
+				if msg, err = decodeMessage(k, data); err != nil {
+					return err
+				}
+			}
+
+			p.Deliveries++
+			p.DeliveredAt = now
+			if err := putPending(pending, k, p); err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stream %s: %w", stream, err)
+	}
+	return messages, nil
+}
+
+// XAck removes messages from a group's pending entries list
+func (e *Embedded) XAck(ctx context.Context, stream, group string, ids ...string) error {
+	return e.update(ctx, func(tx *bolt.Tx) error {
+		_, gb, err := groupBucket(tx, stream, group)
+		if err != nil {
+			return err
+		}
+		pending := gb.Bucket(keyPending)
+		for _, id := range ids {
+			key, err := parseID(id)
+			if err != nil {
+				return err
+			}
+			if err := pending.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// XDel deletes messages from a stream
+func (e *Embedded) XDel(ctx context.Context, stream string, ids ...string) error {
+	return e.update(ctx, func(tx *bolt.Tx) error {
+		sb := tx.Bucket(streamKey(stream))
+		if sb == nil {
+			return nil
+		}
+		entries := sb.Bucket(keyEntries)
+		deleted := int64(0)
+		for _, id := range ids {
+			key, err := parseID(id)
+			if err != nil {
+				return err
+			}
+			if entries.Get(key) == nil {
+				continue
+			}
+			if err := entries.Delete(key); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return addLength(sb, -deleted)
+	})
+}
+
+// XPending lists a group's pending messages, oldest first
+func (e *Embedded) XPending(ctx context.Context, stream, group string, count int64) ([]PendingMessage, error) {
+	messages := []PendingMessage{}
+	err := e.view(ctx, func(tx *bolt.Tx) error {
+		_, gb, err := groupBucket(tx, stream, group)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		c := gb.Bucket(keyPending).Cursor()
+		for k, v := c.First(); k != nil && int64(len(messages)) < count; k, v = c.Next() {
+			var p pendingEntry
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			messages = append(messages, PendingMessage{
+				ID:            formatID(k),
+				Consumer:      p.Consumer,
+				DeliveryCount: p.Deliveries,
+				ElapsedTime:   time.Duration(max(now-p.DeliveredAt, 0)) * time.Millisecond,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending messages: %w", err)
+	}
+	return messages, nil
+}
+
+// XClaim transfers pending messages idle for at least minIdle to a consumer
+// Pending entries whose message was deleted are dropped, as in Valkey 7
+func (e *Embedded) XClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, ids ...string) ([]Message, error) {
+	messages := []Message{}
+	err := e.update(ctx, func(tx *bolt.Tx) error {
+		sb, gb, err := groupBucket(tx, stream, group)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		entries := sb.Bucket(keyEntries)
+		pending := gb.Bucket(keyPending)
+
+		for _, id := range ids {
+			key, err := parseID(id)
+			if err != nil {
+				return err
+			}
+			v := pending.Get(key)
+			if v == nil {
+				continue
+			}
+			var p pendingEntry
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			if now-p.DeliveredAt < minIdle.Milliseconds() {
+				continue
+			}
+
+			data := entries.Get(key)
+			if data == nil {
+				if err := pending.Delete(key); err != nil {
+					return err
+				}
+				continue
+			}
+			msg, err := decodeMessage(key, data)
+			if err != nil {
+				return err
+			}
+
+			p.Consumer = consumer
+			p.Deliveries++
+			p.DeliveredAt = now
+			if err := putPending(pending, key, p); err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim messages from stream %s: %w", stream, err)
+	}
+	return messages, nil
+}
+
+// XRange fetches messages by ID, skipping IDs that don't exist
+func (e *Embedded) XRange(ctx context.Context, stream string, messageIDs ...string) ([]Message, error) {
+	messages := []Message{}
+	err := e.view(ctx, func(tx *bolt.Tx) error {
+		sb := tx.Bucket(streamKey(stream))
+		if sb == nil {
+			return nil
+		}
+		entries := sb.Bucket(keyEntries)
+		for _, id := range messageIDs {
+			key, err := parseID(id)
+			if err != nil {
+				return err
+			}
+			data := entries.Get(key)
+			if data == nil {
+				continue
+			}
+			msg, err := decodeMessage(key, data)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream %s: %w", stream, err)
+	}
+	return messages, nil
+}
+
+// MoveToDLQ copies a poison message with failure metadata to a dead letter stream
+func (e *Embedded) MoveToDLQ(ctx context.Context, sourceStream, dlqStream, messageID string, fields map[string]string, retryCount int64) error {
+	dlqFields := make(map[string]string, len(fields)+4)
+	for k, v := range fields {
+		dlqFields[k] = v
+	}
+	dlqFields["original_stream"] = sourceStream
+	dlqFields["original_message_id"] = messageID
+	dlqFields["failed_at"] = time.Now().UTC().Format(time.RFC3339)
+	dlqFields["retry_count"] = strconv.FormatInt(retryCount, 10)
+
+	if _, err := e.XAdd(ctx, dlqStream, dlqFields); err != nil {
+		return fmt.Errorf("failed to add message to DLQ: %w", err)
+	}
+
+	log.Printf("[DLQ] Moved poison message %s to %s (retries: %d)", messageID, dlqStream, retryCount)
+	return nil
+}
+
+// GetDLQMessages returns the oldest messages of a dead letter stream
+func (e *Embedded) GetDLQMessages(ctx context.Context, dlqStream string, count int64) ([]Message, error) {
+	messages := []Message{}
+	err := e.view(ctx, func(tx *bolt.Tx) error {
+		sb := tx.Bucket(streamKey(dlqStream))
+		if sb == nil {
+			return nil
+		}
+		c := sb.Bucket(keyEntries).Cursor()
+		for k, v := c.First(); k != nil && int64(len(messages)) < count; k, v = c.Next() {
+			msg, err := decodeMessage(k, v)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ: %w", err)
+	}
+	return messages, nil
+}
+
+// Ping checks that the database file can be opened
+func (e *Embedded) Ping(ctx context.Context) error {
+	return e.view(ctx, func(tx *bolt.Tx) error { return nil })
+}
+
+// Close is a no-op: the database is only open during operations
+func (e *Embedded) Close() {}
+
+func (e *Embedded) update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	db, err := e.open(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+func (e *Embedded) view(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	db, err := e.open(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// open opens the database, a read-only open shares the file lock with other readers
+// A missing file is created even for reads so the first reader doesn't fail
+func (e *Embedded) open(ctx context.Context, readOnly bool) (*bolt.DB, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(e.path); os.IsNotExist(err) {
+		readOnly = false
+	}
+
+	db, err := bolt.Open(e.path, 0o640, &bolt.Options{Timeout: lockTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded queue %s: %w", e.path, err)
+	}
+	return db, nil
+}
+
+func streamKey(stream string) []byte {
+	return []byte("stream:" + stream)
+}
+
+// createStream returns a stream's bucket, creating it (MKSTREAM) if needed
+func createStream(tx *bolt.Tx, stream string) (*bolt.Bucket, error) {
+	sb, err := tx.CreateBucketIfNotExists(streamKey(stream))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sb.CreateBucketIfNotExists(keyEntries); err != nil {
+		return nil, err
+	}
+	if _, err := sb.CreateBucketIfNotExists(keyGroups); err != nil {
+		return nil, err
+	}
+	return sb, nil
+}
+
+// groupBucket returns the buckets of a stream and one of its consumer groups
+func groupBucket(tx *bolt.Tx, stream, group string) (*bolt.Bucket, *bolt.Bucket, error) {
+	sb := tx.Bucket(streamKey(stream))
+	if sb == nil {
+		return nil, nil, fmt.Errorf("%w: %s/%s", errNoStream, stream, group)
+	}
+	gb := sb.Bucket(keyGroups).Bucket([]byte(group))
+	if gb == nil {
+		return nil, nil, fmt.Errorf("%w: %s/%s", errNoStream, stream, group)
+	}
+	return sb, gb, nil
+}
+
+func length(sb *bolt.Bucket) int64 {
+	v := sb.Get(keyLength)
+	if len(v) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(v))
+}
+
+func addLength(sb *bolt.Bucket, delta int64) error {
+	n := max(length(sb)+delta, 0)
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(n))
+	return sb.Put(keyLength, v)
+}
+
+func putPending(b *bolt.Bucket, key []byte, p pendingEntry) error {
+	v, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, v)
+}
+
+func decodeMessage(key, data []byte) (Message, error) {
+	msg := Message{ID: formatID(key)}
+	if err := json.Unmarshal(data, &msg.Fields); err != nil {
+		return Message{}, fmt.Errorf("corrupt message %s: %w", msg.ID, err)
+	}
+	return msg, nil
+}
+
+// encodeID stores an ID as 16 big-endian bytes so keys sort in stream order
+func encodeID(ms, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[0:8], ms)
+	binary.BigEndian.PutUint64(key[8:16], seq)
+	return key
+}
+
+func decodeID(key []byte) (uint64, uint64) {
+	if len(key) != 16 {
+		return 0, 0
+	}
+	return binary.BigEndian.Uint64(key[0:8]), binary.BigEndian.Uint64(key[8:16])
+}
+
+func formatID(key []byte) string {
+	ms, seq := decodeID(key)
+	return strconv.FormatUint(ms, 10) + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseID parses "<ms>-<seq>" (or "<ms>", sequence 0)
+func parseID(id string) ([]byte, error) {
+	msPart, seqPart, hasSeq := strings.Cut(id, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream ID %q", id)
+	}
+	seq := uint64(0)
+	if hasSeq {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid stream ID %q", id)
+		}
+	}
+	return encodeID(ms, seq), nil
+}
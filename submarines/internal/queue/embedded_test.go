@@ -0,0 +1,441 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testStream = "nodepulse:test:stream"
+	testGroup  = "test-group"
+)
+
+// openTestQueue opens an empty embedded queue with a consumer group on testStream
+func openTestQueue(t *testing.T) *Embedded {
+	t.Helper()
+
+	q, err := OpenEmbedded(filepath.Join(t.TempDir(), "queue.db"))
+	if err != nil {
+		t.Fatalf("OpenEmbedded: %v", err)
+	}
+	t.Cleanup(q.Close)
+
+	if err := q.XGroupCreate(context.Background(), testStream, testGroup, "0"); err != nil {
+		t.Fatalf("XGroupCreate: %v", err)
+	}
+	return q
+}
+
+// addMessages appends n messages with a sequence field and returns their IDs
+func addMessages(t *testing.T, q *Embedded, n int) []string {
+	t.Helper()
+
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		id, err := q.XAdd(context.Background(), testStream, map[string]string{"seq": fmt.Sprint(i)})
+		if err != nil {
+			t.Fatalf("XAdd: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func messageIDs(messages []Message) []string {
+	ids := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEmbeddedXReadGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		added     int
+		delivered int    // Read with ">" by consumer-a before the test read
+		consumer  string // Consumer of the test read
+		id        string
+		count     int64
+		want      []int // Indexes into the added messages
+	}{
+		{name: "new messages", added: 3, consumer: "consumer-a", id: ">", count: 10, want: []int{0, 1, 2}},
+		{name: "new messages up to count", added: 3, consumer: "consumer-a", id: ">", count: 2, want: []int{0, 1}},
+		{name: "new skips delivered", added: 3, delivered: 2, consumer: "consumer-a", id: ">", count: 10, want: []int{2}},
+		{name: "new skips messages delivered to others", added: 3, delivered: 1, consumer: "consumer-b", id: ">", count: 10, want: []int{1, 2}},
+		{name: "pending redelivers own messages", added: 3, delivered: 2, consumer: "consumer-a", id: "0", count: 10, want: []int{0, 1}},
+		{name: "pending up to count", added: 3, delivered: 2, consumer: "consumer-a", id: "0", count: 1, want: []int{0}},
+		{name: "pending excludes other consumers", added: 3, delivered: 2, consumer: "consumer-b", id: "0", count: 10, want: []int{}},
+		{name: "pending without deliveries", added: 3, consumer: "consumer-a", id: "0", count: 10, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openTestQueue(t)
+			ids := addMessages(t, q, tt.added)
+
+			if tt.delivered > 0 {
+				if _, err := q.XReadGroup(ctx, testGroup, "consumer-a", testStream, ">", int64(tt.delivered)); err != nil {
+					t.Fatalf("XReadGroup (deliver): %v", err)
+				}
+			}
+
+			messages, err := q.XReadGroup(ctx, testGroup, tt.consumer, testStream, tt.id, tt.count)
+			if err != nil {
+				t.Fatalf("XReadGroup: %v", err)
+			}
+
+			want := make([]string, 0, len(tt.want))
+			for _, i := range tt.want {
+				want = append(want, ids[i])
+			}
+			if got := messageIDs(messages); !equalIDs(got, want) {
+				t.Errorf("XReadGroup(%q) = %v, want %v", tt.id, got, want)
+			}
+			for _, msg := range messages {
+				if msg.Fields["seq"] == "" {
+					t.Errorf("message %s has no fields", msg.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedXReadGroupPendingDeliveryCount(t *testing.T) {
+	ctx := context.Background()
+	q := openTestQueue(t)
+	addMessages(t, q, 1)
+
+	if _, err := q.XReadGroup(ctx, testGroup, "consumer-a", testStream, ">", 10); err != nil {
+		t.Fatalf("XReadGroup: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := q.XReadGroup(ctx, testGroup, "consumer-a", testStream, "0", 10); err != nil {
+			t.Fatalf("XReadGroup: %v", err)
+		}
+	}
+
+	pending, err := q.XPending(ctx, testStream, testGroup, 10)
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].DeliveryCount != 3 {
+		t.Fatalf("XPending = %+v, want one message delivered 3 times", pending)
+	}
+}
+
+func TestEmbeddedXReadGroupNoGroup(t *testing.T) {
+	q := openTestQueue(t)
+
+	_, err := q.XReadGroup(context.Background(), "missing-group", "consumer-a", testStream, "0", 10)
+	if !errors.Is(err, errNoStream) {
+		t.Fatalf("XReadGroup on a missing group: err = %v, want errNoStream", err)
+	}
+}
+
+func TestEmbeddedXAck(t *testing.T) {
+	tests := []struct {
+		name        string
+		ack         []int // Indexes into the delivered messages
+		wantPending []int
+	}{
+		{name: "ack none", ack: []int{}, wantPending: []int{0, 1, 2}},
+		{name: "ack one", ack: []int{1}, wantPending: []int{0, 2}},
+		{name: "ack all", ack: []int{0, 1, 2}, wantPending: []int{}},
+		{name: "ack twice", ack: []int{0, 0}, wantPending: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openTestQueue(t)
+			ids := addMessages(t, q, 3)
+
+			if _, err := q.XReadGroup(ctx, testGroup, "consumer-a", testStream, ">", 10); err != nil {
+				t.Fatalf("XReadGroup: %v", err)
+			}
+
+			ack := make([]string, 0, len(tt.ack))
+			for _, i := range tt.ack {
+				ack = append(ack, ids[i])
+			}
+			if err := q.XAck(ctx, testStream, testGroup, ack...); err != nil {
+				t.Fatalf("XAck: %v", err)
+			}
+
+			pending, err := q.XPending(ctx, testStream, testGroup, 10)
+			if err != nil {
+				t.Fatalf("XPending: %v", err)
+			}
+			got := make([]string, 0, len(pending))
+			for _, p := range pending {
+				got = append(got, p.ID)
+			}
+			want := make([]string, 0, len(tt.wantPending))
+			for _, i := range tt.wantPending {
+				want = append(want, ids[i])
+			}
+			if !equalIDs(got, want) {
+				t.Errorf("pending after XAck = %v, want %v", got, want)
+			}
+
+			// Acknowledged messages stay in the stream until deleted
+			if n, err := q.XLen(ctx, testStream); err != nil || n != 3 {
+				t.Errorf("XLen = %d, %v, want 3", n, err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedXClaim(t *testing.T) {
+	tests := []struct {
+		name         string
+		idle         time.Duration // Wait after delivery before claiming
+		minIdle      time.Duration
+		deleted      bool // Message deleted from the stream before claiming
+		wantClaimed  bool
+		wantConsumer string
+		wantPending  bool
+	}{
+		{name: "idle long enough", idle: 20 * time.Millisecond, minIdle: 10 * time.Millisecond, wantClaimed: true, wantConsumer: "consumer-b", wantPending: true},
+		{name: "zero min idle", minIdle: 0, wantClaimed: true, wantConsumer: "consumer-b", wantPending: true},
+		{name: "not idle long enough", minIdle: time.Hour, wantConsumer: "consumer-a", wantPending: true},
+		{name: "deleted message is dropped", minIdle: 0, deleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openTestQueue(t)
+			ids := addMessages(t, q, 1)
+
+			if _, err := q.XReadGroup(ctx, testGroup, "consumer-a", testStream, ">", 10); err != nil {
+				t.Fatalf("XReadGroup: %v", err)
+			}
+			if tt.deleted {
+				if err := q.XDel(ctx, testStream, ids[0]); err != nil {
+					t.Fatalf("XDel: %v", err)
+				}
+			}
+			time.Sleep(tt.idle)
+
+			claimed, err := q.XClaim(ctx, testStream, testGroup, "consumer-b", tt.minIdle, ids...)
+			if err != nil {
+				t.Fatalf("XClaim: %v", err)
+			}
+			if got := len(claimed) == 1; got != tt.wantClaimed {
+				t.Errorf("XClaim claimed %v, want claimed = %v", messageIDs(claimed), tt.wantClaimed)
+			}
+
+			pending, err := q.XPending(ctx, testStream, testGroup, 10)
+			if err != nil {
+				t.Fatalf("XPending: %v", err)
+			}
+			if !tt.wantPending {
+				if len(pending) != 0 {
+					t.Errorf("XPending = %+v, want none", pending)
+				}
+				return
+			}
+			if len(pending) != 1 || pending[0].Consumer != tt.wantConsumer {
+				t.Fatalf("XPending = %+v, want one message owned by %s", pending, tt.wantConsumer)
+			}
+			wantDeliveries := int64(1)
+			if tt.wantClaimed {
+				wantDeliveries = 2
+			}
+			if pending[0].DeliveryCount != wantDeliveries {
+				t.Errorf("delivery count = %d, want %d", pending[0].DeliveryCount, wantDeliveries)
+			}
+		})
+	}
+}
+
+func TestEmbeddedXDelLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted []int    // Indexes into the added messages
+		unknown []string // IDs that aren't in the stream
+		wantLen int64
+	}{
+		{name: "delete none", wantLen: 3},
+		{name: "delete one", deleted: []int{1}, wantLen: 2},
+		{name: "delete all", deleted: []int{0, 1, 2}, wantLen: 0},
+		{name: "delete twice", deleted: []int{0, 0}, wantLen: 2},
+		{name: "delete unknown ID", unknown: []string{"1-0"}, wantLen: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openTestQueue(t)
+			ids := addMessages(t, q, 3)
+
+			del := append([]string{}, tt.unknown...)
+			for _, i := range tt.deleted {
+				del = append(del, ids[i])
+			}
+			if err := q.XDel(ctx, testStream, del...); err != nil {
+				t.Fatalf("XDel: %v", err)
+			}
+
+			n, err := q.XLen(ctx, testStream)
+			if err != nil {
+				t.Fatalf("XLen: %v", err)
+			}
+			if n != tt.wantLen {
+				t.Errorf("XLen = %d, want %d", n, tt.wantLen)
+			}
+
+			remaining, err := q.XRange(ctx, testStream, ids...)
+			if err != nil {
+				t.Fatalf("XRange: %v", err)
+			}
+			if int64(len(remaining)) != tt.wantLen {
+				t.Errorf("XRange returned %d messages, want %d", len(remaining), tt.wantLen)
+			}
+		})
+	}
+}
+
+// digestBatch mirrors one pass of the digest loop (cmd/digest processStream): the
+// consumer's pending messages first, otherwise new ones; processed messages are
+// acknowledged and deleted, failed ones stay pending for the next pass
+func digestBatch(ctx context.Context, q Queue, consumer string, process func(Message) error) (int, error) {
+	messages, err := q.XReadGroup(ctx, testGroup, consumer, testStream, "0", 10)
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		if messages, err = q.XReadGroup(ctx, testGroup, consumer, testStream, ">", 10); err != nil {
+			return 0, err
+		}
+	}
+
+	processed := []string{}
+	for _, msg := range messages {
+		if err := process(msg); err != nil {
+			continue
+		}
+		if err := q.XAck(ctx, testStream, testGroup, msg.ID); err != nil {
+			return 0, err
+		}
+		processed = append(processed, msg.ID)
+	}
+	if len(processed) > 0 {
+		if err := q.XDel(ctx, testStream, processed...); err != nil {
+			return 0, err
+		}
+	}
+	return len(messages), nil
+}
+
+func TestEmbeddedDigestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	q := openTestQueue(t)
+
+	// Ingest publishes three payloads
+	for _, server := range []string{"server-1", "server-2", "server-3"} {
+		if _, err := q.XAdd(ctx, testStream, map[string]string{"server_id": server, "payload": "{}"}); err != nil {
+			t.Fatalf("XAdd: %v", err)
+		}
+	}
+
+	// server-2 fails once (e.g. a database error) and is retried from the pending list
+	attempts := map[string]int{}
+	stored := []string{}
+	process := func(msg Message) error {
+		server := msg.Fields["server_id"]
+		attempts[server]++
+		if server == "server-2" && attempts[server] == 1 {
+			return errors.New("temporary failure")
+		}
+		stored = append(stored, server)
+		return nil
+	}
+
+	read, err := digestBatch(ctx, q, "digest-1", process)
+	if err != nil {
+		t.Fatalf("first pass: %v", err)
+	}
+	if read != 3 {
+		t.Fatalf("first pass read %d messages, want 3", read)
+	}
+
+	pending, err := q.XPending(ctx, testStream, testGroup, 10)
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].DeliveryCount != 1 {
+		t.Fatalf("pending after first pass = %+v, want the failed message", pending)
+	}
+	if n, _ := q.XLen(ctx, testStream); n != 1 {
+		t.Fatalf("XLen after first pass = %d, want 1 (processed messages deleted)", n)
+	}
+
+	// The next pass redelivers the failed message before reading new ones
+	if _, err := q.XAdd(ctx, testStream, map[string]string{"server_id": "server-4", "payload": "{}"}); err != nil {
+		t.Fatalf("XAdd: %v", err)
+	}
+	if read, err = digestBatch(ctx, q, "digest-1", process); err != nil || read != 1 {
+		t.Fatalf("second pass read %d messages, err %v, want the pending message only", read, err)
+	}
+	if read, err = digestBatch(ctx, q, "digest-1", process); err != nil || read != 1 {
+		t.Fatalf("third pass read %d messages, err %v, want the new message", read, err)
+	}
+
+	want := []string{"server-1", "server-3", "server-2", "server-4"}
+	if !equalIDs(stored, want) {
+		t.Errorf("stored %v, want %v", stored, want)
+	}
+	if attempts["server-2"] != 2 {
+		t.Errorf("server-2 processed %d times, want 2", attempts["server-2"])
+	}
+
+	// Everything was acknowledged and deleted
+	if pending, err = q.XPending(ctx, testStream, testGroup, 10); err != nil || len(pending) != 0 {
+		t.Errorf("pending after round trip = %+v, %v, want none", pending, err)
+	}
+	if n, err := q.XLen(ctx, testStream); err != nil || n != 0 {
+		t.Errorf("XLen after round trip = %d, %v, want 0", n, err)
+	}
+}
+
+func TestEmbeddedMoveToDLQ(t *testing.T) {
+	ctx := context.Background()
+	q := openTestQueue(t)
+	ids := addMessages(t, q, 1)
+
+	if err := q.MoveToDLQ(ctx, testStream, testStream+":dlq", ids[0], map[string]string{"seq": "0"}, 3); err != nil {
+		t.Fatalf("MoveToDLQ: %v", err)
+	}
+
+	messages, err := q.GetDLQMessages(ctx, testStream+":dlq", 10)
+	if err != nil {
+		t.Fatalf("GetDLQMessages: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("GetDLQMessages returned %d messages, want 1", len(messages))
+	}
+	fields := messages[0].Fields
+	if fields["seq"] != "0" || fields["original_message_id"] != ids[0] || fields["retry_count"] != "3" {
+		t.Errorf("DLQ message fields = %v", fields)
+	}
+}
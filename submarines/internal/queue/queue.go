@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/config"
+	"github.com/nodepulse/admiral/submarines/internal/valkey"
+)
+
+// Backends (QUEUE_BACKEND)
+const (
+	BackendValkey   = "valkey"   // Valkey streams (default)
+	BackendEmbedded = "embedded" // bolt database file, for single-host installs and tests
+)
+
+// Message is a stream entry
+type Message = valkey.StreamMessage
+
+// PendingMessage is a delivered but unacknowledged entry of a consumer group
+type PendingMessage = valkey.PendingMessage
+
+// Queue is the stream API used by ingest, digest, deployer and the alerter:
+// append-only streams read through consumer groups with explicit acknowledgement,
+// a pending entries list for redelivery and dead letter streams for poison messages
+//
+// Read IDs follow XREADGROUP: ">" reads entries never delivered to the group,
+// "0" re-reads the consumer's own pending entries (incrementing their delivery count)
+type Queue interface {
+	// XAdd appends a message and returns its ID
+	XAdd(ctx context.Context, stream string, values map[string]string) (string, error)
+
+	// XLen returns the number of entries in a stream
+	XLen(ctx context.Context, stream string) (int64, error)
+
+	// XGroupCreate creates a consumer group (and the stream) if it doesn't exist
+	// startID "0" delivers existing entries, "$" only entries added afterwards
+	XGroupCreate(ctx context.Context, stream, group string, startID string) error
+
+	// XReadGroup reads up to count messages for a consumer, waiting briefly when there are none
+	XReadGroup(ctx context.Context, group, consumer, stream, id string, count int64) ([]Message, error)
+
+	// XAck removes messages from the group's pending entries list
+	XAck(ctx context.Context, stream, group string, ids ...string) error
+
+	// XDel deletes messages from a stream
+	XDel(ctx context.Context, stream string, ids ...string) error
+
+	// XPending lists up to count pending messages of a group with their delivery counts
+	XPending(ctx context.Context, stream, group string, count int64) ([]PendingMessage, error)
+
+	// XClaim transfers pending messages idle for at least minIdle to a consumer
+	XClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, ids ...string) ([]Message, error)
+
+	// XRange fetches messages by ID
+	XRange(ctx context.Context, stream string, messageIDs ...string) ([]Message, error)
+
+	// MoveToDLQ copies a poison message with failure metadata to a dead letter stream
+	MoveToDLQ(ctx context.Context, sourceStream, dlqStream, messageID string, fields map[string]string, retryCount int64) error
+
+	// GetDLQMessages returns the oldest count messages of a dead letter stream
+	GetDLQMessages(ctx context.Context, dlqStream string, count int64) ([]Message, error)
+
+	Ping(ctx context.Context) error
+	Close()
+}
+
+var (
+	_ Queue = (*valkey.Client)(nil)
+	_ Queue = (*Embedded)(nil)
+	_ Queue = (*Reconnecting)(nil)
+)
+
+// Open connects to the configured backend
+// With the Valkey backend the client is also returned for the features outside the
+// Queue interface (caching, Pub/Sub, stream introspection); it is nil otherwise
+func Open(cfg *config.Config) (Queue, *valkey.Client, error) {
+	switch cfg.QueueBackend {
+	case BackendValkey, "":
+		client, err := valkey.New(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, client, nil
+	case BackendEmbedded:
+		q, err := OpenEmbedded(cfg.QueuePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return q, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported QUEUE_BACKEND %q (expected %s or %s)",
+			cfg.QueueBackend, BackendValkey, BackendEmbedded)
+	}
+}
@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/config"
+	"github.com/nodepulse/admiral/submarines/internal/valkey"
+)
+
+// ErrUnavailable is returned by a Reconnecting queue until it is connected
+var ErrUnavailable = errors.New("queue unavailable: not connected to valkey yet")
+
+// Reconnecting is the Valkey backend for a service that has to start while Valkey is
+// unreachable (ingest, which spools metrics to disk meanwhile): every call fails with
+// ErrUnavailable until a background loop has connected. Once connected, the Valkey
+// client reconnects by itself after later outages
+type Reconnecting struct {
+	mu     sync.RWMutex
+	client *valkey.Client
+	cancel context.CancelFunc
+}
+
+// OpenReconnecting starts connecting to Valkey every interval until it succeeds
+func OpenReconnecting(cfg *config.Config, interval time.Duration) *Reconnecting {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Reconnecting{cancel: cancel}
+	go r.connect(ctx, cfg, interval)
+	return r
+}
+
+func (r *Reconnecting) connect(ctx context.Context, cfg *config.Config, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		client, err := valkey.New(cfg)
+		if err != nil {
+			log.Printf("[WARN] Still unable to connect to Valkey, retrying in %v: %v", interval, err)
+			continue
+		}
+
+		r.mu.Lock()
+		if ctx.Err() != nil {
+			r.mu.Unlock()
+			client.Close()
+			return
+		}
+		r.client = client
+		r.mu.Unlock()
+
+		log.Printf("[INFO] Connected to Valkey, leaving spool-only mode")
+		return
+	}
+}
+
+// Client returns the connected client or ErrUnavailable
+func (r *Reconnecting) Client() (*valkey.Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.client == nil {
+		return nil, ErrUnavailable
+	}
+	return r.client, nil
+}
+
+func (r *Reconnecting) XAdd(ctx context.Context, stream string, values map[string]string) (string, error) {
+	c, err := r.Client()
+	if err != nil {
+		return "", err
+	}
+	return c.XAdd(ctx, stream, values)
+}
+
+func (r *Reconnecting) XLen(ctx context.Context, stream string) (int64, error) {
+	c, err := r.Client()
+	if err != nil {
+		return 0, err
+	}
+	return c.XLen(ctx, stream)
+}
+
+func (r *Reconnecting) XGroupCreate(ctx context.Context, stream, group string, startID string) error {
+	c, err := r.Client()
+	if err != nil {
+		return err
+	}
+	return c.XGroupCreate(ctx, stream, group, startID)
+}
+
+func (r *Reconnecting) XReadGroup(ctx context.Context, group, consumer, stream, id string, count int64) ([]Message, error) {
+	c, err := r.Client()
+	if err != nil {
+		return nil, err
+	}
+	return c.XReadGroup(ctx, group, consumer, stream, id, count)
+}
+
+func (r *Reconnecting) XAck(ctx context.Context, stream, group string, ids ...string) error {
+	c, err := r.Client()
+	if err != nil {
+		return err
+	}
+	return c.XAck(ctx, stream, group, ids...)
+}
+
+func (r *Reconnecting) XDel(ctx context.Context, stream string, ids ...string) error {
+	c, err := r.Client()
+	if err != nil {
+		return err
+	}
+	return c.XDel(ctx, stream, ids...)
+}
+
+func (r *Reconnecting) XPending(ctx context.Context, stream, group string, count int64) ([]PendingMessage, error) {
+	c, err := r.Client()
+	if err != nil {
+		return nil, err
+	}
+	return c.XPending(ctx, stream, group, count)
+}
+
+func (r *Reconnecting) XClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, ids ...string) ([]Message, error) {
+	c, err := r.Client()
+	if err != nil {
+		return nil, err
+	}
+	return c.XClaim(ctx, stream, group, consumer, minIdle, ids...)
+}
+
+func (r *Reconnecting) XRange(ctx context.Context, stream string, messageIDs ...string) ([]Message, error) {
+	c, err := r.Client()
+	if err != nil {
+		return nil, err
+	}
+	return c.XRange(ctx, stream, messageIDs...)
+}
+
+func (r *Reconnecting) MoveToDLQ(ctx context.Context, sourceStream, dlqStream, messageID string, fields map[string]string, retryCount int64) error {
+	c, err := r.Client()
+	if err != nil {
+		return err
+	}
+	return c.MoveToDLQ(ctx, sourceStream, dlqStream, messageID, fields, retryCount)
+}
+
+func (r *Reconnecting) GetDLQMessages(ctx context.Context, dlqStream string, count int64) ([]Message, error) {
+	c, err := r.Client()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetDLQMessages(ctx, dlqStream, count)
+}
+
+func (r *Reconnecting) Ping(ctx context.Context) error {
+	c, err := r.Client()
+	if err != nil {
+		return err
+	}
+	return c.Ping(ctx)
+}
+
+// Close stops connecting and closes the client if connected
+func (r *Reconnecting) Close() {
+	r.cancel()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		r.client.Close()
+		r.client = nil
+	}
+}
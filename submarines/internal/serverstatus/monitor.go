@@ -0,0 +1,234 @@
+package serverstatus
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nodepulse/admiral/submarines/internal/models"
+	"github.com/nodepulse/admiral/submarines/internal/valkey"
+)
+
+const (
+	// EventsChannel is the Valkey Pub/Sub channel status transitions are published on
+	EventsChannel = "nodepulse:servers:status"
+
+	// EventTypeStatusChange is the admiral.server_events event_type for transitions
+	EventTypeStatusChange = "status_change"
+
+	// advisoryLockKey ensures only one digest worker evaluates statuses at a time
+	advisoryLockKey = "admiral.server_status_monitor"
+)
+
+// Transition describes a single server status change
+type Transition struct {
+	ServerID   string    `json:"server_id"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// Monitor moves admiral.servers.status between online, stale and offline
+// based on last_seen_at and each server's expected reporting interval
+type Monitor struct {
+	db     *sql.DB
+	valkey *valkey.Client
+}
+
+// New creates a new Monitor instance
+// Without a Valkey client (embedded queue backend) transitions are only recorded, not published
+func New(db *sql.DB, valkeyClient *valkey.Client) *Monitor {
+	return &Monitor{
+		db:     db,
+		valkey: valkeyClient,
+	}
+}
+
+// Run evaluates all monitored servers once, records transitions in admiral.server_events
+// and publishes them on Valkey after the transaction commits
+func (m *Monitor) Run(ctx context.Context) error {
+	settings, err := m.getStatusSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read status settings: %w", err)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even after commit
+
+	// Only one worker evaluates at a time (horizontal scaling of digest)
+	var locked bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock(hashtext($1))", advisoryLockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+
+	transitions, err := applyTransitions(ctx, tx, settings)
+	if err != nil {
+		return err
+	}
+
+	if len(transitions) == 0 {
+		return tx.Commit()
+	}
+
+	if err := recordTransitions(ctx, tx, transitions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	m.publish(ctx, transitions)
+	return nil
+}
+
+// applyTransitions updates server statuses and returns the changes
+// Servers an admin set to inactive/error and servers that never reported are left untouched
+func applyTransitions(ctx context.Context, tx *sql.Tx, settings *models.ServerStatusSettings) ([]Transition, error) {
+	query := `
+		WITH computed AS (
+			SELECT
+				id,
+				status AS old_status,
+				CASE
+					WHEN last_seen_at > NOW() - make_interval(secs => report_interval_seconds * $1::integer) THEN $3
+					WHEN last_seen_at > NOW() - make_interval(secs => report_interval_seconds * $2::integer) THEN $4
+					ELSE $5
+				END AS new_status
+			FROM admiral.servers
+			WHERE last_seen_at IS NOT NULL
+			  AND status IN ($6, $3, $4, $5)
+			FOR UPDATE
+		)
+		UPDATE admiral.servers s
+		SET status = c.new_status, status_changed_at = NOW()
+		FROM computed c
+		WHERE s.id = c.id AND c.old_status <> c.new_status
+		RETURNING s.server_id, c.old_status, c.new_status, s.last_seen_at, s.status_changed_at
+	`
+
+	rows, err := tx.QueryContext(ctx, query,
+		settings.StaleAfterIntervals,
+		settings.OfflineAfterIntervals,
+		models.ServerStatusOnline,
+		models.ServerStatusStale,
+		models.ServerStatusOffline,
+		models.ServerStatusActive,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update server statuses: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := []Transition{}
+	for rows.Next() {
+		var t Transition
+		if err := rows.Scan(&t.ServerID, &t.From, &t.To, &t.LastSeenAt, &t.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transition: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+
+	return transitions, rows.Err()
+}
+
+// recordTransitions appends one admiral.server_events row per transition
+func recordTransitions(ctx context.Context, tx *sql.Tx, transitions []Transition) error {
+	query := `
+		INSERT INTO admiral.server_events (server_id, event_type, source, message, data, occurred_at)
+		VALUES ($1, $2, 'admiral', $3, $4, $5)
+	`
+
+	for _, t := range transitions {
+		data, err := json.Marshal(map[string]any{
+			"from":         t.From,
+			"to":           t.To,
+			"last_seen_at": t.LastSeenAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
+		}
+
+		message := fmt.Sprintf("Server status changed from %s to %s", t.From, t.To)
+		if _, err := tx.ExecContext(ctx, query, t.ServerID, EventTypeStatusChange, message, data, t.ChangedAt); err != nil {
+			return fmt.Errorf("failed to record status event for %s: %w", t.ServerID, err)
+		}
+
+		log.Printf("[INFO] Server %s status: %s -> %s (last seen %s)",
+			t.ServerID, t.From, t.To, t.LastSeenAt.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// publish sends transitions to Valkey Pub/Sub (best effort - events are already in Postgres)
+func (m *Monitor) publish(ctx context.Context, transitions []Transition) {
+	if m.valkey == nil {
+		return
+	}
+	for _, t := range transitions {
+		payload, err := json.Marshal(t)
+		if err != nil {
+			continue
+		}
+		if err := m.valkey.Publish(ctx, EventsChannel, string(payload)); err != nil {
+			log.Printf("[WARN] Failed to publish status change for %s: %v", t.ServerID, err)
+		}
+	}
+}
+
+// getStatusSettings reads status thresholds from admiral.settings
+func (m *Monitor) getStatusSettings(ctx context.Context) (*models.ServerStatusSettings, error) {
+	// Default values (1 minute stale, 5 minutes offline at the default 15s interval)
+	settings := &models.ServerStatusSettings{
+		StaleAfterIntervals:   4,
+		OfflineAfterIntervals: 20,
+	}
+
+	query := `
+		SELECT key, value
+		FROM admiral.settings
+		WHERE key IN ('server_stale_after_intervals', 'server_offline_after_intervals')
+	`
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var value models.JSONValue
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+
+		switch key {
+		case "server_stale_after_intervals":
+			if n, err := value.Int(); err == nil && n > 0 {
+				settings.StaleAfterIntervals = n
+			}
+		case "server_offline_after_intervals":
+			if n, err := value.Int(); err == nil && n > 0 {
+				settings.OfflineAfterIntervals = n
+			}
+		}
+	}
+
+	if settings.OfflineAfterIntervals <= settings.StaleAfterIntervals {
+		settings.OfflineAfterIntervals = settings.StaleAfterIntervals + 1
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,564 @@
+package spool
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fsync policies for appended records
+const (
+	FsyncAlways   = "always"   // fsync every append (no loss on power failure, slowest)
+	FsyncInterval = "interval" // fsync on every Run tick (loses at most one interval on power failure)
+	FsyncNever    = "never"    // leave flushing to the OS
+)
+
+const (
+	// headerSize is the record frame header: payload length and CRC32 (both uint32, big endian)
+	headerSize = 8
+
+	// maxRecordSize guards against reading garbage lengths from a damaged segment
+	maxRecordSize = 64 << 20
+
+	segmentExt = ".seg"
+	cursorFile = "cursor"
+)
+
+// ErrFull is returned by Append when the spool has reached its size limit
+var ErrFull = errors.New("spool is full")
+
+// Options configure a spool
+type Options struct {
+	Dir          string // Directory holding segment files and the replay cursor
+	MaxBytes     int64  // Upper bound of unreplayed data on disk
+	SegmentBytes int64  // Size at which the active segment is rotated
+	Fsync        string // FsyncAlways, FsyncInterval or FsyncNever
+}
+
+// Record is one spooled stream message
+type Record struct {
+	Stream    string            `json:"stream"`
+	Fields    map[string]string `json:"fields"`
+	SpooledAt time.Time         `json:"spooled_at"`
+}
+
+// Stats describe the unreplayed contents of a spool
+type Stats struct {
+	Records  int64 `json:"records"`
+	Bytes    int64 `json:"bytes"`
+	Segments int   `json:"segments"`
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+type segment struct {
+	seq  int64
+	size int64
+}
+
+// Spool is a bounded on-disk write-ahead log of stream messages that couldn't be published
+// Records are appended to numbered segment files and replayed oldest first; a cursor file
+// tracks the replay position, and fully replayed segments are deleted
+// Replay is at-least-once: a crash between publishing and saving the cursor repeats a batch
+type Spool struct {
+	mu       sync.Mutex
+	opts     Options
+	segments []segment // Oldest first, the last one is being appended to
+	active   *os.File
+	readSeq  int64 // Segment and offset of the next record to replay
+	readOff  int64
+	records  int64
+	unsynced bool
+}
+
+// Open opens (or creates) the spool in opts.Dir, counting the records left from a previous run
+// A torn record at the end of the newest segment (crash during append) is truncated
+func Open(opts Options) (*Spool, error) {
+	if opts.Fsync == "" {
+		opts.Fsync = FsyncInterval
+	}
+	switch opts.Fsync {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("unsupported spool fsync policy %q (expected %s, %s or %s)",
+			opts.Fsync, FsyncAlways, FsyncInterval, FsyncNever)
+	}
+	if opts.MaxBytes <= 0 || opts.SegmentBytes <= 0 {
+		return nil, fmt.Errorf("spool size limits must be positive")
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{opts: opts}
+	seqs, err := s.listSegments()
+	if err != nil {
+		return nil, err
+	}
+	s.readSeq, s.readOff, err = s.loadCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, seq := range seqs {
+		path := s.segmentPath(seq)
+
+		// Leftovers of a crash between saving the cursor and deleting a replayed segment
+		if seq < s.readSeq {
+			if err := os.Remove(path); err != nil {
+				return nil, fmt.Errorf("failed to remove replayed spool segment: %w", err)
+			}
+			continue
+		}
+
+		from := int64(0)
+		if seq == s.readSeq {
+			from = s.readOff
+		}
+		records, end, size, err := scan(path, from)
+		if err != nil {
+			return nil, err
+		}
+
+		last := i == len(seqs)-1
+		if end < size {
+			if last {
+				log.Printf("[WARN] Truncating torn record at the end of spool segment %s (%d bytes)", path, size-end)
+				if err := os.Truncate(path, end); err != nil {
+					return nil, fmt.Errorf("failed to truncate spool segment: %w", err)
+				}
+				size = end
+			} else {
+				log.Printf("[WARN] Spool segment %s is damaged after offset %d, the rest will be skipped", path, end)
+			}
+		}
+
+		s.records += records
+		s.segments = append(s.segments, segment{seq: seq, size: size})
+	}
+
+	if len(s.segments) == 0 {
+		next := max(s.readSeq, 1)
+		s.segments = append(s.segments, segment{seq: next})
+		s.readSeq, s.readOff = next, 0
+	} else if s.readSeq < s.segments[0].seq {
+		s.readSeq, s.readOff = s.segments[0].seq, 0
+	}
+
+	if err := s.openActive(); err != nil {
+		return nil, err
+	}
+
+	if s.records > 0 {
+		log.Printf("[INFO] Spool %s holds %d unreplayed records", opts.Dir, s.records)
+	}
+	return s, nil
+}
+
+// Append adds a record to the end of the spool
+func (s *Spool) Append(stream string, fields map[string]string) error {
+	payload, err := json.Marshal(Record{Stream: stream, Fields: fields, SpooledAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to encode spool record: %w", err)
+	}
+	frame := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[headerSize:], payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bytes()+int64(len(frame)) > s.opts.MaxBytes {
+		return ErrFull
+	}
+
+	if active := s.segments[len(s.segments)-1]; active.size > 0 && active.size+int64(len(frame)) > s.opts.SegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	active := &s.segments[len(s.segments)-1]
+	if _, err := s.active.Write(frame); err != nil {
+		// Cut off a partial write so the segment stays readable
+		s.active.Truncate(active.size)
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	active.size += int64(len(frame))
+	s.records++
+
+	if s.opts.Fsync == FsyncAlways {
+		if err := s.active.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool segment: %w", err)
+		}
+	} else {
+		s.unsynced = true
+	}
+	return nil
+}
+
+// Depth returns the number of records waiting to be replayed
+func (s *Spool) Depth() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records
+}
+
+// Stats returns the spool depth and disk usage
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{
+		Records:  s.records,
+		Bytes:    s.bytes(),
+		Segments: len(s.segments),
+		MaxBytes: s.opts.MaxBytes,
+	}
+}
+
+// Replay publishes spooled records oldest first, in batches of up to batchSize
+// Stops at the first failed publish (the record stays at the head of the spool)
+// Returns the number of records replayed
+func (s *Spool) Replay(ctx context.Context, batchSize int, publish func(ctx context.Context, rec Record) error) (int, error) {
+	replayed := 0
+	for ctx.Err() == nil {
+		batch, ends, err := s.readBatch(batchSize)
+		if err != nil {
+			return replayed, err
+		}
+		if len(batch) == 0 {
+			return replayed, nil
+		}
+
+		done := 0
+		var publishErr error
+		for _, rec := range batch {
+			if publishErr = publish(ctx, rec); publishErr != nil {
+				break
+			}
+			done++
+		}
+
+		if done > 0 {
+			if err := s.advance(ends[done-1], int64(done)); err != nil {
+				return replayed, err
+			}
+			replayed += done
+		}
+		if publishErr != nil {
+			return replayed, publishErr
+		}
+	}
+	return replayed, ctx.Err()
+}
+
+// Sync flushes appended records to disk
+func (s *Spool) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sync()
+}
+
+// Close syncs and closes the active segment
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.sync(); err != nil {
+		s.active.Close()
+		return err
+	}
+	return s.active.Close()
+}
+
+// Run replays the spool every interval until ctx is cancelled, syncing appends
+// on each tick under FsyncInterval
+func (s *Spool) Run(ctx context.Context, interval time.Duration, batchSize int, publish func(ctx context.Context, rec Record) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Sync(); err != nil {
+			log.Printf("[WARN] Failed to sync spool: %v", err)
+		}
+		if s.Depth() == 0 {
+			continue
+		}
+
+		replayed, err := s.Replay(ctx, batchSize, publish)
+		if replayed > 0 {
+			log.Printf("[INFO] Replayed %d spooled records (%d remaining)", replayed, s.Depth())
+		}
+		if err != nil && ctx.Err() == nil {
+			// Log once per outage, not on every tick
+			if !failing {
+				log.Printf("[WARN] Spool replay paused, publishing failed: %v", err)
+			}
+			failing = true
+			continue
+		}
+		failing = false
+	}
+}
+
+// readBatch reads up to n records from the replay position, deleting segments
+// that have been replayed completely
+// ends[i] is the offset just past batch[i] in the current read segment
+func (s *Spool) readBatch(n int) ([]Record, []int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		current := s.segments[0]
+		isActive := len(s.segments) == 1
+
+		batch, ends, err := readRecords(s.segmentPath(current.seq), s.readOff, current.size, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(batch) > 0 || isActive {
+			return batch, ends, nil
+		}
+
+		// Replayed (or damaged from here on): move to the next segment
+		if err := s.dropOldest(); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// advance moves the replay position past records that were published
+func (s *Spool) advance(offset, records int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readOff = offset
+	s.records -= records
+	return s.saveCursor()
+}
+
+// dropOldest deletes the oldest segment and continues replay at the next one
+func (s *Spool) dropOldest() error {
+	oldest := s.segments[0]
+	s.segments = s.segments[1:]
+	s.readSeq, s.readOff = s.segments[0].seq, 0
+	if err := s.saveCursor(); err != nil {
+		return err
+	}
+	if err := os.Remove(s.segmentPath(oldest.seq)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove replayed spool segment: %w", err)
+	}
+	return nil
+}
+
+// rotate starts a new active segment
+func (s *Spool) rotate() error {
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	s.unsynced = false
+	if err := s.active.Close(); err != nil {
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+	s.segments = append(s.segments, segment{seq: s.segments[len(s.segments)-1].seq + 1})
+	return s.openActive()
+}
+
+func (s *Spool) openActive() error {
+	seq := s.segments[len(s.segments)-1].seq
+	f, err := os.OpenFile(s.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	s.active = f
+	return nil
+}
+
+func (s *Spool) sync() error {
+	if !s.unsynced {
+		return nil
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	s.unsynced = false
+	return nil
+}
+
+// bytes returns the size of unreplayed data
+func (s *Spool) bytes() int64 {
+	total := -s.readOff
+	for _, seg := range s.segments {
+		total += seg.size
+	}
+	return max(total, 0)
+}
+
+func (s *Spool) segmentPath(seq int64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%016d%s", seq, segmentExt))
+}
+
+// listSegments returns the sequence numbers of the segment files, oldest first
+func (s *Spool) listSegments() ([]int64, error) {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	seqs := []int64{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// loadCursor reads the replay position ("<segment> <offset>"), zero when there is none
+func (s *Spool) loadCursor() (int64, int64, error) {
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, cursorFile))
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read spool cursor: %w", err)
+	}
+
+	var seq, off int64
+	if _, err := fmt.Sscanf(string(data), "%d %d", &seq, &off); err != nil {
+		log.Printf("[WARN] Ignoring invalid spool cursor %q, replaying from the oldest segment", strings.TrimSpace(string(data)))
+		return 0, 0, nil
+	}
+	return seq, off, nil
+}
+
+// saveCursor atomically replaces the cursor file
+func (s *Spool) saveCursor() error {
+	path := filepath.Join(s.opts.Dir, cursorFile)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "%d %d\n", s.readSeq, s.readOff); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	if s.opts.Fsync == FsyncAlways {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to sync spool cursor: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace spool cursor: %w", err)
+	}
+	return nil
+}
+
+// scan counts the intact records of a segment from an offset
+// Returns the record count, the offset after the last intact record and the file size
+func scan(path string, from int64) (int64, int64, int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to stat spool segment: %w", err)
+	}
+	size := info.Size()
+	if from > size {
+		from = size
+	}
+
+	var records int64
+	end := from
+	for {
+		batch, ends, err := readRecords(path, end, size, 1000)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if len(batch) == 0 {
+			return records, end, size, nil
+		}
+		records += int64(len(batch))
+		end = ends[len(ends)-1]
+	}
+}
+
+// readRecords reads up to n intact records between offset and limit
+// Reading stops quietly at a truncated or corrupt record
+func readRecords(path string, offset, limit int64, n int) ([]Record, []int64, error) {
+	if offset >= limit {
+		return nil, nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	r := io.NewSectionReader(f, offset, limit-offset)
+	records := []Record{}
+	ends := []int64{}
+	pos := offset
+	header := make([]byte, headerSize)
+
+	for len(records) < n {
+		if _, err := io.ReadFull(r, header); err != nil {
+			break
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		if length > maxRecordSize {
+			break
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			break
+		}
+
+		var rec Record
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			break
+		}
+		pos += headerSize + int64(length)
+		records = append(records, rec)
+		ends = append(ends, pos)
+	}
+
+	return records, ends, nil
+}
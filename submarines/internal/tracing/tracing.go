@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/nodepulse/admiral/submarines/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created by submarines services
+const tracerName = "github.com/nodepulse/admiral/submarines"
+
+// propagator carries W3C trace context (traceparent / tracestate)
+// in HTTP headers and Valkey stream message fields
+var propagator = propagation.TraceContext{}
+
+// Init installs the tracer provider of a service
+// Spans are exported over OTLP/HTTP when cfg.OTLPEndpoint is set (the exporter also reads
+// the other standard OTEL_EXPORTER_OTLP_* variables, e.g. headers or timeout)
+// Without an endpoint trace IDs are still generated and propagated, so logs can be correlated
+// The returned function flushes pending spans and must be called on shutdown
+func Init(ctx context.Context, cfg *config.Config, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	ratio := min(max(cfg.TraceSampleRatio, 0), 1)
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	}
+
+	if cfg.OTLPEndpoint != "" {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+		log.Printf("[INFO] Exporting traces to %s (sample ratio %.2f)", cfg.OTLPEndpoint, ratio)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used by submarines services
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Inject writes the trace context of ctx into stream message fields
+func Inject(ctx context.Context, fields map[string]string) {
+	propagator.Inject(ctx, propagation.MapCarrier(fields))
+}
+
+// Extract continues the trace carried by stream message fields
+func Extract(ctx context.Context, fields map[string]string) context.Context {
+	return propagator.Extract(ctx, propagation.MapCarrier(fields))
+}
+
+// ExtractHeaders continues a trace started by the caller of an HTTP request (e.g. an agent)
+func ExtractHeaders(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// End records err on a span (if any) and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the trace ID of ctx for log correlation, empty when there is none
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
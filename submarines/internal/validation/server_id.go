@@ -44,13 +44,15 @@ func (v *ServerIDValidator) ValidateServerID(ctx context.Context, serverID strin
 
 	cacheKey := fmt.Sprintf("server:valid:%s", serverID)
 
-	// 1. Check Valkey cache first
-	cached := v.valkey.Do(ctx, v.valkey.B().Get().Key(cacheKey).Build())
-	if cached.Error() == nil {
-		val, err := cached.ToString()
-		if err == nil {
-			// Cache hit - return cached result
-			return val == "true", nil
+	// 1. Check Valkey cache first (no cache without Valkey, e.g. embedded queue backend)
+	if v.valkey != nil {
+		cached := v.valkey.Do(ctx, v.valkey.B().Get().Key(cacheKey).Build())
+		if cached.Error() == nil {
+			val, err := cached.ToString()
+			if err == nil {
+				// Cache hit - return cached result
+				return val == "true", nil
+			}
 		}
 	}
 
@@ -65,6 +67,10 @@ func (v *ServerIDValidator) ValidateServerID(ctx context.Context, serverID strin
 		return false, fmt.Errorf("database query failed: %w", err)
 	}
 
+	if v.valkey == nil {
+		return exists, nil
+	}
+
 	// 3. Cache the result (both valid AND invalid with same TTL)
 	cacheValue := "false"
 	if exists {
@@ -82,6 +88,9 @@ func (v *ServerIDValidator) ValidateServerID(ctx context.Context, serverID strin
 // Useful when a server is deleted or added
 func (v *ServerIDValidator) InvalidateCache(ctx context.Context, serverID string) error {
 	cacheKey := fmt.Sprintf("server:valid:%s", serverID)
+	if v.valkey == nil {
+		return nil
+	}
 	delCmd := v.valkey.B().Del().Key(cacheKey).Build()
 
 	result := v.valkey.Do(ctx, delCmd)
@@ -20,7 +20,7 @@ type PendingMessage struct {
 func (c *Client) XPending(ctx context.Context, stream, group string, count int64) ([]PendingMessage, error) {
 	// XPENDING stream group START END COUNT
 	cmd := c.client.B().Xpending().
-		Key(stream).
+		Key(c.key(stream)).
 		Group(group).
 		Start("-").
 		End("+").
@@ -74,7 +74,7 @@ func (c *Client) XRange(ctx context.Context, stream string, messageIDs ...string
 	// Fetch each message by ID
 	messages := []StreamMessage{}
 	for _, id := range messageIDs {
-		cmd := c.client.B().Xrange().Key(stream).Start(id).End(id).Build()
+		cmd := c.client.B().Xrange().Key(c.key(stream)).Start(id).End(id).Build()
 		result := c.client.Do(ctx, cmd)
 
 		if err := result.Error(); err != nil {
@@ -123,7 +123,7 @@ func (c *Client) MoveToDLQ(ctx context.Context, sourceStream, dlqStream, message
 
 // GetDLQMessages retrieves messages from the dead letter queue
 func (c *Client) GetDLQMessages(ctx context.Context, dlqStream string, count int64) ([]StreamMessage, error) {
-	cmd := c.client.B().Xrange().Key(dlqStream).Start("-").End("+").Count(count).Build()
+	cmd := c.client.B().Xrange().Key(c.key(dlqStream)).Start("-").End("+").Count(count).Build()
 	result := c.client.Do(ctx, cmd)
 
 	if err := result.Error(); err != nil {
@@ -0,0 +1,183 @@
+package valkey
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+// StreamInfo describes a stream and the consumer groups reading it (XINFO STREAM/GROUPS/CONSUMERS)
+type StreamInfo struct {
+	Stream          string          `json:"stream"`
+	Exists          bool            `json:"exists"`
+	Length          int64           `json:"length"`
+	LastGeneratedID string          `json:"last_generated_id,omitempty"`
+	EntriesAdded    int64           `json:"entries_added"`
+	FirstEntry      *EntryInfo      `json:"first_entry"`
+	LastEntry       *EntryInfo      `json:"last_entry"`
+	Groups          []ConsumerGroup `json:"groups"`
+}
+
+// EntryInfo is the ID and age of a stream entry
+type EntryInfo struct {
+	ID         string    `json:"id"`
+	AddedAt    time.Time `json:"added_at"`
+	AgeSeconds float64   `json:"age_seconds"`
+}
+
+// ConsumerGroup is the progress of one consumer group
+// Lag (entries not yet delivered to the group) is nil when Valkey can't compute it,
+// e.g. after entries were deleted from the middle of the stream
+type ConsumerGroup struct {
+	Name            string     `json:"name"`
+	LastDeliveredID string     `json:"last_delivered_id"`
+	EntriesRead     *int64     `json:"entries_read"`
+	Lag             *int64     `json:"lag"`
+	Pending         int64      `json:"pending"` // Delivered but not acknowledged
+	Consumers       []Consumer `json:"consumers"`
+}
+
+// Consumer is one member of a consumer group
+type Consumer struct {
+	Name       string `json:"name"`
+	Pending    int64  `json:"pending"`
+	IdleMs     int64  `json:"idle_ms"`     // Since the last attempted interaction
+	InactiveMs *int64 `json:"inactive_ms"` // Since the last successful read (Valkey 7.2+)
+}
+
+// StreamInfo reports the length, first/last entries and consumer groups of a stream
+// A missing stream is reported with Exists = false rather than an error
+func (c *Client) StreamInfo(ctx context.Context, stream string) (*StreamInfo, error) {
+	info := &StreamInfo{Stream: stream, Groups: []ConsumerGroup{}}
+	key := c.key(stream)
+
+	result := c.client.Do(ctx, c.client.B().XinfoStream().Key(key).Build())
+	if err := result.Error(); err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return info, nil
+		}
+		return nil, fmt.Errorf("failed to get info of stream %s: %w", stream, err)
+	}
+	fields, err := result.AsMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse info of stream %s: %w", stream, err)
+	}
+
+	now := time.Now()
+	info.Exists = true
+	info.Length = int64Field(fields, "length")
+	info.LastGeneratedID = stringField(fields, "last-generated-id")
+	info.EntriesAdded = int64Field(fields, "entries-added")
+	info.FirstEntry = entryField(fields, "first-entry", now)
+	info.LastEntry = entryField(fields, "last-entry", now)
+
+	result = c.client.Do(ctx, c.client.B().XinfoGroups().Key(key).Build())
+	groups, err := result.ToArray()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consumer groups of stream %s: %w", stream, err)
+	}
+
+	for _, g := range groups {
+		gf, err := g.AsMap()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse consumer group of stream %s: %w", stream, err)
+		}
+		group := ConsumerGroup{
+			Name:            stringField(gf, "name"),
+			LastDeliveredID: stringField(gf, "last-delivered-id"),
+			EntriesRead:     optionalInt64Field(gf, "entries-read"),
+			Lag:             optionalInt64Field(gf, "lag"),
+			Pending:         int64Field(gf, "pending"),
+			Consumers:       []Consumer{},
+		}
+
+		result := c.client.Do(ctx, c.client.B().XinfoConsumers().Key(key).Group(group.Name).Build())
+		consumers, err := result.ToArray()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get consumers of group %s: %w", group.Name, err)
+		}
+		for _, m := range consumers {
+			cf, err := m.AsMap()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse consumer of group %s: %w", group.Name, err)
+			}
+			group.Consumers = append(group.Consumers, Consumer{
+				Name:       stringField(cf, "name"),
+				Pending:    int64Field(cf, "pending"),
+				IdleMs:     int64Field(cf, "idle"),
+				InactiveMs: optionalInt64Field(cf, "inactive"),
+			})
+		}
+
+		info.Groups = append(info.Groups, group)
+	}
+
+	return info, nil
+}
+
+// StreamIDTime returns when a stream entry was added (the millisecond part of its ID)
+func StreamIDTime(id string) (time.Time, bool) {
+	ms, _, ok := strings.Cut(id, "-")
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(n), true
+}
+
+func stringField(fields map[string]valkey.ValkeyMessage, name string) string {
+	v, ok := fields[name]
+	if !ok {
+		return ""
+	}
+	s, _ := v.ToString()
+	return s
+}
+
+func int64Field(fields map[string]valkey.ValkeyMessage, name string) int64 {
+	v, ok := fields[name]
+	if !ok {
+		return 0
+	}
+	n, _ := v.AsInt64()
+	return n
+}
+
+// optionalInt64Field returns nil for missing or nil fields
+func optionalInt64Field(fields map[string]valkey.ValkeyMessage, name string) *int64 {
+	v, ok := fields[name]
+	if !ok || v.IsNil() {
+		return nil
+	}
+	n, err := v.AsInt64()
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// entryField parses a [id, fields] entry, nil for an empty stream
+func entryField(fields map[string]valkey.ValkeyMessage, name string, now time.Time) *EntryInfo {
+	v, ok := fields[name]
+	if !ok || v.IsNil() {
+		return nil
+	}
+	entry, err := v.AsXRangeEntry()
+	if err != nil {
+		return nil
+	}
+
+	info := &EntryInfo{ID: entry.ID}
+	if addedAt, ok := StreamIDTime(entry.ID); ok {
+		info.AddedAt = addedAt.UTC()
+		info.AgeSeconds = max(now.Sub(addedAt).Seconds(), 0)
+	}
+	return info
+}
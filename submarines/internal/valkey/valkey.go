@@ -2,42 +2,153 @@ package valkey
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nodepulse/admiral/submarines/internal/config"
 	"github.com/valkey-io/valkey-go"
 )
 
+// Deployment modes (VALKEY_MODE)
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// streamHashTag puts every stream key in the same cluster hash slot, so a stream,
+// its DLQ and multi-key commands across them are served by one node
+const streamHashTag = "{nodepulse}"
+
+// ErrUnreachable wraps connection failures of New (as opposed to configuration errors)
+var ErrUnreachable = errors.New("valkey unreachable")
+
 type Client struct {
-	client valkey.Client
+	client  valkey.Client
+	cluster bool
 }
 
-// GetClient returns the underlying Valkey client
+// GetClient returns the underlying Valkey client (nil for a nil Client)
 func (c *Client) GetClient() valkey.Client {
+	if c == nil {
+		return nil
+	}
 	return c.client
 }
 
 func New(cfg *config.Config) (*Client, error) {
-	client, err := valkey.NewClient(valkey.ClientOption{
-		InitAddress: []string{cfg.GetValkeyAddress()},
-		Password:    cfg.ValkeyPassword,
-	})
+	opt, err := clientOption(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create valkey client: %w", err)
+		return nil, err
+	}
+
+	client, err := valkey.NewClient(opt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create valkey client: %w: %w", ErrUnreachable, err)
 	}
 
 	// Test connection
 	ctx := context.Background()
 	pong := client.Do(ctx, client.B().Ping().Build())
 	if err := pong.Error(); err != nil {
-		return nil, fmt.Errorf("failed to ping valkey: %w", err)
+		client.Close()
+		return nil, fmt.Errorf("failed to ping valkey: %w: %w", ErrUnreachable, err)
 	}
 
-	log.Println("Connected to Valkey successfully")
+	log.Printf("Connected to Valkey successfully (%s mode)", cfg.ValkeyMode)
 
-	return &Client{client: client}, nil
+	return &Client{client: client, cluster: cfg.ValkeyMode == ModeCluster}, nil
+}
+
+// clientOption builds the connection options for the configured deployment mode
+func clientOption(cfg *config.Config) (valkey.ClientOption, error) {
+	tlsConfig, err := tlsConfig(cfg)
+	if err != nil {
+		return valkey.ClientOption{}, err
+	}
+
+	opt := valkey.ClientOption{
+		InitAddress: cfg.GetValkeyAddresses(),
+		Username:    cfg.ValkeyUsername,
+		Password:    cfg.ValkeyPassword,
+		TLSConfig:   tlsConfig,
+	}
+
+	switch cfg.ValkeyMode {
+	case ModeStandalone, "":
+		opt.InitAddress = []string{cfg.GetValkeyAddress()}
+		opt.ForceSingleClient = true
+	case ModeSentinel:
+		// Sentinels share the data nodes' TLS settings but have their own credentials
+		opt.Sentinel = valkey.SentinelOption{
+			MasterSet: cfg.ValkeySentinelMaster,
+			Username:  cfg.ValkeySentinelUsername,
+			Password:  cfg.ValkeySentinelPassword,
+			TLSConfig: tlsConfig,
+		}
+	case ModeCluster:
+		// The client discovers the remaining nodes and slot layout from any seed node
+		opt.ShuffleInit = true
+	default:
+		return valkey.ClientOption{}, fmt.Errorf("unsupported VALKEY_MODE %q (expected %s, %s or %s)",
+			cfg.ValkeyMode, ModeStandalone, ModeSentinel, ModeCluster)
+	}
+
+	return opt, nil
+}
+
+// tlsConfig returns the TLS settings for Valkey connections, nil when TLS is disabled
+func tlsConfig(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.ValkeyTLS {
+		return nil, nil
+	}
+
+	tc := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ValkeyTLSServerName,
+	}
+
+	if cfg.ValkeyTLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.ValkeyTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read valkey CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in valkey CA file %s", cfg.ValkeyTLSCAFile)
+		}
+		tc.RootCAs = pool
+	}
+
+	if cfg.ValkeyTLSCertFile != "" || cfg.ValkeyTLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ValkeyTLSCertFile, cfg.ValkeyTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load valkey client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return tc, nil
+}
+
+// key maps a stream name to the key stored in Valkey
+// In cluster mode stream keys get a shared hash tag ("nodepulse:metrics:stream" -> "{nodepulse}:metrics:stream");
+// standalone and sentinel keep the plain names so existing streams stay readable
+func (c *Client) key(stream string) string {
+	if !c.cluster || strings.Contains(stream, "{") {
+		return stream
+	}
+	if rest, ok := strings.CutPrefix(stream, "nodepulse:"); ok {
+		return streamHashTag + ":" + rest
+	}
+	return streamHashTag + ":" + stream
 }
 
 func (c *Client) Get(ctx context.Context, key string) (string, error) {
@@ -99,12 +210,18 @@ func (c *Client) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// Publish sends a message to a Pub/Sub channel
+func (c *Client) Publish(ctx context.Context, channel, message string) error {
+	cmd := c.client.Do(ctx, c.client.B().Publish().Channel(channel).Message(message).Build())
+	return cmd.Error()
+}
+
 // XAdd publishes a message to a Redis/Valkey Stream (NO auto-trimming to prevent data loss)
 func (c *Client) XAdd(ctx context.Context, stream string, values map[string]string) (string, error) {
 	// Build XADD command WITHOUT MAXLEN - we don't want to lose data
 	// Backpressure protection happens at application level (reject when stream too long)
 	// Messages are removed only after digest workers ACK them
-	cmd := c.client.B().Xadd().Key(stream).Id("*").FieldValue()
+	cmd := c.client.B().Xadd().Key(c.key(stream)).Id("*").FieldValue()
 	for k, v := range values {
 		cmd = cmd.FieldValue(k, v)
 	}
@@ -118,7 +235,7 @@ func (c *Client) XAdd(ctx context.Context, stream string, values map[string]stri
 
 // XLen returns the number of entries in a stream
 func (c *Client) XLen(ctx context.Context, stream string) (int64, error) {
-	cmd := c.client.B().Xlen().Key(stream).Build()
+	cmd := c.client.B().Xlen().Key(c.key(stream)).Build()
 	result := c.client.Do(ctx, cmd)
 	if err := result.Error(); err != nil {
 		return 0, fmt.Errorf("failed to get stream length: %w", err)
@@ -128,7 +245,7 @@ func (c *Client) XLen(ctx context.Context, stream string) (int64, error) {
 
 // XReadGroup reads messages from a Redis/Valkey Stream using a consumer group
 func (c *Client) XReadGroup(ctx context.Context, group, consumer, stream, id string, count int64) ([]StreamMessage, error) {
-	cmd := c.client.B().Xreadgroup().Group(group, consumer).Count(count).Block(5000).Streams().Key(stream).Id(id).Build()
+	cmd := c.client.B().Xreadgroup().Group(group, consumer).Count(count).Block(5000).Streams().Key(c.key(stream)).Id(id).Build()
 	result := c.client.Do(ctx, cmd)
 
 	// Check for errors
@@ -167,22 +284,43 @@ func (c *Client) XReadGroup(ctx context.Context, group, consumer, stream, id str
 
 // XAck acknowledges messages in a consumer group
 func (c *Client) XAck(ctx context.Context, stream, group string, ids ...string) error {
-	cmd := c.client.B().Xack().Key(stream).Group(group).Id(ids...).Build()
+	cmd := c.client.B().Xack().Key(c.key(stream)).Group(group).Id(ids...).Build()
 	result := c.client.Do(ctx, cmd)
 	return result.Error()
 }
 
+// XClaim transfers pending messages idle for at least minIdle to another consumer
+// Returns the claimed messages (their delivery count is incremented)
+func (c *Client) XClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, ids ...string) ([]StreamMessage, error) {
+	if len(ids) == 0 {
+		return []StreamMessage{}, nil
+	}
+
+	cmd := c.client.B().Xclaim().Key(c.key(stream)).Group(group).Consumer(consumer).
+		MinIdleTime(strconv.FormatInt(minIdle.Milliseconds(), 10)).Id(ids...).Build()
+	entries, err := c.client.Do(ctx, cmd).AsXRange()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim messages from stream %s: %w", stream, err)
+	}
+
+	messages := make([]StreamMessage, 0, len(entries))
+	for _, entry := range entries {
+		messages = append(messages, StreamMessage{ID: entry.ID, Fields: entry.FieldValues})
+	}
+	return messages, nil
+}
+
 // XDel deletes messages from a stream by their IDs
 // This should be called after XAck to free memory
 func (c *Client) XDel(ctx context.Context, stream string, ids ...string) error {
-	cmd := c.client.B().Xdel().Key(stream).Id(ids...).Build()
+	cmd := c.client.B().Xdel().Key(c.key(stream)).Id(ids...).Build()
 	result := c.client.Do(ctx, cmd)
 	return result.Error()
 }
 
 // XGroupCreate creates a consumer group for a stream
 func (c *Client) XGroupCreate(ctx context.Context, stream, group string, startID string) error {
-	cmd := c.client.B().XgroupCreate().Key(stream).Group(group).Id(startID).Mkstream().Build()
+	cmd := c.client.B().XgroupCreate().Key(c.key(stream)).Group(group).Id(startID).Mkstream().Build()
 	result := c.client.Do(ctx, cmd)
 	// Ignore "BUSYGROUP" error (group already exists)
 	if err := result.Error(); err != nil {